@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"youtube-deck-go/internal/backup"
+	"youtube-deck-go/internal/db/migrations"
+)
+
+const backupUsage = `usage: server backup [-json] <file>
+
+Writes a backup of the database to file, which must not exist yet: a
+consistent copy of the SQLite database, or with -json the portable backup
+of subscriptions, decks, settings, watched videos and the queue. The server
+may keep running meanwhile.
+
+The database is read from DB_PATH (default data.db).`
+
+const restoreUsage = `usage: server restore [-merge] <file>
+
+Restores a backup made by the backup subcommand or downloaded from
+/admin/backup, telling a database copy from a JSON backup by its content.
+Stop the server first.
+
+The backup replaces the database, which is kept as <database>.before-restore.
+With -merge it is added to the database instead: the subscriptions, decks,
+columns, mute rules, watched videos and queued videos the database lacks
+are added, and everything else is left as it is.
+
+Backups made by a newer release are refused; older ones are migrated.
+The database is DB_PATH (default data.db).`
+
+// runBackup implements the backup subcommand and returns the exit code.
+func runBackup(args []string) int {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, backupUsage) }
+	asJSON := flags.Bool("json", false, "")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	out := flags.Arg(0)
+
+	dbPath := databasePath()
+	if _, err := os.Stat(dbPath); err != nil {
+		fmt.Fprintf(os.Stderr, "no database at %s\n", dbPath)
+		return 1
+	}
+	database, err := sql.Open("sqlite", dataSourceName(dbPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "open database: %v\n", err)
+		return 1
+	}
+	defer database.Close()
+	database.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	if *asJSON {
+		err = writeJSONBackup(ctx, database, out)
+	} else {
+		err = backup.Snapshot(ctx, database, out)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("backed up %s to %s\n", dbPath, out)
+	return 0
+}
+
+func writeJSONBackup(ctx context.Context, database *sql.DB, path string) error {
+	doc, err := backup.Export(ctx, database)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	err = backup.Write(f, doc)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// runRestore implements the restore subcommand and returns the exit code.
+func runRestore(args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprintln(os.Stderr, restoreUsage) }
+	merge := flags.Bool("merge", false, "")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if err := restore(context.Background(), flags.Arg(0), databasePath(), *merge); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func restore(ctx context.Context, in, dbPath string, merge bool) error {
+	// The backup is restored into a new database next to the current one,
+	// which is only replaced once that succeeded.
+	staged := dbPath + ".restore"
+	if err := os.Remove(staged); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	defer os.Remove(staged)
+
+	snapshot, err := isDatabase(in)
+	if err != nil {
+		return err
+	}
+	var doc *backup.Document
+	if snapshot {
+		// A copy is migrated, leaving the backup as it was.
+		if err := copyFile(in, staged); err != nil {
+			return err
+		}
+		database, err := openSnapshot(ctx, staged)
+		if err != nil {
+			return fmt.Errorf("%s: %w", in, err)
+		}
+		if !merge {
+			database.Close()
+			return replaceDatabase(staged, dbPath)
+		}
+		doc, err = backup.Export(ctx, database)
+		database.Close()
+		if err != nil {
+			return err
+		}
+	} else {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		doc, err = backup.Read(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", in, err)
+		}
+	}
+
+	target := dbPath
+	if !merge {
+		target = staged
+	}
+	database, err := openDatabase(target)
+	if err != nil {
+		return err
+	}
+	stats, err := backup.Restore(ctx, database, doc, !merge)
+	if closeErr := database.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("restored %d subscriptions (%d already there), %d decks, %d watched videos and %d queued videos\n",
+		stats.Subscriptions, stats.Skipped, stats.Decks, stats.Watched, stats.Queued)
+	if merge {
+		return nil
+	}
+	return replaceDatabase(staged, dbPath)
+}
+
+// openSnapshot opens a database snapshot, refusing ones of a later schema
+// and migrating earlier ones.
+func openSnapshot(ctx context.Context, path string) (*sql.DB, error) {
+	database, err := sql.Open("sqlite", dataSourceName(path))
+	if err != nil {
+		return nil, err
+	}
+	database.SetMaxOpenConns(1)
+	version, err := backup.SchemaVersion(ctx, database)
+	if err == nil && version == 0 {
+		err = errors.New("not a deck database")
+	}
+	if err == nil {
+		err = backup.CheckSchema(version)
+	}
+	if err == nil {
+		_, err = migrations.Up(ctx, database)
+	}
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+	return database, nil
+}
+
+// replaceDatabase moves the restored database at staged to path, keeping the
+// database it replaces as path.before-restore.
+func replaceDatabase(staged, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		previous := path + ".before-restore"
+		if err := os.Rename(path, previous); err != nil {
+			return err
+		}
+		fmt.Printf("kept the previous database as %s\n", previous)
+	}
+	if err := os.Rename(staged, path); err != nil {
+		return err
+	}
+	fmt.Printf("restored %s\n", path)
+	return nil
+}
+
+// isDatabase tells whether the file at path is an SQLite database.
+func isDatabase(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false, nil
+	}
+	return bytes.Equal(header, []byte("SQLite format 3\x00")), nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
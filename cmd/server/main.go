@@ -3,23 +3,43 @@ package main
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	"youtube-deck-go/internal/auth"
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+	"youtube-deck-go/internal/events"
 	"youtube-deck-go/internal/handlers"
+	"youtube-deck-go/internal/jobs"
 	"youtube-deck-go/internal/middleware"
+	"youtube-deck-go/internal/quota"
+	"youtube-deck-go/internal/scheduler"
 	"youtube-deck-go/internal/youtube"
 
 	_ "modernc.org/sqlite"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "backup":
+			os.Exit(runBackup(os.Args[2:]))
+		case "restore":
+			os.Exit(runRestore(os.Args[2:]))
+		}
+	}
+
 	apiKey := os.Getenv("YOUTUBE_API_KEY")
 	if apiKey == "" {
 		log.Fatal("YOUTUBE_API_KEY environment variable is required")
@@ -30,39 +50,24 @@ func main() {
 		port = "8080"
 	}
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "data.db"
-	}
+	dbPath := databasePath()
 
 	clientSecretFile := os.Getenv("GOOGLE_CLIENT_SECRET")
 	if clientSecretFile == "" {
 		clientSecretFile = "client_secret.json"
 	}
 
-	database, err := sql.Open("sqlite", dbPath)
+	database, err := openDatabase(dbPath)
 	if err != nil {
 		log.Fatalf("failed to open database: %v", err)
 	}
 	defer database.Close()
 
-	database.SetMaxOpenConns(1)
-	database.SetMaxIdleConns(1)
-	database.SetConnMaxLifetime(0)
+	ledger := quota.NewLedger(db.New(database),
+		int64(envInt("QUOTA_DAILY_LIMIT", quota.DefaultDailyLimit)),
+		int64(envInt("QUOTA_SOFT_LIMIT", quota.DefaultSoftLimit)))
 
-	if _, err := database.Exec(schema); err != nil {
-		log.Fatalf("failed to create schema: %v", err)
-	}
-
-	for _, stmt := range migrations {
-		if _, err := database.Exec(stmt); err != nil {
-			if !isAlterTableDuplicate(err) {
-				log.Printf("migration warning: %v", err)
-			}
-		}
-	}
-
-	ytClient, err := youtube.New(apiKey)
+	ytClient, err := youtube.New(apiKey, ledger)
 	if err != nil {
 		log.Fatalf("failed to create youtube client: %v", err)
 	}
@@ -81,45 +86,79 @@ func main() {
 	}
 
 	logger := slog.Default()
-	h := handlers.New(database, ytClient, authMgr, logger)
-
-	mux := http.NewServeMux()
-
-	// Serve static files (CSS, JS, images)
-	staticFS := http.FileServer(http.Dir("static"))
-	mux.Handle("GET /static/", http.StripPrefix("/static/", staticFS))
-
-	mux.HandleFunc("GET /{$}", h.HandleDeck)
-	mux.HandleFunc("GET /search", h.HandleSearch)
-	mux.HandleFunc("GET /search/results", h.HandleSearchResults)
-	mux.HandleFunc("GET /search/close", h.HandleSearchClose)
-	mux.HandleFunc("POST /subscriptions", h.HandleAddSubscription)
-	mux.HandleFunc("GET /subscriptions/filter", h.HandleFilterSubscriptions)
-	mux.HandleFunc("POST /subscriptions/reorder", h.HandleReorder)
-	mux.HandleFunc("DELETE /subscriptions/{id}", h.HandleDeleteSubscription)
-	mux.HandleFunc("GET /subscriptions/{id}/videos", h.HandleVideos)
-	mux.HandleFunc("GET /subscriptions/{id}/column", h.HandleColumnVideos)
-	mux.HandleFunc("POST /subscriptions/{id}/refresh", h.HandleRefreshSubscription)
-	mux.HandleFunc("POST /subscriptions/{id}/fetch-more", h.HandleFetchMoreVideos)
-	mux.HandleFunc("PATCH /subscriptions/{id}/active", h.HandleToggleActive)
-	mux.HandleFunc("PATCH /subscriptions/{id}/hide-shorts", h.HandleToggleHideShorts)
-	mux.HandleFunc("POST /videos/{id}/watched", h.HandleToggleWatched)
-	mux.HandleFunc("GET /proxy/image", h.HandleImageProxy)
-
+	hub := events.NewHub()
+	runner := jobs.NewRunner(db.New(database), logger)
+	if n, err := runner.Recover(context.Background()); err != nil {
+		log.Printf("recover jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("failed %d jobs interrupted by the last shutdown", n)
+	}
+	h := handlers.New(database, ytClient, youtube.NewFeedFetcher(nil, youtube.DefaultFeedURL), authMgr, hub, runner, ledger, logger)
+	// REGION is the ISO 3166-1 country videos are watched from; region
+	// blocks are ignored without it.
+	h.SetRegion(os.Getenv("REGION"))
 	if authMgr != nil {
-		authH := handlers.NewAuthHandlers(authMgr, database)
-		mux.HandleFunc("GET /auth/login", authH.HandleLogin)
-		mux.HandleFunc("GET /auth/callback", authH.HandleCallback)
-		mux.HandleFunc("GET /auth/logout", authH.HandleLogout)
-		mux.HandleFunc("POST /import", authH.HandleImportSubscriptions)
+		h.SetAccount(youtube.NewAccount(authMgr.HTTPClient, ledger))
 	}
 
-	handler := middleware.CSRF(mux)
+	handler := routes(h, hub, authMgr)
 
 	server := &http.Server{
 		Addr:    ":" + port,
 		Handler: handler,
 	}
+	server.RegisterOnShutdown(hub.Close)
+
+	schedCfg := scheduler.DefaultConfig()
+	schedCfg.Interval = envDuration("REFRESH_INTERVAL", schedCfg.Interval)
+	schedCfg.Concurrency = envInt("REFRESH_CONCURRENCY", schedCfg.Concurrency)
+	sched := scheduler.New(db.New(database), h, schedCfg, logger)
+	shortsRetryInterval := envDuration("SHORTS_RETRY_INTERVAL", 5*time.Minute)
+	statsRefreshInterval := envDuration("STATS_REFRESH_INTERVAL", time.Hour)
+	availabilityInterval := envDuration("AVAILABILITY_CHECK_INTERVAL", 6*time.Hour)
+	playlistSyncInterval := envDuration("PLAYLIST_SYNC_INTERVAL", 6*time.Hour)
+	// Subscriptions are only synced with the YouTube account on demand
+	// unless SUBSCRIPTION_SYNC_INTERVAL is set.
+	subscriptionSyncInterval := envDuration("SUBSCRIPTION_SYNC_INTERVAL", 0)
+
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	schedDone := make(chan struct{})
+	go func() {
+		if err := h.BackfillChannelInfo(schedCtx); err != nil {
+			logger.Warn("backfill channel info", "error", err)
+		}
+		if err := h.BackfillDurations(schedCtx); err != nil {
+			logger.Warn("backfill durations", "error", err)
+		}
+		var periodic sync.WaitGroup
+		periodic.Add(4)
+		go func() {
+			defer periodic.Done()
+			scheduler.Every(schedCtx, shortsRetryInterval, "retry unknown shorts", logger, h.RetryUnknownShorts)
+		}()
+		go func() {
+			defer periodic.Done()
+			scheduler.Every(schedCtx, statsRefreshInterval, "refresh video statistics", logger, h.RefreshVideoStatistics)
+		}()
+		go func() {
+			defer periodic.Done()
+			scheduler.Every(schedCtx, availabilityInterval, "reconcile video availability", logger, h.ReconcileAvailability)
+		}()
+		go func() {
+			defer periodic.Done()
+			scheduler.Every(schedCtx, playlistSyncInterval, "sync playlist positions", logger, h.SyncPlaylistPositions)
+		}()
+		if authMgr != nil && subscriptionSyncInterval > 0 {
+			periodic.Add(1)
+			go func() {
+				defer periodic.Done()
+				scheduler.Every(schedCtx, subscriptionSyncInterval, "sync YouTube subscriptions", logger, h.SyncAccountSubscriptions)
+			}()
+		}
+		sched.Run(schedCtx)
+		periodic.Wait()
+		close(schedDone)
+	}()
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
@@ -141,6 +180,14 @@ func main() {
 		log.Fatalf("server shutdown failed: %v", err)
 	}
 
+	stopScheduler()
+	runner.Close()
+	select {
+	case <-schedDone:
+	case <-ctx.Done():
+		log.Println("scheduler did not stop in time")
+	}
+
 	if err := database.Close(); err != nil {
 		log.Printf("database close error: %v", err)
 	}
@@ -148,65 +195,161 @@ func main() {
 	log.Println("Server stopped")
 }
 
-// isAlterTableDuplicate checks if an ALTER TABLE error is due to a duplicate column.
-// This uses string matching which is SQLite-specific. For production use with
-// multiple database backends, consider using a proper migration library.
-func isAlterTableDuplicate(err error) bool {
-	return err != nil && (contains(err.Error(), "duplicate column") || contains(err.Error(), "already exists"))
+// openDatabase opens the SQLite database at path and applies pending
+// migrations.
+func openDatabase(path string) (*sql.DB, error) {
+	database, err := sql.Open("sqlite", dataSourceName(path))
+	if err != nil {
+		return nil, err
+	}
+
+	database.SetMaxOpenConns(1)
+	database.SetMaxIdleConns(1)
+	database.SetConnMaxLifetime(0)
+
+	applied, err := migrations.Up(context.Background(), database)
+	if err != nil {
+		database.Close()
+		return nil, err
+	}
+	for _, m := range applied {
+		log.Printf("applied migration %04d_%s", m.Version, m.Name)
+	}
+	// Migrations can change which videos the mute rules hide: the one
+	// adding videos.muted leaves it unset for the videos stored before.
+	if len(applied) > 0 {
+		if err := db.New(database).UpdateMutedVideos(context.Background(), sql.NullInt64{}); err != nil {
+			database.Close()
+			return nil, fmt.Errorf("update muted videos: %w", err)
+		}
+	}
+	return database, nil
+}
+
+// databasePath is where the database lives: DB_PATH, by default data.db.
+func databasePath() string {
+	if path := os.Getenv("DB_PATH"); path != "" {
+		return path
+	}
+	return "data.db"
 }
 
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsAt(s, substr))
+// dataSourceName turns on foreign keys, which SQLite leaves off by default,
+// so that deleting a row cascades to the rows referencing it.
+func dataSourceName(path string) string {
+	return path + "?_pragma=foreign_keys(1)"
 }
 
-func containsAt(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+// routes registers every endpoint on a new mux. The auth endpoints are only
+// served when OAuth is configured.
+func routes(h *handlers.Handlers, hub *events.Hub, authMgr *auth.Manager) http.Handler {
+	mux := http.NewServeMux()
+
+	// Serve static files (CSS, JS, images)
+	staticFS := http.FileServer(http.Dir("static"))
+	mux.Handle("GET /static/", http.StripPrefix("/static/", staticFS))
+
+	mux.HandleFunc("GET /{$}", h.HandleDeck)
+	mux.HandleFunc("GET /decks/{id}", h.HandleShowDeck)
+	mux.HandleFunc("POST /decks/{id}/select", h.HandleSelectDeck)
+	mux.HandleFunc("POST /decks", h.HandleCreateDeck)
+	mux.HandleFunc("DELETE /decks/{id}", h.HandleDeleteDeck)
+	mux.HandleFunc("POST /groups", h.HandleCreateGroup)
+	mux.HandleFunc("DELETE /groups/{id}", h.HandleDeleteGroup)
+	mux.Handle("GET /events", hub)
+	mux.HandleFunc("GET /search", h.HandleSearch)
+	mux.HandleFunc("GET /search/results", h.HandleSearchResults)
+	mux.HandleFunc("GET /search/close", h.HandleSearchClose)
+	mux.HandleFunc("GET /library", h.HandleLibrarySearchModal)
+	mux.HandleFunc("GET /library/search", h.HandleLibrarySearch)
+	mux.HandleFunc("GET /import", h.HandleImportModal)
+	mux.HandleFunc("POST /import/opml", h.HandleImportOPML)
+	mux.HandleFunc("POST /import/takeout", h.HandleImportTakeout)
+	mux.HandleFunc("GET /export/subscriptions.opml", h.HandleExportOPML)
+	mux.HandleFunc("POST /import/apps", h.HandleImportApps)
+	mux.HandleFunc("GET /export/newpipe.json", h.HandleExportNewPipe)
+	mux.HandleFunc("GET /export/freetube-profiles.db", h.HandleExportFreeTubeProfiles)
+	mux.HandleFunc("GET /export/freetube-history.db", h.HandleExportFreeTubeHistory)
+	mux.HandleFunc("GET /export/invidious.json", h.HandleExportInvidious)
+	mux.HandleFunc("GET /admin/backup", h.HandleBackup)
+	mux.HandleFunc("GET /jobs", h.HandleJobs)
+	mux.HandleFunc("GET /jobs/{id}", h.HandleJob)
+	mux.HandleFunc("POST /jobs/{id}/cancel", h.HandleCancelJob)
+	mux.HandleFunc("POST /jobs/{id}/dismiss", h.HandleDismissJob)
+	mux.HandleFunc("GET /jobs/{id}/report", h.HandleImportReport)
+	mux.HandleFunc("GET /sync", h.HandleSyncModal)
+	mux.HandleFunc("POST /sync", h.HandleSync)
+	mux.HandleFunc("POST /sync/changes/accept", h.HandleAcceptSyncChanges)
+	mux.HandleFunc("POST /sync/changes/reject", h.HandleRejectSyncChanges)
+	mux.HandleFunc("POST /sync/changes/{id}/accept", h.HandleAcceptSyncChange)
+	mux.HandleFunc("POST /sync/changes/{id}/reject", h.HandleRejectSyncChange)
+	mux.HandleFunc("POST /subscriptions", h.HandleAddSubscription)
+	mux.HandleFunc("GET /subscriptions/filter", h.HandleFilterSubscriptions)
+	mux.HandleFunc("POST /subscriptions/reorder", h.HandleReorder)
+	mux.HandleFunc("DELETE /subscriptions/{id}", h.HandleDeleteSubscription)
+	mux.HandleFunc("GET /subscriptions/{id}/videos", h.HandleVideos)
+	mux.HandleFunc("GET /subscriptions/{id}/column", h.HandleColumnVideos)
+	mux.HandleFunc("POST /subscriptions/{id}/refresh", h.HandleRefreshSubscription)
+	mux.HandleFunc("GET /subscriptions/{id}/reload", h.HandleReloadColumn)
+	mux.HandleFunc("PATCH /subscriptions/{id}/refresh-interval", h.HandleSetRefreshInterval)
+	mux.HandleFunc("POST /subscriptions/{id}/fetch-more", h.HandleFetchMoreVideos)
+	mux.HandleFunc("PATCH /subscriptions/{id}/active", h.HandleToggleActive)
+	mux.HandleFunc("PATCH /subscriptions/{id}/hide-shorts", h.HandleToggleHideShorts)
+	mux.HandleFunc("PATCH /subscriptions/{id}/duration-filter", h.HandleSetDurationFilter)
+	mux.HandleFunc("PATCH /subscriptions/{id}/upcoming", h.HandleSetUpcomingMode)
+	mux.HandleFunc("GET /subscriptions/{id}/upcoming", h.HandleUpcomingVideos)
+	mux.HandleFunc("POST /subscriptions/{id}/reclassify-shorts", h.HandleReclassifyShorts)
+	mux.HandleFunc("PATCH /subscriptions/{id}/sort", h.HandleSetSortOrder)
+	mux.HandleFunc("GET /subscriptions/{id}/mute-rules", h.HandleMuteRules)
+	mux.HandleFunc("POST /subscriptions/{id}/mute-rules", h.HandleCreateMuteRule)
+	mux.HandleFunc("GET /subscriptions/{id}/muted", h.HandleMutedVideos)
+	mux.HandleFunc("GET /subscriptions/{id}/gone", h.HandleGoneVideos)
+	mux.HandleFunc("DELETE /mute-rules/{id}", h.HandleDeleteMuteRule)
+	mux.HandleFunc("GET /subscriptions/{id}/groups", h.HandleSubscriptionGroups)
+	mux.HandleFunc("PUT /subscriptions/{id}/groups", h.HandleSetSubscriptionGroups)
+	mux.HandleFunc("POST /columns", h.HandleAddVirtualColumn)
+	mux.HandleFunc("GET /columns/{id}", h.HandleVirtualColumn)
+	mux.HandleFunc("DELETE /columns/{id}", h.HandleDeleteVirtualColumn)
+	mux.HandleFunc("GET /columns/{id}/videos", h.HandleVirtualColumnVideos)
+	mux.HandleFunc("POST /videos/{id}/watched", h.HandleToggleWatched)
+	mux.HandleFunc("POST /videos/{id}/queue", h.HandleEnqueueVideo)
+	mux.HandleFunc("DELETE /videos/{id}/queue", h.HandleDequeueVideo)
+	mux.HandleFunc("GET /proxy/image", h.HandleImageProxy)
+	mux.HandleFunc("GET /quota", h.HandleQuota)
+
+	if authMgr != nil {
+		authH := handlers.NewAuthHandlers(authMgr)
+		mux.HandleFunc("GET /auth/login", authH.HandleLogin)
+		mux.HandleFunc("GET /auth/callback", authH.HandleCallback)
+		mux.HandleFunc("GET /auth/logout", authH.HandleLogout)
+	}
+
+	return middleware.CSRF(mux)
+}
+
+// envDuration reads a time.Duration such as "15m" from the environment.
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("invalid %s %q, using %s", key, v, def)
+		return def
 	}
-	return false
+	return d
 }
 
-const schema = `
-CREATE TABLE IF NOT EXISTS subscriptions (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    name TEXT NOT NULL,
-    youtube_id TEXT NOT NULL UNIQUE,
-    type TEXT NOT NULL CHECK(type IN ('channel', 'playlist')),
-    thumbnail_url TEXT,
-    last_checked DATETIME,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    position INTEGER DEFAULT 0,
-    active INTEGER DEFAULT 0,
-    page_token TEXT,
-    hide_shorts INTEGER DEFAULT 0
-);
-
-CREATE TABLE IF NOT EXISTS videos (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
-    youtube_id TEXT NOT NULL UNIQUE,
-    title TEXT NOT NULL,
-    thumbnail_url TEXT,
-    duration TEXT,
-    published_at DATETIME,
-    watched INTEGER DEFAULT 0,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    is_short INTEGER DEFAULT 0
-);
-
-CREATE INDEX IF NOT EXISTS idx_videos_subscription ON videos(subscription_id);
-CREATE INDEX IF NOT EXISTS idx_videos_watched ON videos(watched);
-CREATE INDEX IF NOT EXISTS idx_videos_sub_watched_short ON videos(subscription_id, watched, is_short);
-CREATE INDEX IF NOT EXISTS idx_subscriptions_active_position ON subscriptions(active, position);
-`
-
-var migrations = []string{
-	"ALTER TABLE subscriptions ADD COLUMN position INTEGER DEFAULT 0",
-	"ALTER TABLE subscriptions ADD COLUMN active INTEGER DEFAULT 0",
-	"ALTER TABLE subscriptions ADD COLUMN page_token TEXT",
-	"ALTER TABLE subscriptions ADD COLUMN hide_shorts INTEGER DEFAULT 0",
-	"ALTER TABLE videos ADD COLUMN is_short INTEGER DEFAULT 0",
-	"CREATE INDEX IF NOT EXISTS idx_videos_sub_watched_short ON videos(subscription_id, watched, is_short)",
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("invalid %s %q, using %d", key, v, def)
+		return def
+	}
+	return n
 }
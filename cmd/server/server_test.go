@@ -0,0 +1,1812 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"youtube-deck-go/internal/backup"
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/events"
+	"youtube-deck-go/internal/handlers"
+	"youtube-deck-go/internal/interop"
+	"youtube-deck-go/internal/jobs"
+	"youtube-deck-go/internal/opml"
+	"youtube-deck-go/internal/quota"
+	"youtube-deck-go/internal/youtube"
+	"youtube-deck-go/internal/youtube/youtubetest"
+)
+
+const testCSRFToken = "test-token"
+
+type testServer struct {
+	t       *testing.T
+	handler http.Handler
+	h       *handlers.Handlers
+	db      *sql.DB
+	queries *db.Queries
+	yt      *youtubetest.Fake
+	ledger  *quota.Ledger
+	jobs    *jobs.Runner
+}
+
+// newTestServer serves the real routes against a fresh SQLite database in a
+// temp dir, with the YouTube API and feeds replaced by an in-memory fake.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	database, err := openDatabase(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("openDatabase: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	queries := db.New(database)
+	fake := youtubetest.NewFake()
+	ledger := quota.NewLedger(queries, 100, 50)
+	hub := events.NewHub()
+	t.Cleanup(hub.Close)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	runner := jobs.NewRunner(queries, logger)
+	t.Cleanup(runner.Close)
+
+	h := handlers.New(database, fake, fake, nil, hub, runner, ledger, logger)
+	return &testServer{
+		t:       t,
+		handler: routes(h, hub, nil),
+		h:       h,
+		db:      database,
+		queries: queries,
+		yt:      fake,
+		ledger:  ledger,
+		jobs:    runner,
+	}
+}
+
+// do sends a request through the full handler chain, including the CSRF
+// middleware, and returns the recorded response.
+func (s *testServer) do(method, path, contentType, body string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.AddCookie(&http.Cookie{Name: "csrf_token", Value: testCSRFToken})
+	req.Header.Set("X-CSRF-Token", testCSRFToken)
+	req.Header.Set("HX-Request", "true")
+
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *testServer) expectStatus(rec *httptest.ResponseRecorder, want int) {
+	s.t.Helper()
+	if rec.Code != want {
+		s.t.Fatalf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+func (s *testServer) createSubscription(youtubeID, name, kind string) db.Subscription {
+	s.t.Helper()
+	sub, err := s.queries.CreateSubscription(context.Background(), db.CreateSubscriptionParams{
+		YoutubeID: youtubeID,
+		Name:      name,
+		Type:      kind,
+	})
+	if err != nil {
+		s.t.Fatalf("CreateSubscription: %v", err)
+	}
+	return sub
+}
+
+// upload posts content as the file field of a multipart form, as a file
+// input would.
+func (s *testServer) upload(path, filename, content string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err == nil {
+		_, err = io.WriteString(part, content)
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		s.t.Fatalf("build upload: %v", err)
+	}
+	return s.do(http.MethodPost, path, form.FormDataContentType(), body.String())
+}
+
+// importReport waits for the import job rec started and returns its report.
+func (s *testServer) importReport(rec *httptest.ResponseRecorder) string {
+	s.t.Helper()
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `id="jobs" hx-swap-oob`) {
+		s.t.Fatalf("import response lacks the job toasts: %s", rec.Body.String())
+	}
+	s.jobs.Wait()
+	list, err := s.queries.ListUndismissedJobs(context.Background())
+	if err != nil || len(list) == 0 {
+		s.t.Fatalf("ListUndismissedJobs = %v, %v", list, err)
+	}
+	path := fmt.Sprintf("/jobs/%d", list[len(list)-1].ID)
+	toast := s.do(http.MethodGet, path, "", "")
+	s.expectStatus(toast, http.StatusOK)
+	if !strings.Contains(toast.Body.String(), path+"/report") {
+		s.t.Fatalf("import job toast lacks its report: %s", toast.Body.String())
+	}
+	report := s.do(http.MethodGet, path+"/report", "", "")
+	s.expectStatus(report, http.StatusOK)
+	return report.Body.String()
+}
+
+func (s *testServer) videoCount() int64 {
+	s.t.Helper()
+	var n int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM videos").Scan(&n); err != nil {
+		s.t.Fatalf("count videos: %v", err)
+	}
+	return n
+}
+
+// inDeck reports whether the subscription has a column on the deck.
+func (s *testServer) inDeck(deckID, subscriptionID int64) bool {
+	s.t.Helper()
+	in, err := s.queries.IsSubscriptionInDeck(context.Background(), db.IsSubscriptionInDeckParams{
+		DeckID:         deckID,
+		SubscriptionID: sql.NullInt64{Int64: subscriptionID, Valid: true},
+	})
+	if err != nil {
+		s.t.Fatalf("IsSubscriptionInDeck: %v", err)
+	}
+	return in == 1
+}
+
+// makeVideos returns n videos of prefix, newest first, one hour apart.
+func makeVideos(prefix string, n int) []youtube.VideoInfo {
+	base := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	videos := make([]youtube.VideoInfo, n)
+	for i := range videos {
+		videos[i] = youtube.VideoInfo{
+			ID:          fmt.Sprintf("%s%03d", prefix, i),
+			Title:       fmt.Sprintf("%s video %d", prefix, i),
+			Duration:    "PT5M",
+			PublishedAt: base.Add(-time.Duration(i) * time.Hour),
+		}
+	}
+	return videos
+}
+
+func TestDeckRenders(t *testing.T) {
+	s := newTestServer(t)
+	s.createSubscription("UCdeck", "Deck Channel", "channel")
+
+	rec := s.do(http.MethodGet, "/", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Deck Channel") {
+		t.Error("deck does not list the subscription")
+	}
+}
+
+func TestCSRFRequired(t *testing.T) {
+	s := newTestServer(t)
+	sub := s.createSubscription("UCcsrf", "CSRF", "channel")
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/subscriptions/%d", sub.ID), nil)
+	rec := httptest.NewRecorder()
+	s.handler.ServeHTTP(rec, req)
+	s.expectStatus(rec, http.StatusForbidden)
+}
+
+func TestAddSubscriptionFetchesVideos(t *testing.T) {
+	s := newTestServer(t)
+	ch := s.yt.AddChannel(youtube.ChannelInfo{ID: "UCadd", Title: "Added", CustomURL: "@added", SubscriberCount: 42})
+	s.yt.AddVideos(ch.UploadsPlaylistID, makeVideos("add", 5)...)
+
+	rec := s.do(http.MethodPost, "/subscriptions", "application/json",
+		`{"youtube_id":"UCadd","name":"Added","type":"channel"}`)
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Added") {
+		t.Errorf("response does not render the sidebar item: %s", rec.Body.String())
+	}
+
+	if got := s.videoCount(); got != 5 {
+		t.Errorf("stored %d videos, want 5", got)
+	}
+	subs, err := s.queries.ListSubscriptions(context.Background())
+	if err != nil || len(subs) != 1 {
+		t.Fatalf("ListSubscriptions = %v, %v", subs, err)
+	}
+	if got := subs[0].UploadsPlaylistID.String; got != ch.UploadsPlaylistID {
+		t.Errorf("uploads playlist = %q, want %q", got, ch.UploadsPlaylistID)
+	}
+	if !subs[0].LastChecked.Valid {
+		t.Error("last_checked not set")
+	}
+}
+
+func TestToggleActiveRendersColumn(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLactive", makeVideos("act", 3)...)
+	sub := s.createSubscription("PLactive", "Active Playlist", "playlist")
+
+	rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	for _, title := range []string{"act video 0", "act video 2"} {
+		if !strings.Contains(body, title) {
+			t.Errorf("column is missing %q", title)
+		}
+	}
+
+	if !s.inDeck(1, sub.ID) {
+		t.Error("subscription not added to the deck")
+	}
+
+	rec = s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=0", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if s.inDeck(1, sub.ID) {
+		t.Error("subscription still on the deck")
+	}
+}
+
+func TestFetchMoreVideosPaginates(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLmore", makeVideos("more", 45)...)
+	sub := s.createSubscription("PLmore", "More", "playlist")
+
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+	if got := s.videoCount(); got != 20 {
+		t.Fatalf("stored %d videos after activating, want 20", got)
+	}
+
+	path := fmt.Sprintf("/subscriptions/%d/fetch-more", sub.ID)
+	s.expectStatus(s.do(http.MethodPost, path, "", ""), http.StatusOK)
+	if got := s.videoCount(); got != 40 {
+		t.Fatalf("stored %d videos after first fetch-more, want 40", got)
+	}
+
+	rec := s.do(http.MethodPost, path, "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if got := s.videoCount(); got != 45 {
+		t.Fatalf("stored %d videos after second fetch-more, want 45", got)
+	}
+	got, err := s.queries.GetSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.PageToken.Valid {
+		t.Errorf("page token = %q, want none at the end of the playlist", got.PageToken.String)
+	}
+}
+
+func TestFetchMoreVideosRefusedWhenQuotaDegraded(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLbudget", makeVideos("budget", 30)...)
+	sub := s.createSubscription("PLbudget", "Budget", "playlist")
+
+	if err := s.ledger.Spend(context.Background(), "search.list", 60); err != nil {
+		t.Fatal(err)
+	}
+	rec := s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/fetch-more", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if n := s.yt.Calls("FetchPlaylistVideosWithToken"); n != 0 {
+		t.Errorf("fetched from the API %d times with the quota degraded", n)
+	}
+}
+
+func TestRefreshUsesFeed(t *testing.T) {
+	s := newTestServer(t)
+	ch := s.yt.AddChannel(youtube.ChannelInfo{ID: "UCfeed", Title: "Feed"})
+	videos := makeVideos("feed", 4)
+	s.yt.AddVideos(ch.UploadsPlaylistID, videos[1:]...)
+	sub := s.createSubscription("UCfeed", "Feed", "channel")
+
+	path := fmt.Sprintf("/subscriptions/%d/refresh", sub.ID)
+	s.expectStatus(s.do(http.MethodPost, path, "", ""), http.StatusOK)
+	if got := s.videoCount(); got != 3 {
+		t.Fatalf("stored %d videos, want 3", got)
+	}
+
+	s.yt.AddVideos(ch.UploadsPlaylistID, videos[0])
+	rec := s.do(http.MethodPost, path, "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if got := s.videoCount(); got != 4 {
+		t.Fatalf("stored %d videos after new upload, want 4", got)
+	}
+	if n := s.yt.Calls("FetchPlaylistVideosWithToken"); n != 0 {
+		t.Errorf("refresh paged through the Data API %d times, want the feed only", n)
+	}
+}
+
+func TestRefreshFallsBackToDataAPI(t *testing.T) {
+	s := newTestServer(t)
+	ch := s.yt.AddChannel(youtube.ChannelInfo{ID: "UCnofeed", Title: "No Feed"})
+	s.yt.AddVideos(ch.UploadsPlaylistID, makeVideos("nofeed", 2)...)
+	sub := s.createSubscription("UCnofeed", "No Feed", "channel")
+	s.yt.FeedErr = errors.New("feed unavailable")
+
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusOK)
+	if got := s.videoCount(); got != 2 {
+		t.Errorf("stored %d videos, want 2", got)
+	}
+	if n := s.yt.Calls("FetchPlaylistVideosWithToken"); n != 1 {
+		t.Errorf("Data API fetched %d times, want 1", n)
+	}
+
+	s.yt.Err = errors.New("api down")
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusInternalServerError)
+}
+
+func TestSaveVideosHidesShorts(t *testing.T) {
+	s := newTestServer(t)
+	videos := makeVideos("short", 3)
+	videos[1].Duration = "PT45S"
+	s.yt.AddVideos("PLshorts", videos...)
+	s.yt.SetShort(videos[1].ID)
+	sub := s.createSubscription("PLshorts", "Shorts", "playlist")
+
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/hide-shorts", sub.ID), "", ""), http.StatusOK)
+	rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+
+	stored, err := s.queries.GetVideo(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.YoutubeID != videos[1].ID || stored.IsShort.Int64 != 1 {
+		t.Errorf("video %s not stored as a short: %+v", videos[1].ID, stored)
+	}
+	if strings.Contains(rec.Body.String(), videos[1].Title) {
+		t.Error("column shows a short although shorts are hidden")
+	}
+}
+
+func TestShortsVerdict(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	videos := makeVideos("verdict", 3)
+	videos[0].Duration = "PT45S"
+	videos[1].Duration = "PT50S"
+	s.yt.AddVideos("PLverdict", videos...)
+	s.yt.SetProbeFails(videos[0].ID, true)
+	sub := s.createSubscription("PLverdict", "Verdicts", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/hide-shorts", sub.ID), "", ""), http.StatusOK)
+	rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+
+	unknown, err := s.queries.GetVideo(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unknown.IsShort.Valid || unknown.ShortAttempts != 1 {
+		t.Errorf("video with a failed probe stored as %v after %d attempts, want unknown after 1", unknown.IsShort, unknown.ShortAttempts)
+	}
+	if !strings.Contains(rec.Body.String(), videos[0].Title) {
+		t.Error("column hides a video that isn't known to be a Short")
+	}
+	long, err := s.queries.GetVideo(ctx, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if long.IsShort.Int64 != 0 || long.ShortSource.String != youtube.ShortsSourceDuration {
+		t.Errorf("long video classified as %v by %q", long.IsShort, long.ShortSource.String)
+	}
+
+	// Not due yet: the first retry waits for the backoff.
+	if err := s.h.RetryUnknownShorts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.queries.GetVideo(ctx, 1); v.ShortAttempts != 1 {
+		t.Errorf("video probed again before its backoff, attempts = %d", v.ShortAttempts)
+	}
+
+	s.yt.SetProbeFails(videos[0].ID, false)
+	s.yt.SetShort(videos[0].ID)
+	if _, err := s.db.Exec("UPDATE videos SET short_checked_at = datetime('now', '-1 day')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.h.RetryUnknownShorts(ctx); err != nil {
+		t.Fatal(err)
+	}
+	retried, err := s.queries.GetVideo(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.IsShort.Int64 != 1 || retried.ShortSource.String != youtube.ShortsSourceProbe || retried.ShortAttempts != 2 {
+		t.Errorf("retried video = %v by %q after %d attempts", retried.IsShort, retried.ShortSource.String, retried.ShortAttempts)
+	}
+
+	// A portrait player gives the second video away without a probe.
+	videos[1].EmbedWidth, videos[1].EmbedHeight = 270, 480
+	s.yt.UpdateVideo(videos[1])
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/reclassify-shorts", sub.ID), "", ""), http.StatusOK)
+	s.jobs.Wait()
+	list, err := s.queries.ListUndismissedJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Status != jobs.StatusDone || list[0].Result != "Reclassified 3 videos of Verdicts" {
+		t.Fatalf("reclassify jobs = %+v", list)
+	}
+	rec = s.do(http.MethodGet, fmt.Sprintf("/subscriptions/%d/column", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), videos[1].Title) {
+		t.Error("column shows a video reclassified as a Short")
+	}
+	portrait, err := s.queries.GetVideo(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portrait.IsShort.Int64 != 1 || portrait.ShortSource.String != youtube.ShortsSourceAspect {
+		t.Errorf("portrait video classified as %v by %q", portrait.IsShort, portrait.ShortSource.String)
+	}
+}
+
+func TestVideoStatistics(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	videos := makeVideos("stats", 3)
+	for i := range videos {
+		videos[i].PublishedAt = time.Now().UTC().Add(-time.Duration(i+1) * time.Hour)
+		videos[i].Description = fmt.Sprintf("About video %d", i)
+		videos[i].Statistics = &youtube.VideoStatistics{Views: int64(100 * (i + 1)), Likes: 7, Comments: 1}
+	}
+	s.yt.AddVideos("PLstats", videos...)
+	sub := s.createSubscription("PLstats", "Stats", "playlist")
+	rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "100 views") || !strings.Contains(rec.Body.String(), "About video 0") {
+		t.Error("column card lacks the statistics or description")
+	}
+
+	path := fmt.Sprintf("/subscriptions/%d/sort", sub.ID)
+	form := "application/x-www-form-urlencoded"
+	s.expectStatus(s.do(http.MethodPatch, path, form, "sort=loudest"), http.StatusBadRequest)
+	rec = s.do(http.MethodPatch, path, form, "sort=popular")
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	if strings.Index(body, videos[2].Title) > strings.Index(body, videos[0].Title) {
+		t.Error("column sorted by popularity doesn't list the most viewed video first")
+	}
+
+	// Statistics refresh once they are stale.
+	videos[0].Statistics = &youtube.VideoStatistics{Views: 1_250_000, Likes: 9, Comments: 2}
+	s.yt.UpdateVideo(videos[0])
+	if err := s.h.RefreshVideoStatistics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := s.queries.GetVideo(ctx, 1); v.ViewCount.Int64 != 100 {
+		t.Errorf("fresh statistics refreshed, views = %d", v.ViewCount.Int64)
+	}
+	if _, err := s.db.Exec("UPDATE videos SET stats_updated_at = datetime('now', '-1 day')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.h.RefreshVideoStatistics(ctx); err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err := s.queries.GetVideo(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.ViewCount.Int64 != 1_250_000 || refreshed.CommentCount.Int64 != 2 {
+		t.Errorf("refreshed statistics = %d views, %d comments", refreshed.ViewCount.Int64, refreshed.CommentCount.Int64)
+	}
+
+	rec = s.do(http.MethodGet, fmt.Sprintf("/subscriptions/%d/videos?sort=popular", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	body = rec.Body.String()
+	if !strings.Contains(body, "1.2M views") || !strings.Contains(body, "About video 1") {
+		t.Error("videos page lacks statistics or descriptions")
+	}
+	if strings.Index(body, videos[0].Title) > strings.Index(body, videos[2].Title) {
+		t.Error("videos page sorted by popularity doesn't list the most viewed video first")
+	}
+}
+
+func TestVideoAvailability(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	s.h.SetRegion("de")
+	videos := makeVideos("gone", 4)
+	s.yt.AddVideos("PLgone", videos...)
+	sub := s.createSubscription("PLgone", "Gone", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+
+	s.yt.DeleteVideo(videos[0].ID)
+	videos[1].UploadStatus = "rejected"
+	s.yt.UpdateVideo(videos[1])
+	videos[2].RegionBlocked = []string{"DE"}
+	s.yt.UpdateVideo(videos[2])
+	if err := s.h.ReconcileAvailability(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := s.do(http.MethodGet, fmt.Sprintf("/subscriptions/%d/column", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	for _, v := range videos[:3] {
+		if strings.Contains(body, v.Title) {
+			t.Errorf("column still shows unavailable video %q", v.Title)
+		}
+	}
+	if !strings.Contains(body, videos[3].Title) {
+		t.Error("column lost the available video")
+	}
+	if count, _ := s.queries.CountUnwatchedBySubscription(ctx, sub.ID); count != 1 {
+		t.Errorf("unwatched count = %d, want 1", count)
+	}
+
+	rec = s.do(http.MethodGet, fmt.Sprintf("/subscriptions/%d/gone", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	body = rec.Body.String()
+	for _, want := range []string{videos[0].Title, "Deleted or private", "Removed by YouTube", "Blocked in your region"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("gone section lacks %q", want)
+		}
+	}
+
+	// A video that comes back returns to its column on the next check.
+	videos[1].UploadStatus = "processed"
+	s.yt.UpdateVideo(videos[1])
+	if _, err := s.db.Exec("UPDATE videos SET availability_checked_at = datetime('now', '-2 days')"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.h.ReconcileAvailability(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if gone, _ := s.queries.CountGoneVideos(ctx, sub.ID); gone != 2 {
+		t.Errorf("gone count = %d, want 2", gone)
+	}
+
+	// Clearing a gone video marks it as watched.
+	s.expectStatus(s.do(http.MethodPost, "/videos/1/watched", "", ""), http.StatusOK)
+	if gone, _ := s.queries.CountGoneVideos(ctx, sub.ID); gone != 1 {
+		t.Errorf("gone count after clearing = %d, want 1", gone)
+	}
+}
+
+func TestPlaylistOrder(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	videos := makeVideos("course", 3)
+	s.yt.AddVideos("PLcourse", videos...)
+	s.yt.ReorderPlaylist("PLcourse", videos[2].ID, videos[0].ID, videos[1].ID)
+
+	rec := s.do(http.MethodPost, "/subscriptions", "application/json",
+		`{"youtube_id":"PLcourse","name":"Course","type":"playlist"}`)
+	s.expectStatus(rec, http.StatusOK)
+	subs, err := s.queries.ListSubscriptions(ctx)
+	if err != nil || len(subs) != 1 {
+		t.Fatalf("ListSubscriptions = %v, %v", subs, err)
+	}
+	sub := subs[0]
+	if sub.SortOrder != "playlist" {
+		t.Errorf("new playlist sorts by %q, want playlist", sub.SortOrder)
+	}
+
+	columnOrder := func(sort string, want ...youtube.VideoInfo) {
+		t.Helper()
+		rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/sort", sub.ID), "application/x-www-form-urlencoded", "sort="+sort)
+		s.expectStatus(rec, http.StatusOK)
+		body := rec.Body.String()
+		for i := 1; i < len(want); i++ {
+			if strings.Index(body, want[i-1].Title) > strings.Index(body, want[i].Title) {
+				t.Errorf("sorted by %s, %q comes after %q", sort, want[i-1].Title, want[i].Title)
+			}
+		}
+	}
+	columnOrder("playlist", videos[2], videos[0], videos[1])
+	columnOrder("oldest", videos[2], videos[1], videos[0])
+	columnOrder("newest", videos[0], videos[1], videos[2])
+
+	// Reordering and removing upstream carries over on the next sync.
+	s.yt.ReorderPlaylist("PLcourse", videos[1].ID, videos[2].ID)
+	s.yt.DeleteVideo(videos[0].ID)
+	if err := s.h.SyncPlaylistPositions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var removed sql.NullInt64
+	if err := s.db.QueryRow("SELECT playlist_position FROM videos WHERE youtube_id = ?", videos[0].ID).Scan(&removed); err != nil {
+		t.Fatal(err)
+	}
+	if removed.Valid {
+		t.Errorf("removed video keeps position %d", removed.Int64)
+	}
+	columnOrder("playlist", videos[1], videos[2], videos[0])
+
+	channel := s.createSubscription("UCnoorder", "Channel", "channel")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/sort", channel.ID), "application/x-www-form-urlencoded", "sort=playlist"), http.StatusBadRequest)
+}
+
+func TestLibrarySearch(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	videos := makeVideos("lib", 4)
+	videos[0].Title = "Building a compiler in Rust"
+	videos[1].Title = "Weekend vlog"
+	videos[1].Description = "We talk about rust removal on an old bike"
+	videos[1].Statistics = &youtube.VideoStatistics{}
+	videos[2].Title = "Cooking pasta"
+	videos[3].Title = "Rust async explained"
+	videos[3].Duration = "PT40S"
+	s.yt.AddVideos("PLlib", videos...)
+	s.yt.SetShort(videos[3].ID)
+	sub := s.createSubscription("PLlib", "Library", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+
+	rec := s.do(http.MethodGet, "/library", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Search Library") {
+		t.Error("library search modal doesn't render")
+	}
+
+	search := func(query string) string {
+		t.Helper()
+		rec := s.do(http.MethodGet, "/library/search?"+query, "", "")
+		s.expectStatus(rec, http.StatusOK)
+		return rec.Body.String()
+	}
+
+	body := search("q=rus")
+	for _, v := range []int{0, 1, 3} {
+		if !strings.Contains(body, videos[v].ID) {
+			t.Errorf("search for rus misses %s", videos[v].ID)
+		}
+	}
+	if strings.Contains(body, videos[2].ID) {
+		t.Error("search for rus finds the pasta video")
+	}
+	if strings.Index(body, videos[1].ID) < strings.Index(body, videos[0].ID) {
+		t.Error("a match in the description ranks above matches in titles")
+	}
+	if !strings.Contains(body, "<mark") || !strings.Contains(body, ">Rust</mark>") {
+		t.Error("matches are not highlighted")
+	}
+
+	if body := search("q=rust&shorts=exclude"); strings.Contains(body, videos[3].ID) {
+		t.Error("excluding Shorts still finds the Short")
+	}
+	if body := search("q=rust&shorts=only"); strings.Contains(body, videos[0].ID) || !strings.Contains(body, videos[3].ID) {
+		t.Error("only Shorts filter is wrong")
+	}
+
+	if _, err := s.db.Exec("UPDATE videos SET watched = 1 WHERE youtube_id = ?", videos[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if body := search("q=rust&watched=yes"); !strings.Contains(body, videos[0].ID) || strings.Contains(body, videos[3].ID) {
+		t.Error("watched filter is wrong")
+	}
+	if body := search("q=rust&from=2024-06-01&to=2024-06-01"); !strings.Contains(body, videos[0].ID) {
+		t.Error("date range leaves out a video published on its last day")
+	}
+	if body := search("q=rust&to=2024-05-31"); strings.Contains(body, "lib0") {
+		t.Error("date range includes a later video")
+	}
+	if body := search(fmt.Sprintf("q=rust&channel=%d", sub.ID+1)); strings.Contains(body, "lib0") {
+		t.Error("channel filter includes other channels")
+	}
+
+	// Renames are indexed again.
+	if _, err := s.db.Exec("UPDATE videos SET title = 'Pasta with sauce' WHERE youtube_id = ?", videos[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if body := search(`q=rust+"AND`); strings.Contains(body, videos[0].ID) {
+		t.Error("renamed video still found by its old title")
+	}
+	rows, err := s.queries.SearchLibrary(ctx, db.SearchLibraryParams{Query: db.LibraryQuery("sauce"), Limit: 10})
+	if err != nil || len(rows) != 1 {
+		t.Errorf("search for the new title = %v, %v", rows, err)
+	}
+
+	s.expectStatus(s.do(http.MethodGet, "/library/search?q=rust&watched=maybe", "", ""), http.StatusBadRequest)
+	s.expectStatus(s.do(http.MethodGet, "/library/search?q=rust&from=yesterday", "", ""), http.StatusBadRequest)
+}
+
+func TestDurationFilter(t *testing.T) {
+	s := newTestServer(t)
+	videos := makeVideos("dur", 3)
+	videos[0].Duration = "PT2M"
+	videos[2].Duration = "PT1H5M"
+	s.yt.AddVideos("PLdur", videos...)
+	sub := s.createSubscription("PLdur", "Durations", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+
+	stored, err := s.queries.GetVideo(context.Background(), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.DurationSeconds.Int64 != 3900 {
+		t.Errorf("duration_seconds = %v, want 3900", stored.DurationSeconds)
+	}
+
+	path := fmt.Sprintf("/subscriptions/%d/duration-filter", sub.ID)
+	form := "application/x-www-form-urlencoded"
+	s.expectStatus(s.do(http.MethodPatch, path, form, "min=10&max=5"), http.StatusBadRequest)
+	s.expectStatus(s.do(http.MethodPatch, path, form, "min=-1"), http.StatusBadRequest)
+
+	rec := s.do(http.MethodPatch, path, form, "min=3&max=60")
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	if !strings.Contains(body, videos[1].Title) {
+		t.Error("column hides a video within the bounds")
+	}
+	for _, v := range []youtube.VideoInfo{videos[0], videos[2]} {
+		if strings.Contains(body, v.Title) {
+			t.Errorf("column shows %q outside the bounds", v.Title)
+		}
+	}
+
+	sub, err = s.queries.GetSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := s.queries.CountUnwatchedBySubscriptionFiltered(context.Background(), db.CountUnwatchedBySubscriptionFilteredParams{
+		SubscriptionID: sub.ID,
+		MinSeconds:     sub.MinDurationSeconds,
+		MaxSeconds:     sub.MaxDurationSeconds,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("filtered count = %d, want 1", count)
+	}
+
+	// Clearing both bounds shows every video again.
+	rec = s.do(http.MethodPatch, path, form, "min=&max=")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), videos[2].Title) {
+		t.Error("column still hides long videos after clearing the filter")
+	}
+}
+
+func TestMuteRules(t *testing.T) {
+	s := newTestServer(t)
+	videos := makeVideos("mute", 3)
+	videos[0].Title = "Live Q&A with viewers"
+	videos[1].Title = "Building a bookshelf"
+	videos[2].Title = "Unboxing #ad"
+	s.yt.AddVideos("PLmute", videos...)
+	sub := s.createSubscription("PLmute", "Muted", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+
+	path := fmt.Sprintf("/subscriptions/%d/mute-rules", sub.ID)
+	form := "application/x-www-form-urlencoded"
+	s.expectStatus(s.do(http.MethodPost, path, form, "pattern=&action=exclude"), http.StatusBadRequest)
+	s.expectStatus(s.do(http.MethodPost, path, form, "pattern=(&regex=1&action=exclude"), http.StatusBadRequest)
+
+	rec := s.do(http.MethodPost, path, form, "pattern=live+q%26a&action=exclude&scope=subscription")
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	if strings.Contains(body, html.EscapeString(videos[0].Title)) {
+		t.Error("column shows a video matching a keyword rule")
+	}
+	if !strings.Contains(body, "Show <span") {
+		t.Error("column does not offer to show the muted video")
+	}
+
+	// A global rule reloads the page and applies to every subscription.
+	rec = s.do(http.MethodPost, path, form, `pattern=%23ad$&regex=1&action=exclude&scope=global`)
+	s.expectStatus(rec, http.StatusOK)
+	if rec.Header().Get("HX-Refresh") != "true" {
+		t.Error("adding a global rule does not reload the page")
+	}
+
+	sidebar, err := s.queries.ListAllSubscriptionsOrdered(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sidebar) != 1 || sidebar[0].UnwatchedCount != 1 {
+		t.Errorf("sidebar counts = %+v, want 1 unwatched", sidebar)
+	}
+
+	// New videos are muted as they are stored.
+	latest := makeVideos("newmute", 1)[0]
+	latest.Title = "Another unboxing #ad"
+	latest.PublishedAt = time.Now()
+	s.yt.AddVideos("PLmute", latest)
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusOK)
+	var muted int64
+	if err := s.db.QueryRow("SELECT muted FROM videos WHERE youtube_id = ?", latest.ID).Scan(&muted); err != nil || muted != 1 {
+		t.Errorf("new video matching a rule: muted = %d, %v", muted, err)
+	}
+
+	rec = s.do(http.MethodGet, fmt.Sprintf("/subscriptions/%d/muted", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	for _, v := range []youtube.VideoInfo{videos[0], videos[2]} {
+		if !strings.Contains(rec.Body.String(), html.EscapeString(v.Title)) {
+			t.Errorf("muted videos lack %q", v.Title)
+		}
+	}
+
+	// Include rules mute everything that matches none of them.
+	rec = s.do(http.MethodPost, path, form, "pattern=live&action=include&scope=subscription")
+	s.expectStatus(rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), videos[1].Title) {
+		t.Error("column shows a video matching no include rule")
+	}
+
+	rules, err := s.queries.ListMuteRules(context.Background(), sql.NullInt64{Int64: sub.ID, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 || rules[0].SubscriptionID.Valid {
+		t.Fatalf("rules = %+v, want 3 with the global one first", rules)
+	}
+	for _, rule := range rules {
+		s.expectStatus(s.do(http.MethodDelete, fmt.Sprintf("/mute-rules/%d", rule.ID), "", ""), http.StatusOK)
+	}
+	sub, err = s.queries.GetSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := s.queries.CountUnwatchedBySubscriptionFiltered(context.Background(), db.CountUnwatchedBySubscriptionFilteredParams{
+		SubscriptionID: sub.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("unwatched after deleting the rules = %d, want 4", count)
+	}
+}
+
+func TestUpcomingBroadcasts(t *testing.T) {
+	s := newTestServer(t)
+	videos := makeVideos("live", 2)
+	premiere := youtube.VideoInfo{
+		ID:               "premiere",
+		Title:            "Premiere of the documentary",
+		PublishedAt:      time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC),
+		BroadcastState:   youtube.BroadcastUpcoming,
+		ScheduledStartAt: time.Date(2024, 6, 3, 18, 0, 0, 0, time.UTC),
+	}
+	s.yt.AddVideos("PLlive", append([]youtube.VideoInfo{premiere}, videos...)...)
+	sub := s.createSubscription("PLlive", "Live", "playlist")
+	rec := s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Upcoming · ") {
+		t.Error("upcoming premiere has no badge")
+	}
+
+	stored, err := s.queries.GetVideo(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.BroadcastState != youtube.BroadcastUpcoming || !stored.ScheduledStartAt.Valid || stored.Duration.Valid {
+		t.Errorf("stored premiere = %+v", stored)
+	}
+
+	path := fmt.Sprintf("/subscriptions/%d/upcoming", sub.ID)
+	form := "application/x-www-form-urlencoded"
+	s.expectStatus(s.do(http.MethodPatch, path, form, "mode=sometimes"), http.StatusBadRequest)
+
+	rec = s.do(http.MethodPatch, path, form, "mode=separate")
+	s.expectStatus(rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), premiere.Title) {
+		t.Error("column lists the premiere among its videos although it shows upcoming ones separately")
+	}
+	rec = s.do(http.MethodGet, path, "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), premiere.Title) {
+		t.Error("upcoming section lacks the premiere")
+	}
+
+	s.expectStatus(s.do(http.MethodPatch, path, form, "mode=hide"), http.StatusOK)
+	sub, err = s.queries.GetSubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := s.queries.CountUnwatchedBySubscriptionFiltered(context.Background(), db.CountUnwatchedBySubscriptionFilteredParams{
+		SubscriptionID: sub.ID,
+		HideUpcoming:   1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("unwatched without upcoming = %d, want 2", count)
+	}
+
+	// Once the premiere has ended, a refresh turns it into a regular video.
+	premiere.BroadcastState = youtube.BroadcastCompleted
+	premiere.Duration = "PT42M"
+	premiere.PublishedAt = time.Date(2024, 6, 3, 18, 1, 0, 0, time.UTC)
+	s.yt.UpdateVideo(premiere)
+	rec = s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), premiere.Title) {
+		t.Error("ended premiere is still hidden")
+	}
+	stored, err = s.queries.GetVideo(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.BroadcastState != youtube.BroadcastCompleted || stored.DurationSeconds.Int64 != 42*60 ||
+		!stored.PublishedAt.Time.Equal(premiere.PublishedAt) {
+		t.Errorf("ended premiere = %+v", stored)
+	}
+}
+
+func TestBackfillDurations(t *testing.T) {
+	s := newTestServer(t)
+	sub := s.createSubscription("PLold", "Old", "playlist")
+	if _, err := s.db.Exec(`INSERT INTO videos (subscription_id, youtube_id, title, duration)
+		VALUES (?, 'old1', 'Old 1', 'PT1M30S'), (?, 'old2', 'Old 2', 'bogus'), (?, 'old3', 'Old 3', NULL)`,
+		sub.ID, sub.ID, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.h.BackfillDurations(context.Background()); err != nil {
+		t.Fatalf("BackfillDurations: %v", err)
+	}
+
+	for id, want := range map[int64]sql.NullInt64{
+		1: {Int64: 90, Valid: true},
+		2: {},
+		3: {},
+	} {
+		v, err := s.queries.GetVideo(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.DurationSeconds != want {
+			t.Errorf("video %d duration_seconds = %v, want %v", id, v.DurationSeconds, want)
+		}
+	}
+}
+
+func TestToggleWatched(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLwatch", makeVideos("watch", 2)...)
+	sub := s.createSubscription("PLwatch", "Watch", "playlist")
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+
+	rec := s.do(http.MethodPost, "/videos/1/watched", "", "")
+	s.expectStatus(rec, http.StatusOK)
+
+	count, err := s.queries.CountUnwatchedBySubscription(context.Background(), sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("unwatched = %d, want 1", count)
+	}
+}
+
+func TestSearchResults(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddChannel(youtube.ChannelInfo{ID: "UCgo", Title: "Go Programming"})
+	s.yt.AddPlaylist("PLgo", "Go Tutorials")
+
+	rec := s.do(http.MethodGet, "/search/results?q=go&type=channel", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if body := rec.Body.String(); !strings.Contains(body, "Go Programming") || strings.Contains(body, "Go Tutorials") {
+		t.Errorf("channel search body = %s", body)
+	}
+
+	rec = s.do(http.MethodGet, "/search/results?q=go&type=playlist", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if body := rec.Body.String(); !strings.Contains(body, "Go Tutorials") {
+		t.Errorf("playlist search body = %s", body)
+	}
+}
+
+func TestSearchResolvesLinks(t *testing.T) {
+	s := newTestServer(t)
+	ch := s.yt.AddChannel(youtube.ChannelInfo{ID: "UCgo", Title: "Go Programming", CustomURL: "@golang"})
+	s.yt.AddVideos(ch.UploadsPlaylistID, youtube.VideoInfo{ID: "goVideo0001", Title: "Go 1.23"})
+	s.yt.AddPlaylist("PLgotutorials", "Go Tutorials")
+
+	for _, q := range []string{
+		"@golang",
+		"https://www.youtube.com/@golang",
+		"https://www.youtube.com/c/golang",
+		"https://youtu.be/goVideo0001",
+	} {
+		rec := s.do(http.MethodGet, "/search/results?type=playlist&q="+url.QueryEscape(q), "", "")
+		s.expectStatus(rec, http.StatusOK)
+		if body := html.UnescapeString(rec.Body.String()); !strings.Contains(body, "Go Programming") || !strings.Contains(body, `"youtube_id":"UCgo"`) {
+			t.Errorf("%s resolves to %s", q, body)
+		}
+	}
+	rec := s.do(http.MethodGet, "/search/results?q="+url.QueryEscape("youtube.com/playlist?list=PLgotutorials"), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if body := rec.Body.String(); !strings.Contains(body, "Go Tutorials") {
+		t.Errorf("playlist link resolves to %s", body)
+	}
+	rec = s.do(http.MethodGet, "/search/results?q=%40nobody", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if body := rec.Body.String(); !strings.Contains(body, "No results found") {
+		t.Errorf("unknown handle resolves to %s", body)
+	}
+	if n := s.yt.Calls("SearchChannels") + s.yt.Calls("SearchPlaylists"); n != 0 {
+		t.Errorf("links were searched for %d times", n)
+	}
+}
+
+func TestRiverColumnPaginatesAcrossSubscriptions(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLriverA", makeVideos("ra", 12)...)
+	s.yt.AddVideos("PLriverB", makeVideos("rb", 12)...)
+	for _, id := range []string{"PLriverA", "PLriverB"} {
+		sub := s.createSubscription(id, id, "playlist")
+		s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusOK)
+	}
+	today := youtube.VideoInfo{ID: "fresh", Title: "Fresh upload", PublishedAt: time.Now().UTC()}
+	s.yt.AddVideos("PLriverA", today)
+	subs, _ := s.queries.ListSubscriptions(context.Background())
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", subs[0].ID), "", ""), http.StatusOK)
+
+	rec := s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=all")
+	s.expectStatus(rec, http.StatusOK)
+	s.expectStatus(s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=all"), http.StatusConflict)
+
+	cols, err := s.queries.ListVirtualColumns(context.Background(), 1)
+	if err != nil || len(cols) != 1 {
+		t.Fatalf("ListVirtualColumns = %v, %v", cols, err)
+	}
+
+	// Walk the column page by page, marking the first card of each page as
+	// watched to make sure the cursor doesn't skip anything.
+	seen := map[string]bool{}
+	next := fmt.Sprintf("/columns/%d/videos", cols[0].ID)
+	for pages := 0; next != ""; pages++ {
+		if pages > 5 {
+			t.Fatal("pagination does not terminate")
+		}
+		rec := s.do(http.MethodGet, next, "", "")
+		s.expectStatus(rec, http.StatusOK)
+		body := rec.Body.String()
+
+		next = ""
+		if i := strings.Index(body, `hx-get="/columns/`); i >= 0 {
+			rest := body[i+len(`hx-get="`):]
+			next = strings.ReplaceAll(rest[:strings.Index(rest, `"`)], "&amp;", "&")
+		}
+		for _, part := range strings.Split(body, `id="col-video-`)[1:] {
+			id := part[:strings.Index(part, `"`)]
+			if seen[id] {
+				t.Errorf("video %s shown twice", id)
+			}
+			seen[id] = true
+		}
+		if pages == 0 && !strings.Contains(body, "Fresh upload") {
+			t.Error("newest video is not at the top of the first page")
+		}
+		if pages == 1 {
+			s.expectStatus(s.do(http.MethodPost, "/videos/1/watched", "", ""), http.StatusOK)
+		}
+	}
+	if len(seen) != 25 {
+		t.Errorf("river showed %d videos, want 25", len(seen))
+	}
+
+	rec = s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=today")
+	s.expectStatus(rec, http.StatusOK)
+	cols, _ = s.queries.ListVirtualColumns(context.Background(), 1)
+	rec = s.do(http.MethodGet, fmt.Sprintf("/columns/%d/videos", cols[1].ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if n := strings.Count(rec.Body.String(), `id="col-video-`); n != 1 {
+		t.Errorf("today column shows %d videos, want 1", n)
+	}
+}
+
+func TestDecksKeepTheirOwnColumns(t *testing.T) {
+	s := newTestServer(t)
+	sub := s.createSubscription("PLdeck", "Deck Playlist", "playlist")
+	column := fmt.Sprintf(`id="column-%d"`, sub.ID)
+
+	rec := s.do(http.MethodPost, "/decks", "application/x-www-form-urlencoded", "name=Music")
+	s.expectStatus(rec, http.StatusCreated)
+	if got := rec.Header().Get("HX-Redirect"); got != "/" {
+		t.Fatalf("HX-Redirect = %q, want /", got)
+	}
+
+	rec = s.do(http.MethodGet, "/", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Music") {
+		t.Error("new deck isn't the current one")
+	}
+	s.expectStatus(s.do(http.MethodPatch, fmt.Sprintf("/subscriptions/%d/active?active=1", sub.ID), "", ""), http.StatusOK)
+	if !s.inDeck(2, sub.ID) || s.inDeck(1, sub.ID) {
+		t.Fatal("column not added to the current deck only")
+	}
+
+	if body := s.do(http.MethodGet, "/", "", "").Body.String(); !strings.Contains(body, column) {
+		t.Error("current deck is missing its column")
+	}
+	// Looking at another deck doesn't switch to it.
+	if body := s.do(http.MethodGet, "/decks/1", "", "").Body.String(); strings.Contains(body, column) || !strings.Contains(body, "Switch to this deck") {
+		t.Error("first deck shows the other deck's column or passes for the current one")
+	}
+	if body := s.do(http.MethodGet, "/", "", "").Body.String(); !strings.Contains(body, column) {
+		t.Error("viewing a deck switched to it")
+	}
+	s.expectStatus(s.do(http.MethodGet, "/decks/99", "", ""), http.StatusNotFound)
+	s.expectStatus(s.do(http.MethodPost, "/decks/99/select", "", ""), http.StatusNotFound)
+	s.expectStatus(s.do(http.MethodDelete, "/decks/99", "", ""), http.StatusNotFound)
+
+	// Switch to the first deck and back, and open a virtual column, which
+	// goes with the deck.
+	rec = s.do(http.MethodPost, "/decks/1/select", "", "")
+	s.expectStatus(rec, http.StatusNoContent)
+	if got := rec.Header().Get("HX-Redirect"); got != "/" {
+		t.Errorf("HX-Redirect = %q, want /", got)
+	}
+	if body := s.do(http.MethodGet, "/", "", "").Body.String(); strings.Contains(body, column) {
+		t.Error("switching to the first deck didn't take")
+	}
+	s.expectStatus(s.do(http.MethodPost, "/decks/2/select", "", ""), http.StatusNoContent)
+	s.expectStatus(s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=all"), http.StatusOK)
+	if cols, err := s.queries.ListVirtualColumns(context.Background(), 2); err != nil || len(cols) != 1 {
+		t.Fatalf("virtual columns of the current deck = %v, %v", cols, err)
+	}
+	s.expectStatus(s.do(http.MethodDelete, "/decks/2", "", ""), http.StatusOK)
+	var virtual int64
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM virtual_columns").Scan(&virtual); err != nil {
+		t.Fatalf("count virtual columns: %v", err)
+	}
+	if virtual != 0 {
+		t.Errorf("%d virtual columns left after deleting their deck, want 0", virtual)
+	}
+	s.expectStatus(s.do(http.MethodDelete, "/decks/1", "", ""), http.StatusConflict)
+	if body := s.do(http.MethodGet, "/", "", "").Body.String(); strings.Contains(body, column) {
+		t.Error("deleted deck still shown")
+	}
+}
+
+func TestFoldersGroupSubscriptions(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddVideos("PLin", makeVideos("in", 4)...)
+	s.yt.AddVideos("PLout", makeVideos("out", 3)...)
+	in := s.createSubscription("PLin", "In Folder", "playlist")
+	out := s.createSubscription("PLout", "Not In Folder", "playlist")
+	for _, sub := range []db.Subscription{in, out} {
+		s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusOK)
+	}
+
+	rec := s.do(http.MethodPost, "/groups", "application/x-www-form-urlencoded", "name=Talks")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Talks") {
+		t.Error("sidebar does not list the new folder")
+	}
+	s.expectStatus(s.do(http.MethodPost, "/groups", "application/x-www-form-urlencoded", "name=Talks"), http.StatusConflict)
+
+	rec = s.do(http.MethodPut, fmt.Sprintf("/subscriptions/%d/groups", in.ID), "application/x-www-form-urlencoded", "group_id=1")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "checked") {
+		t.Error("folder picker does not show the membership")
+	}
+
+	for _, tc := range []struct {
+		group     string
+		want, not string
+	}{
+		{"1", `aria-label="In Folder"`, `aria-label="Not In Folder"`},
+		{"none", `aria-label="Not In Folder"`, `aria-label="In Folder"`},
+	} {
+		body := s.do(http.MethodGet, "/subscriptions/filter?group="+tc.group, "", "").Body.String()
+		if !strings.Contains(body, tc.want) || strings.Contains(body, tc.not) {
+			t.Errorf("group %s lists the wrong subscriptions", tc.group)
+		}
+	}
+
+	s.expectStatus(s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=folder&group_id=1"), http.StatusOK)
+	s.expectStatus(s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=folder&group_id=1"), http.StatusConflict)
+	cols, err := s.queries.ListVirtualColumns(context.Background(), 1)
+	if err != nil || len(cols) != 1 {
+		t.Fatalf("ListVirtualColumns = %v, %v", cols, err)
+	}
+	rec = s.do(http.MethodGet, fmt.Sprintf("/columns/%d/videos", cols[0].ID), "", "")
+	if n := strings.Count(rec.Body.String(), `id="col-video-`); n != 4 {
+		t.Errorf("folder column shows %d videos, want 4", n)
+	}
+
+	rec = s.do(http.MethodDelete, "/groups/1", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), fmt.Sprintf(`id="vcolumn-%d" hx-swap-oob="delete"`, cols[0].ID)) {
+		t.Error("folder column not removed from the deck")
+	}
+	if cols, _ := s.queries.ListVirtualColumns(context.Background(), 1); len(cols) != 0 {
+		t.Errorf("%d virtual columns left after deleting the folder", len(cols))
+	}
+}
+
+func TestWatchLaterQueue(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	s.yt.AddVideos("PLqueue", makeVideos("q", 3)...)
+	sub := s.createSubscription("PLqueue", "Queue", "playlist")
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", sub.ID), "", ""), http.StatusOK)
+	videos, err := s.queries.ListVideos(ctx, db.ListVideosParams{SubscriptionID: sub.ID, Sort: "newest"})
+	if err != nil || len(videos) != 3 {
+		t.Fatalf("ListVideos = %d videos, %v", len(videos), err)
+	}
+
+	s.expectStatus(s.do(http.MethodPost, "/columns", "application/x-www-form-urlencoded", "kind=queue"), http.StatusOK)
+	for _, v := range videos {
+		rec := s.do(http.MethodPost, fmt.Sprintf("/videos/%d/queue", v.ID), "", "")
+		s.expectStatus(rec, http.StatusOK)
+		if !strings.Contains(rec.Body.String(), fmt.Sprintf(`id="queue-video-%d"`, v.ID)) {
+			t.Errorf("queued video %d not appended to the queue column", v.ID)
+		}
+	}
+	rec := s.do(http.MethodPost, fmt.Sprintf("/videos/%d/queue", videos[0].ID), "", "")
+	if !strings.Contains(rec.Header().Get("HX-Trigger"), "Already") {
+		t.Error("queueing a video twice was not reported")
+	}
+
+	// Move the last video to the front.
+	order := fmt.Sprintf(`{"ids": ["%d", "%d", "%d"], "context": "queue"}`, videos[2].ID, videos[0].ID, videos[1].ID)
+	s.expectStatus(s.do(http.MethodPost, "/subscriptions/reorder", "application/json", order), http.StatusOK)
+
+	cols, _ := s.queries.ListVirtualColumns(ctx, 1)
+	body := s.do(http.MethodGet, fmt.Sprintf("/columns/%d/videos", cols[0].ID), "", "").Body.String()
+	first := strings.Index(body, videos[2].Title)
+	second := strings.Index(body, videos[0].Title)
+	if first < 0 || second < 0 || first > second {
+		t.Error("queue not shown in the stored order")
+	}
+	playAll := "watch_videos?video_ids=" + videos[2].YoutubeID + "," + videos[0].YoutubeID + "," + videos[1].YoutubeID
+	if !strings.Contains(body, playAll) {
+		t.Errorf("play all link missing %q", playAll)
+	}
+	if !strings.Contains(body, `href="https://www.youtube.com/watch?v=`+videos[2].YoutubeID+`"`) {
+		t.Error("play next does not start at the head of the queue")
+	}
+
+	// Watching a queued video dequeues it.
+	rec = s.do(http.MethodPost, fmt.Sprintf("/videos/%d/watched", videos[2].ID), "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), fmt.Sprintf(`id="queue-video-%d" hx-swap-oob="delete"`, videos[2].ID)) {
+		t.Error("watched video not removed from the queue column")
+	}
+	if n, _ := s.queries.CountQueue(ctx); n != 2 {
+		t.Errorf("queue holds %d videos after watching one, want 2", n)
+	}
+
+	s.expectStatus(s.do(http.MethodDelete, fmt.Sprintf("/videos/%d/queue", videos[0].ID), "", ""), http.StatusOK)
+	if n, _ := s.queries.CountQueue(ctx); n != 1 {
+		t.Errorf("queue holds %d videos after removing one, want 1", n)
+	}
+	if v, _ := s.queries.GetVideo(ctx, videos[0].ID); v.Watched.Int64 != 0 {
+		t.Error("removing a video from the queue marked it as watched")
+	}
+}
+
+func TestOPMLImportExport(t *testing.T) {
+	s := newTestServer(t)
+	ch := s.yt.AddChannel(youtube.ChannelInfo{ID: "UCgoLang0000000000000000", Title: "Go Programming", CustomURL: "@golang"})
+	s.yt.AddChannel(youtube.ChannelInfo{ID: "UCrustLang00000000000000", Title: "Rust", CustomURL: "@rustlang"})
+	s.yt.AddVideos(ch.UploadsPlaylistID, youtube.VideoInfo{ID: "goVideo0001", Title: "Go 1.23"})
+	talks := s.createSubscription("PLtalks", "Talks", "playlist")
+	s.createSubscription("UCgoLang0000000000000000", "Go Programming", "channel")
+	s.expectStatus(s.do(http.MethodPost, "/groups", "application/x-www-form-urlencoded", "name=Conferences"), http.StatusOK)
+	s.expectStatus(s.do(http.MethodPut, fmt.Sprintf("/subscriptions/%d/groups", talks.ID), "application/x-www-form-urlencoded", "group_id=1"), http.StatusOK)
+
+	rec := s.do(http.MethodGet, "/export/subscriptions.opml", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/x-opml") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	doc, err := opml.Parse(rec.Body)
+	if err != nil {
+		t.Fatalf("export does not parse: %v", err)
+	}
+	feeds := doc.Feeds()
+	if len(feeds) != 2 {
+		t.Fatalf("exported %d feeds, want 2", len(feeds))
+	}
+	for _, f := range feeds {
+		switch f.Name() {
+		case "Talks":
+			if f.Folder != "Conferences" || f.XMLURL != youtube.FeedURL("playlist", "PLtalks") {
+				t.Errorf("exported playlist %+v", f)
+			}
+		case "Go Programming":
+			if f.Folder != "" || f.XMLURL != youtube.FeedURL("channel", "UCgoLang0000000000000000") {
+				t.Errorf("exported channel %+v", f)
+			}
+		default:
+			t.Errorf("unexpected feed %+v", f)
+		}
+	}
+
+	const upload = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+  <head><title>Feeds</title></head>
+  <body>
+    <outline text="Go Programming" xmlUrl="https://www.youtube.com/feeds/videos.xml?channel_id=UCgoLang0000000000000000"/>
+    <outline text="Languages">
+      <outline text="Rust" type="rss" xmlUrl="https://www.youtube.com/feeds/videos.xml?user=rustlang"/>
+      <outline text="Tutorials" type="rss" xmlUrl="https://www.youtube.com/feeds/videos.xml?playlist_id=PLtutorials0"/>
+    </outline>
+    <outline text="Some blog" type="rss" xmlUrl="https://blog.example.com/feed.xml"/>
+    <outline text="Gone" type="rss" xmlUrl="https://www.youtube.com/feeds/videos.xml?user=nobody"/>
+  </body>
+</opml>`
+	body := s.importReport(s.upload("/import/opml", "feeds.opml", upload))
+	if !strings.Contains(body, "2 imported") || !strings.Contains(body, "1 already subscribed") || !strings.Contains(body, "2 failed") {
+		t.Errorf("report = %s", body)
+	}
+	if !strings.Contains(body, "Some blog") {
+		t.Error("report does not name the non-YouTube feed")
+	}
+
+	subs, err := s.queries.ListSubscriptions(context.Background())
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	byID := map[string]db.Subscription{}
+	for _, sub := range subs {
+		byID[sub.YoutubeID] = sub
+	}
+	if len(subs) != 4 || byID["UCrustLang00000000000000"].Name != "Rust" || byID["PLtutorials0"].SortOrder != "playlist" {
+		t.Errorf("subscriptions after import = %+v", subs)
+	}
+	group, err := s.queries.GetGroupByName(context.Background(), "Languages")
+	if err != nil {
+		t.Fatalf("import did not create the folder's group: %v", err)
+	}
+	members, err := s.queries.ListSubscriptionGroupNames(context.Background())
+	if err != nil {
+		t.Fatalf("ListSubscriptionGroupNames: %v", err)
+	}
+	var inGroup int
+	for _, m := range members {
+		if m.GroupName == group.Name {
+			inGroup++
+		}
+	}
+	if inGroup != 2 {
+		t.Errorf("%d subscriptions filed in the imported group, want 2", inGroup)
+	}
+
+	rec = s.upload("/import/opml", "feeds.opml", "not xml")
+	s.expectStatus(rec, http.StatusBadRequest)
+
+	// One import runs at a time.
+	release := make(chan struct{})
+	if _, err := s.jobs.Start(context.Background(), "subscription-import", func(ctx context.Context, progress jobs.Progress) (string, error) {
+		<-release
+		return "[]", nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rec = s.upload("/import/opml", "feeds.opml", upload)
+	s.expectStatus(rec, http.StatusConflict)
+	close(release)
+	s.jobs.Wait()
+}
+
+func TestTakeoutImport(t *testing.T) {
+	s := newTestServer(t)
+	const goID, rustID = "UCgoLang0000000000000000", "UCrustLang00000000000000"
+	goCh := s.yt.AddChannel(youtube.ChannelInfo{ID: goID, Title: "Go Programming"})
+	rust := s.yt.AddChannel(youtube.ChannelInfo{ID: rustID, Title: "Rust"})
+	s.yt.AddVideos(goCh.UploadsPlaylistID, makeVideos("goVideo0", 3)...)
+	s.yt.AddVideos(rust.UploadsPlaylistID, makeVideos("rustVide", 3)...)
+	s.yt.AddPlaylist("PLcooking000", "Cooking")
+	stored := s.createSubscription(goID, "Go Programming", "channel")
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", stored.ID), "", ""), http.StatusOK)
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{
+		"Takeout/YouTube and YouTube Music/subscriptions/subscriptions.csv": "\ufeffChannel Id,Channel Url,Channel Title\n" +
+			goID + ",http://www.youtube.com/channel/" + goID + ",Go Programming\n" +
+			rustID + ",http://www.youtube.com/channel/" + rustID + ",Rust\n",
+		"Takeout/YouTube and YouTube Music/playlists/playlists.csv": "Playlist ID,Add new videos to top,Playlist title (original)\n" +
+			"PLcooking000,False,Cooking\nPLprivate000,False,Secret\n",
+		"Takeout/YouTube and YouTube Music/playlists/Cooking-videos.csv": "Video ID,Playlist Video Creation Timestamp\ngoVideo0000,2024-01-01T00:00:00+00:00\n",
+		"Takeout/YouTube and YouTube Music/history/watch-history.json": `[
+			{"title": "Watched go 0", "titleUrl": "https://www.youtube.com/watch?v\u003dgoVideo0000", "time": "2024-06-02T10:00:00Z"},
+			{"title": "Watched go 0", "titleUrl": "https://www.youtube.com/watch?v\u003dgoVideo0000", "time": "2024-06-01T10:00:00Z"},
+			{"title": "Watched rust 1", "titleUrl": "https://www.youtube.com/watch?v\u003drustVide001", "time": "2024-06-03T10:00:00Z"},
+			{"title": "Watched a video that has been removed", "time": "2024-06-04T10:00:00Z"}
+		]`,
+		"Takeout/archive_browser.html": "<html></html>",
+	} {
+		f, err := zw.Create(name)
+		if err == nil {
+			_, err = io.WriteString(f, content)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	body := s.importReport(s.upload("/import/takeout", "takeout-20240701.zip", archive.String()))
+	for _, want := range []string{
+		"subscriptions.csv", "1 imported, 1 already subscribed, 0 failed",
+		"playlists.csv", "1 imported, 0 already subscribed, 1 failed", "Secret",
+		"watch-history.json", "2 watched videos, 1 stored ones marked watched",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("report lacks %q: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "Cooking-videos.csv") {
+		t.Error("report lists a playlist's video list")
+	}
+
+	var watched string
+	if err := s.db.QueryRow("SELECT group_concat(youtube_id) FROM videos WHERE watched = 1").Scan(&watched); err != nil || watched != "goVideo0000" {
+		t.Errorf("watched videos = %q, %v", watched, err)
+	}
+	var rustSubID int64
+	if err := s.db.QueryRow("SELECT id FROM subscriptions WHERE youtube_id = ?", rustID).Scan(&rustSubID); err != nil {
+		t.Fatalf("Rust was not imported: %v", err)
+	}
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", rustSubID), "", ""), http.StatusOK)
+	if err := s.db.QueryRow("SELECT group_concat(youtube_id) FROM (SELECT youtube_id FROM videos WHERE watched = 1 ORDER BY youtube_id)").Scan(&watched); err != nil || watched != "goVideo0000,rustVide001" {
+		t.Errorf("watched videos after fetching Rust = %q, %v", watched, err)
+	}
+
+	// Nothing to import, so the report comes right away.
+	rec := s.upload("/import/takeout", "watch-history.html", "<html></html>")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "export it as JSON") {
+		t.Errorf("HTML history report = %s", rec.Body.String())
+	}
+}
+
+func TestAppInterop(t *testing.T) {
+	s := newTestServer(t)
+	const goID, rustID, zigID = "UCgoLang0000000000000000", "UCrustLang00000000000000", "UCzigLang000000000000000"
+	goCh := s.yt.AddChannel(youtube.ChannelInfo{ID: goID, Title: "Go Programming"})
+	s.yt.AddChannel(youtube.ChannelInfo{ID: rustID, Title: "Rust"})
+	s.yt.AddChannel(youtube.ChannelInfo{ID: zigID, Title: "Zig"})
+	s.yt.AddVideos(goCh.UploadsPlaylistID, makeVideos("goVideo0", 3)...)
+	stored := s.createSubscription(goID, "Go Programming", "channel")
+	s.expectStatus(s.do(http.MethodPost, fmt.Sprintf("/subscriptions/%d/refresh", stored.ID), "", ""), http.StatusOK)
+
+	invidious := `{"subscriptions":["` + goID + `","` + rustID + `"],"watch_history":["goVideo0001","notStored00"],"playlists":[]}`
+	rec := s.upload("/import/apps?dry_run=1", "subscription_manager.json", invidious)
+	s.expectStatus(rec, http.StatusOK)
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Invidious subscriptions", "1 to import, 1 already subscribed, 0 failed",
+		"Invidious watch history", "2 watched videos, 1 stored ones to mark watched",
+		"nothing has changed",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("preview lacks %q: %s", want, body)
+		}
+	}
+	var subs, watched int64
+	if err := s.db.QueryRow("SELECT (SELECT COUNT(*) FROM subscriptions), (SELECT COUNT(*) FROM videos WHERE watched = 1)").Scan(&subs, &watched); err != nil || subs != 1 || watched != 0 {
+		t.Fatalf("preview changed the library: %d subscriptions, %d watched, %v", subs, watched, err)
+	}
+
+	if body := s.importReport(s.upload("/import/apps", "subscription_manager.json", invidious)); !strings.Contains(body, "1 imported, 1 already subscribed") || !strings.Contains(body, "1 stored ones marked watched") {
+		t.Errorf("import report = %s", body)
+	}
+	var rustName string
+	if err := s.db.QueryRow("SELECT name FROM subscriptions WHERE youtube_id = ?", rustID).Scan(&rustName); err != nil || rustName != "Rust" {
+		t.Errorf("Rust subscription name = %q, %v", rustName, err)
+	}
+	var watchedID string
+	if err := s.db.QueryRow("SELECT youtube_id FROM videos WHERE watched = 1").Scan(&watchedID); err != nil || watchedID != "goVideo0001" {
+		t.Errorf("watched video = %q, %v", watchedID, err)
+	}
+
+	profiles := `{"name":"All Channels","subscriptions":[{"id":"` + zigID + `","name":"Zig","thumbnail":""}],"_id":"allChannels"}
+{"name":"Systems","subscriptions":[{"id":"` + zigID + `","name":"Zig","thumbnail":""}],"_id":"p1"}
+`
+	s.importReport(s.upload("/import/apps", "profiles.db", profiles))
+	var group string
+	if err := s.db.QueryRow(`
+		SELECT g.name FROM groups g
+		JOIN subscription_groups sg ON sg.group_id = g.id
+		JOIN subscriptions s ON s.id = sg.subscription_id
+		WHERE s.youtube_id = ?`, zigID).Scan(&group); err != nil || group != "Systems" {
+		t.Errorf("Zig group = %q, %v", group, err)
+	}
+
+	rec = s.upload("/import/apps", "notes.json", `{"hello":"world"}`)
+	s.expectStatus(rec, http.StatusBadRequest)
+
+	rec = s.do(http.MethodGet, "/export/invidious.json", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	export, err := interop.Read(rec.Body.Bytes())
+	if err != nil || len(export.Subscriptions) != 3 || len(export.History) != 1 || export.History[0].VideoID != "goVideo0001" {
+		t.Errorf("Invidious export = %+v, %v", export, err)
+	}
+	rec = s.do(http.MethodGet, "/export/freetube-profiles.db", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	export, err = interop.Read(rec.Body.Bytes())
+	if err != nil || len(export.Subscriptions) != 3 {
+		t.Fatalf("FreeTube export = %+v, %v", export, err)
+	}
+	for _, sub := range export.Subscriptions {
+		if sub.ID == zigID && (len(sub.Groups) != 1 || sub.Groups[0] != "Systems") {
+			t.Errorf("FreeTube export lost Zig's group: %+v", sub)
+		}
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	s := newTestServer(t)
+	s.yt.AddChannel(youtube.ChannelInfo{ID: "UCgoLang0000000000000000", Title: "Go Programming"})
+	s.createSubscription("UCgoLang0000000000000000", "Go Programming", "channel")
+
+	rec := s.do(http.MethodGet, "/admin/backup", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.HasPrefix(rec.Body.String(), "SQLite format 3\x00") {
+		t.Fatal("database backup is not an SQLite database")
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, ".db") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "backup.db")
+	if err := os.WriteFile(snapshot, rec.Body.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec = s.do(http.MethodGet, "/admin/backup?format=json", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	doc, err := backup.Read(rec.Body)
+	if err != nil || len(doc.Subscriptions) != 1 {
+		t.Fatalf("JSON backup = %+v, %v", doc, err)
+	}
+
+	countSubscriptions := func(path string) int64 {
+		t.Helper()
+		database, err := sql.Open("sqlite", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer database.Close()
+		var n int64
+		if err := database.QueryRow("SELECT COUNT(*) FROM subscriptions").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Restoring the snapshot replaces the database, keeping the old one.
+	dbPath := filepath.Join(dir, "data.db")
+	t.Setenv("DB_PATH", dbPath)
+	if err := os.WriteFile(dbPath, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code := runRestore([]string{snapshot}); code != 0 {
+		t.Fatalf("restore exited with %d", code)
+	}
+	if n := countSubscriptions(dbPath); n != 1 {
+		t.Errorf("restored database has %d subscriptions", n)
+	}
+	if old, err := os.ReadFile(dbPath + ".before-restore"); err != nil || string(old) != "old" {
+		t.Errorf("previous database = %q, %v", old, err)
+	}
+
+	// A JSON backup of another deck merges into it.
+	other := newTestServer(t)
+	other.createSubscription("UCrustLang00000000000000", "Rust", "channel")
+	rec = other.do(http.MethodGet, "/admin/backup?format=json", "", "")
+	jsonBackup := filepath.Join(dir, "other.json")
+	if err := os.WriteFile(jsonBackup, rec.Body.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if code := runRestore([]string{"-merge", jsonBackup}); code != 0 {
+		t.Fatalf("restore -merge exited with %d", code)
+	}
+	if n := countSubscriptions(dbPath); n != 2 {
+		t.Errorf("merged database has %d subscriptions, want 2", n)
+	}
+
+	out := filepath.Join(dir, "again.json")
+	if code := runBackup([]string{"-json", out}); code != 0 {
+		t.Fatalf("backup -json exited with %d", code)
+	}
+	if code := runBackup([]string{"-json", out}); code == 0 {
+		t.Error("backup overwrote an existing file")
+	}
+}
+
+func TestSubscriptionSync(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	rec := s.do(http.MethodPost, "/sync", "", "")
+	s.expectStatus(rec, http.StatusBadRequest)
+
+	s.h.SetAccount(s.yt)
+	manual := s.createSubscription("UCmanual000000000000000a", "Added by hand", "channel")
+	goSub := s.createSubscription("UCgoLang0000000000000000", "Go", "channel")
+	s.yt.SetMySubscriptions(
+		youtube.SearchResult{ID: "UCgoLang0000000000000000", Title: "Go Programming", Type: "channel"},
+		youtube.SearchResult{ID: "UCrustLang00000000000000", Title: "Rust", ThumbnailURL: "https://yt3.ggpht.com/rust=s88", Type: "channel"},
+		youtube.SearchResult{ID: "UCzigLang000000000000000", Title: "Zig", Type: "channel"},
+	)
+	// Syncing runs in the background; its toast tells how it went.
+	rec = s.do(http.MethodPost, "/sync", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), `id="jobs" hx-swap-oob`) {
+		t.Errorf("sync response lacks the job toasts: %s", rec.Body.String())
+	}
+	s.jobs.Wait()
+	rec = s.do(http.MethodGet, "/jobs", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Found 3 changes to review") {
+		t.Errorf("job toasts after syncing: %s", rec.Body.String())
+	}
+	changes, err := s.queries.ListSyncChanges(ctx)
+	if err != nil || len(changes) != 3 {
+		t.Fatalf("first sync changes = %+v, %v", changes, err)
+	}
+	kinds := map[string]int64{}
+	for _, c := range changes {
+		kinds[c.Kind+" "+c.YoutubeID] = c.ID
+	}
+	// The subscription added by hand was never in the account, so it isn't
+	// proposed for removal.
+	for _, want := range []string{"add UCrustLang00000000000000", "add UCzigLang000000000000000", "rename UCgoLang0000000000000000"} {
+		if _, ok := kinds[want]; !ok {
+			t.Errorf("first sync lacks %q: %v", want, kinds)
+		}
+	}
+
+	s.expectStatus(s.do(http.MethodPost, "/sync/changes/"+fmt.Sprint(kinds["add UCrustLang00000000000000"])+"/accept", "", ""), http.StatusOK)
+	s.expectStatus(s.do(http.MethodPost, "/sync/changes/"+fmt.Sprint(kinds["add UCzigLang000000000000000"])+"/reject", "", ""), http.StatusOK)
+	rec = s.do(http.MethodPost, "/sync/changes/accept", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Nothing to review") {
+		t.Errorf("after accepting all: %s", rec.Body.String())
+	}
+	rust, err := s.queries.GetSubscriptionByYoutubeID(ctx, "UCrustLang00000000000000")
+	if err != nil || rust.Name != "Rust" {
+		t.Fatalf("accepted addition = %+v, %v", rust, err)
+	}
+	if sub, _ := s.queries.GetSubscription(ctx, goSub.ID); sub.Name != "Go Programming" {
+		t.Errorf("accepted rename left the name %q", sub.Name)
+	}
+	if _, err := s.queries.GetSubscriptionByYoutubeID(ctx, "UCzigLang000000000000000"); err == nil {
+		t.Error("rejected addition was subscribed to")
+	}
+
+	// Unsubscribing from Go on YouTube proposes its removal, a resized
+	// avatar isn't a change, and the rejected addition stays rejected.
+	s.yt.SetMySubscriptions(
+		youtube.SearchResult{ID: "UCrustLang00000000000000", Title: "Rust", ThumbnailURL: "https://yt3.ggpht.com/rust=s240", Type: "channel"},
+		youtube.SearchResult{ID: "UCzigLang000000000000000", Title: "Zig", Type: "channel"},
+	)
+	s.expectStatus(s.do(http.MethodPost, "/sync", "", ""), http.StatusOK)
+	s.jobs.Wait()
+	changes, err = s.queries.ListSyncChanges(ctx)
+	if err != nil || len(changes) != 1 || changes[0].Kind != "remove" || changes[0].YoutubeID != "UCgoLang0000000000000000" {
+		t.Fatalf("second sync changes = %+v, %v", changes, err)
+	}
+
+	// A pending removal survives the next sync, and a new avatar is proposed.
+	s.yt.SetMySubscriptions(
+		youtube.SearchResult{ID: "UCrustLang00000000000000", Title: "Rust", ThumbnailURL: "https://yt3.ggpht.com/ferris=s88", Type: "channel"},
+	)
+	if err := s.h.SyncAccountSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.jobs.Wait()
+	changes, err = s.queries.ListSyncChanges(ctx)
+	if err != nil || len(changes) != 2 || changes[0].Kind != "remove" || changes[1].Kind != "avatar" {
+		t.Fatalf("third sync changes = %+v, %v", changes, err)
+	}
+	rec = s.do(http.MethodGet, "/sync", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if !strings.Contains(rec.Body.String(), "Unsubscribed on YouTube") || !strings.Contains(rec.Body.String(), "New avatar") {
+		t.Errorf("sync modal: %s", rec.Body.String())
+	}
+	s.expectStatus(s.do(http.MethodPost, "/sync/changes/reject", "", ""), http.StatusOK)
+	if err := s.h.SyncAccountSubscriptions(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.jobs.Wait()
+	if changes, _ := s.queries.ListSyncChanges(ctx); len(changes) != 0 {
+		t.Errorf("rejected changes proposed again: %+v", changes)
+	}
+	if _, err := s.queries.GetSubscription(ctx, manual.ID); err != nil {
+		t.Errorf("subscription added by hand: %v", err)
+	}
+	if _, err := s.queries.GetSubscription(ctx, goSub.ID); err != nil {
+		t.Errorf("subscription whose removal was rejected: %v", err)
+	}
+
+	// A scheduled sync is skipped while another one runs.
+	release := make(chan struct{})
+	running, err := s.jobs.Start(ctx, "subscription-sync", func(ctx context.Context, progress jobs.Progress) (string, error) {
+		<-release
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.h.SyncAccountSubscriptions(ctx); err != nil {
+		t.Errorf("sync while another runs: %v", err)
+	}
+	close(release)
+	s.jobs.Wait()
+	list, err := s.queries.ListUndismissedJobs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := list[len(list)-1]; last.ID != running.ID {
+		t.Errorf("scheduled sync started job %d while job %d ran", last.ID, running.ID)
+	}
+}
+
+func TestJobToasts(t *testing.T) {
+	s := newTestServer(t)
+	started := make(chan struct{})
+	job, err := s.jobs.Start(context.Background(), "subscription-sync", func(ctx context.Context, progress jobs.Progress) (string, error) {
+		progress(50, 200)
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	path := "/jobs/" + fmt.Sprint(job.ID)
+
+	// A reloaded page finds the running job and polls it.
+	rec := s.do(http.MethodGet, "/jobs", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	for _, want := range []string{`hx-get="` + path + `"`, `hx-trigger="every 1s"`, "50 of 200", path + "/cancel"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("running job toast lacks %q: %s", want, rec.Body.String())
+		}
+	}
+
+	s.expectStatus(s.do(http.MethodPost, path+"/cancel", "", ""), http.StatusOK)
+	s.jobs.Wait()
+	rec = s.do(http.MethodGet, path, "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if body := rec.Body.String(); !strings.Contains(body, "Cancelled") || strings.Contains(body, "every 1s") {
+		t.Errorf("cancelled job toast: %s", body)
+	}
+
+	s.expectStatus(s.do(http.MethodPost, path+"/dismiss", "", ""), http.StatusOK)
+	rec = s.do(http.MethodGet, "/jobs", "", "")
+	s.expectStatus(rec, http.StatusOK)
+	if strings.Contains(rec.Body.String(), path) {
+		t.Errorf("dismissed job still shown: %s", rec.Body.String())
+	}
+
+	// A sync that fails says why in its toast.
+	s.h.SetAccount(s.yt)
+	s.yt.Err = errors.New("quota exceeded")
+	s.expectStatus(s.do(http.MethodPost, "/sync", "", ""), http.StatusOK)
+	s.jobs.Wait()
+	rec = s.do(http.MethodGet, "/jobs", "", "")
+	if !strings.Contains(rec.Body.String(), "quota exceeded") {
+		t.Errorf("failed sync toast: %s", rec.Body.String())
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"sync"
 
@@ -14,6 +16,9 @@ import (
 	"google.golang.org/api/youtube/v3"
 )
 
+// ErrNotSignedIn is returned by HTTPClient when nobody is signed in.
+var ErrNotSignedIn = errors.New("auth: not signed in with Google")
+
 type Manager struct {
 	config *oauth2.Config
 	token  *oauth2.Token
@@ -65,6 +70,16 @@ func (m *Manager) Client(ctx context.Context) *oauth2.Token {
 	return m.token
 }
 
+// HTTPClient returns a client that authorizes its requests with the token,
+// refreshing it as needed.
+func (m *Manager) HTTPClient(ctx context.Context) (*http.Client, error) {
+	token := m.Token()
+	if token == nil {
+		return nil, ErrNotSignedIn
+	}
+	return m.config.Client(ctx, token), nil
+}
+
 func (m *Manager) Config() *oauth2.Config {
 	return m.config
 }
@@ -0,0 +1,349 @@
+// Package backup snapshots and restores the state of a deck.
+//
+// A snapshot is a copy of the whole SQLite database, videos and all, made
+// with VACUUM INTO while the app keeps running. A Document is the portable
+// alternative: what the user set up and can't fetch again — subscriptions
+// with their column settings, folders, decks, mute rules, settings, the
+// watched state and the queue — as versioned JSON that doesn't depend on
+// row IDs, so it can also be merged into another deck.
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+)
+
+// Format is the version of the Document layout. Documents of a later
+// format are refused; earlier ones are read as they are.
+const Format = 1
+
+// currentDeckKey is the setting holding the ID of the deck on screen. It is
+// stored as the deck's Current flag instead, since IDs don't carry over.
+const currentDeckKey = "current_deck_id"
+
+// ErrNewerSchema is returned for backups made by a release that knows
+// migrations this one doesn't.
+var ErrNewerSchema = errors.New("backup: made by a newer release")
+
+// Document is the portable backup of a deck.
+type Document struct {
+	Format int `json:"format"`
+	// SchemaVersion is the migration the database was at.
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+
+	Subscriptions []Subscription    `json:"subscriptions"`
+	Groups        []string          `json:"groups"`
+	Decks         []Deck            `json:"decks"`
+	MuteRules     []MuteRule        `json:"mute_rules"`
+	Settings      map[string]string `json:"settings"`
+	Watched       []Watch           `json:"watched"`
+	Queue         []QueuedVideo     `json:"queue"`
+}
+
+// Subscription is a channel or playlist with its column settings.
+type Subscription struct {
+	YoutubeID          string   `json:"youtube_id"`
+	Type               string   `json:"type"`
+	Name               string   `json:"name"`
+	ThumbnailURL       string   `json:"thumbnail_url,omitempty"`
+	UploadsPlaylistID  string   `json:"uploads_playlist_id,omitempty"`
+	CustomURL          string   `json:"custom_url,omitempty"`
+	Position           int64    `json:"position"`
+	HideShorts         bool     `json:"hide_shorts,omitempty"`
+	RefreshInterval    *int64   `json:"refresh_interval,omitempty"`
+	MinDurationSeconds *int64   `json:"min_duration_seconds,omitempty"`
+	MaxDurationSeconds *int64   `json:"max_duration_seconds,omitempty"`
+	UpcomingMode       string   `json:"upcoming_mode"`
+	SortOrder          string   `json:"sort_order"`
+	Groups             []string `json:"groups,omitempty"`
+}
+
+// Deck is a deck and its columns, in order.
+type Deck struct {
+	Name    string   `json:"name"`
+	Current bool     `json:"current,omitempty"`
+	Columns []Column `json:"columns"`
+}
+
+// Column shows either a subscription, by YouTube ID, or a virtual column.
+type Column struct {
+	Subscription string         `json:"subscription,omitempty"`
+	Virtual      *VirtualColumn `json:"virtual,omitempty"`
+}
+
+// VirtualColumn merges videos across subscriptions: all of them, today's,
+// or a folder's.
+type VirtualColumn struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Group string `json:"group,omitempty"`
+}
+
+// MuteRule mutes videos by title, in one subscription or in all of them
+// when Subscription is empty.
+type MuteRule struct {
+	Subscription string `json:"subscription,omitempty"`
+	Pattern      string `json:"pattern"`
+	IsRegex      bool   `json:"is_regex,omitempty"`
+	Action       string `json:"action"`
+}
+
+// Watch is a watched video, with when it was watched if an imported watch
+// history tells.
+type Watch struct {
+	YoutubeID string     `json:"youtube_id"`
+	WatchedAt *time.Time `json:"watched_at,omitempty"`
+}
+
+// QueuedVideo is a video in the queue. It carries enough of the video to
+// show it before its subscription is fetched again.
+type QueuedVideo struct {
+	YoutubeID       string     `json:"youtube_id"`
+	Subscription    string     `json:"subscription"`
+	Title           string     `json:"title"`
+	ThumbnailURL    string     `json:"thumbnail_url,omitempty"`
+	Duration        string     `json:"duration,omitempty"`
+	DurationSeconds *int64     `json:"duration_seconds,omitempty"`
+	PublishedAt     *time.Time `json:"published_at,omitempty"`
+}
+
+// Snapshot writes a consistent copy of the database to path, which must not
+// exist yet. Writers wait for it to finish; readers don't.
+func Snapshot(ctx context.Context, database *sql.DB, path string) error {
+	if _, err := database.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backup: snapshot: %w", err)
+	}
+	return nil
+}
+
+// SchemaVersion returns the latest migration applied to database, or 0 for
+// a database without any.
+func SchemaVersion(ctx context.Context, database *sql.DB) (int64, error) {
+	statuses, err := migrations.List(ctx, database)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for _, s := range statuses {
+		if s.Applied {
+			version = s.Version
+		}
+	}
+	return version, nil
+}
+
+// CheckSchema returns ErrNewerSchema when version is past the latest
+// migration this release knows. Older versions are fine: migrating brings
+// them up to date.
+func CheckSchema(version int64) error {
+	all, err := migrations.Load()
+	if err != nil {
+		return err
+	}
+	if latest := all[len(all)-1].Version; version > latest {
+		return fmt.Errorf("%w (schema version %d, this release knows up to %d)", ErrNewerSchema, version, latest)
+	}
+	return nil
+}
+
+// Export reads the portable backup out of database.
+func Export(ctx context.Context, database *sql.DB) (*Document, error) {
+	version, err := SchemaVersion(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	// One read transaction keeps the document consistent while the app
+	// goes on writing.
+	tx, err := database.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	q := db.New(tx)
+
+	doc := &Document{Format: Format, SchemaVersion: version, CreatedAt: time.Now().UTC(), Settings: map[string]string{}}
+
+	memberships, err := q.ListSubscriptionGroupNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[int64][]string)
+	for _, m := range memberships {
+		groups[m.SubscriptionID] = append(groups[m.SubscriptionID], m.GroupName)
+	}
+	subs, err := q.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range subs {
+		doc.Subscriptions = append(doc.Subscriptions, Subscription{
+			YoutubeID:          s.YoutubeID,
+			Type:               s.Type,
+			Name:               s.Name,
+			ThumbnailURL:       s.ThumbnailUrl.String,
+			UploadsPlaylistID:  s.UploadsPlaylistID.String,
+			CustomURL:          s.CustomUrl.String,
+			Position:           s.Position.Int64,
+			HideShorts:         s.HideShorts.Int64 != 0,
+			RefreshInterval:    nullInt(s.RefreshInterval),
+			MinDurationSeconds: nullInt(s.MinDurationSeconds),
+			MaxDurationSeconds: nullInt(s.MaxDurationSeconds),
+			UpcomingMode:       s.UpcomingMode,
+			SortOrder:          s.SortOrder,
+			Groups:             groups[s.ID],
+		})
+	}
+
+	groupRows, err := q.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groupRows {
+		doc.Groups = append(doc.Groups, g.Name)
+	}
+
+	settings, err := q.ListSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	currentDeck := ""
+	for _, s := range settings {
+		if s.Key == currentDeckKey {
+			currentDeck = s.Value
+			continue
+		}
+		doc.Settings[s.Key] = s.Value
+	}
+	decks, err := q.ListDecks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	columns, err := q.ListDeckColumnsForBackup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range decks {
+		deck := Deck{Name: d.Name, Current: fmt.Sprint(d.ID) == currentDeck, Columns: []Column{}}
+		for _, c := range columns {
+			switch {
+			case c.DeckID != d.ID:
+			case c.SubscriptionYoutubeID.Valid:
+				deck.Columns = append(deck.Columns, Column{Subscription: c.SubscriptionYoutubeID.String})
+			case c.VirtualKind.Valid:
+				deck.Columns = append(deck.Columns, Column{Virtual: &VirtualColumn{
+					Kind: c.VirtualKind.String, Name: c.VirtualName.String, Group: c.GroupName.String,
+				}})
+			}
+		}
+		doc.Decks = append(doc.Decks, deck)
+	}
+
+	rules, err := q.ListMuteRulesForBackup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		doc.MuteRules = append(doc.MuteRules, MuteRule{
+			Subscription: r.SubscriptionYoutubeID.String, Pattern: r.Pattern, IsRegex: r.IsRegex != 0, Action: r.Action,
+		})
+	}
+
+	if doc.Watched, err = exportWatched(ctx, q); err != nil {
+		return nil, err
+	}
+
+	queue, err := q.ListQueueForBackup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range queue {
+		doc.Queue = append(doc.Queue, QueuedVideo{
+			YoutubeID:       v.YoutubeID,
+			Subscription:    v.SubscriptionYoutubeID,
+			Title:           v.Title,
+			ThumbnailURL:    v.ThumbnailUrl.String,
+			Duration:        v.Duration.String,
+			DurationSeconds: nullInt(v.DurationSeconds),
+			PublishedAt:     nullTime(v.PublishedAt),
+		})
+	}
+	return doc, nil
+}
+
+// exportWatched lists the watched videos together with the rest of the
+// imported watch history, whose videos may not be stored yet.
+func exportWatched(ctx context.Context, q *db.Queries) ([]Watch, error) {
+	history, err := q.ListWatchHistory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	watched, err := q.ListWatchedVideoIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(history))
+	var watches []Watch
+	for _, h := range history {
+		seen[h.YoutubeID] = true
+		watches = append(watches, Watch{YoutubeID: h.YoutubeID, WatchedAt: nullTime(h.WatchedAt)})
+	}
+	for _, id := range watched {
+		if !seen[id] {
+			watches = append(watches, Watch{YoutubeID: id})
+		}
+	}
+	slices.SortFunc(watches, func(a, b Watch) int {
+		return strings.Compare(a.YoutubeID, b.YoutubeID)
+	})
+	return watches, nil
+}
+
+// Write writes doc as indented JSON.
+func Write(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// Read reads a Document, refusing ones of a later format or schema.
+func Read(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("backup: read: %w", err)
+	}
+	if doc.Format < 1 {
+		return nil, errors.New("backup: not a deck backup")
+	}
+	if doc.Format > Format {
+		return nil, fmt.Errorf("%w (format %d, this release reads up to %d)", ErrNewerSchema, doc.Format, Format)
+	}
+	if err := CheckSchema(doc.SchemaVersion); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+func nullInt(n sql.NullInt64) *int64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int64
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,207 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+	if _, err := migrations.Up(context.Background(), database); err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func mustExec(t *testing.T, database *sql.DB, stmts ...string) {
+	t.Helper()
+	for _, stmt := range stmts {
+		if _, err := database.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+// seed fills database with a deck worth backing up.
+func seed(t *testing.T, database *sql.DB) {
+	mustExec(t, database,
+		`INSERT INTO subscriptions (id, name, youtube_id, type, position, hide_shorts, refresh_interval, min_duration_seconds, upcoming_mode, sort_order)
+		 VALUES (1, 'Go', 'UCgoLang0000000000000000', 'channel', 1, 1, 30, 60, 'hide', 'popular'),
+		        (2, 'Lectures', 'PLlectures000000', 'playlist', 2, 0, NULL, NULL, 'show', 'playlist')`,
+		`INSERT INTO groups (id, name, position) VALUES (1, 'Tech', 0), (2, 'Empty', 1)`,
+		`INSERT INTO subscription_groups VALUES (1, 1)`,
+		`INSERT INTO decks (id, name, position) VALUES (2, 'Evening', 1)`,
+		`INSERT INTO virtual_columns (id, kind, name, group_id) VALUES (1, 'group', 'Tech', 1)`,
+		`INSERT INTO deck_columns (deck_id, subscription_id, virtual_column_id, position) VALUES
+		 (1, 2, NULL, 0), (1, 1, NULL, 1), (2, NULL, 1, 0)`,
+		`UPDATE settings SET value = '2' WHERE key = 'current_deck_id'`,
+		`INSERT INTO settings (key, value) VALUES ('theme', 'dark')`,
+		`INSERT INTO mute_rules (subscription_id, pattern, is_regex, action) VALUES (NULL, 'sponsor', 0, 'exclude'), (1, '^Live', 1, 'exclude')`,
+		`INSERT INTO videos (id, subscription_id, youtube_id, title, published_at, watched) VALUES
+		 (1, 1, 'goVideo0000', 'Go 0', '2024-01-01 00:00:00', 1),
+		 (2, 1, 'goVideo0001', 'Go 1', '2024-01-02 00:00:00', 0)`,
+		`INSERT INTO watch_history (youtube_id, watched_at) VALUES ('notStored00', '2024-06-01 10:00:00')`,
+		`INSERT INTO queue (video_id, position) VALUES (2, 0)`,
+	)
+}
+
+func export(t *testing.T, database *sql.DB) *Document {
+	t.Helper()
+	doc, err := Export(context.Background(), database)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	doc.CreatedAt = time.Time{}
+	return doc
+}
+
+func TestExportRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t)
+	seed(t, source)
+	doc := export(t, source)
+
+	if len(doc.Subscriptions) != 2 || len(doc.Decks) != 2 || !doc.Decks[1].Current || len(doc.Watched) != 2 || len(doc.Queue) != 1 {
+		t.Fatalf("Export = %+v", doc)
+	}
+	if _, ok := doc.Settings[currentDeckKey]; ok {
+		t.Error("Export kept the current deck ID among the settings")
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, doc); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	target := openTestDB(t)
+	stats, err := Restore(ctx, target, read, true)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if stats.Subscriptions != 2 || stats.Decks != 1 || stats.Queued != 1 {
+		t.Errorf("Restore stats = %+v", stats)
+	}
+	if restored := export(t, target); !reflect.DeepEqual(restored, doc) {
+		t.Errorf("restored deck differs:\n got %+v\nwant %+v", restored, doc)
+	}
+	// Watched videos that aren't stored are marked as they are fetched.
+	var watched int64
+	if err := target.QueryRow("SELECT watched FROM videos WHERE youtube_id = 'goVideo0001'").Scan(&watched); err != nil || watched != 0 {
+		t.Errorf("queued video watched = %d, %v", watched, err)
+	}
+	mustExec(t, target, `INSERT INTO videos (id, subscription_id, youtube_id, title) SELECT 100, id, 'goVideo0000', 'Go 0' FROM subscriptions WHERE youtube_id = 'UCgoLang0000000000000000'`)
+	if marked, err := db.New(target).MarkNewVideosWatchedFromHistory(ctx, []int64{100}); err != nil || len(marked) != 1 {
+		t.Errorf("fetched video marked watched: %v, %v", marked, err)
+	}
+}
+
+func TestRestoreMerges(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t)
+	seed(t, source)
+	doc := export(t, source)
+
+	target := openTestDB(t)
+	mustExec(t, target,
+		`INSERT INTO subscriptions (id, name, youtube_id, type, position, sort_order) VALUES (1, 'Go here', 'UCgoLang0000000000000000', 'channel', 5, 'newest')`,
+		`INSERT INTO subscriptions (id, name, youtube_id, type, position) VALUES (2, 'Rust', 'UCrustLang00000000000000', 'channel', 6)`,
+		`INSERT INTO videos (id, subscription_id, youtube_id, title, watched) VALUES (1, 1, 'goVideo0000', 'Go 0', 0)`,
+		`INSERT INTO settings (key, value) VALUES ('theme', 'light')`,
+	)
+	stats, err := Restore(ctx, target, doc, false)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if stats.Subscriptions != 1 || stats.Skipped != 1 || stats.Decks != 1 || stats.Watched != 1 || stats.Queued != 1 {
+		t.Errorf("Restore stats = %+v", stats)
+	}
+
+	var name, sortOrder string
+	if err := target.QueryRow("SELECT name, sort_order FROM subscriptions WHERE id = 1").Scan(&name, &sortOrder); err != nil || name != "Go here" || sortOrder != "newest" {
+		t.Errorf("existing subscription became %q, %q, %v", name, sortOrder, err)
+	}
+	var position int64
+	if err := target.QueryRow("SELECT position FROM subscriptions WHERE youtube_id = 'PLlectures000000'").Scan(&position); err != nil || position != 8 {
+		t.Errorf("merged subscription position = %d, %v; want after the existing ones", position, err)
+	}
+	var theme, current string
+	if err := target.QueryRow("SELECT (SELECT value FROM settings WHERE key = 'theme'), (SELECT value FROM settings WHERE key = 'current_deck_id')").Scan(&theme, &current); err != nil || theme != "light" || current != "1" {
+		t.Errorf("settings = %q, %q, %v; want the existing ones kept", theme, current, err)
+	}
+	var groups int64
+	if err := target.QueryRow("SELECT COUNT(*) FROM subscription_groups sg JOIN groups g ON g.id = sg.group_id WHERE g.name = 'Tech' AND sg.subscription_id = 1").Scan(&groups); err != nil || groups != 1 {
+		t.Errorf("existing subscription not filed in the backup's folder: %d, %v", groups, err)
+	}
+
+	// Merging the same backup again adds nothing.
+	again, err := Restore(ctx, target, doc, false)
+	if err != nil || again.Subscriptions != 0 || again.Decks != 0 || again.Queued != 0 {
+		t.Errorf("second Restore = %+v, %v", again, err)
+	}
+	var columns, rules int64
+	if err := target.QueryRow("SELECT (SELECT COUNT(*) FROM deck_columns), (SELECT COUNT(*) FROM mute_rules)").Scan(&columns, &rules); err != nil || columns != 3 || rules != 2 {
+		t.Errorf("after merging twice: %d columns, %d mute rules, %v", columns, rules, err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t)
+	seed(t, source)
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := Snapshot(ctx, source, path); err != nil {
+		t.Fatal(err)
+	}
+	if err := Snapshot(ctx, source, path); err == nil {
+		t.Error("Snapshot overwrote an existing file")
+	}
+
+	copied, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer copied.Close()
+	version, err := SchemaVersion(ctx, copied)
+	if err != nil || version == 0 {
+		t.Fatalf("snapshot schema version = %d, %v", version, err)
+	}
+	if doc := export(t, copied); !reflect.DeepEqual(doc, export(t, source)) {
+		t.Errorf("snapshot differs from its source: %+v", doc)
+	}
+}
+
+func TestReadRefusesNewerBackups(t *testing.T) {
+	for _, file := range []string{
+		`{"format": 2, "schema_version": 1}`,
+		`{"format": 1, "schema_version": 999999}`,
+	} {
+		if _, err := Read(strings.NewReader(file)); !errors.Is(err, ErrNewerSchema) {
+			t.Errorf("Read(%s) error = %v, want ErrNewerSchema", file, err)
+		}
+	}
+	if _, err := Read(strings.NewReader(`{"subscriptions": []}`)); err == nil {
+		t.Error("Read accepted a document without a format")
+	}
+}
@@ -0,0 +1,322 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"youtube-deck-go/internal/db"
+)
+
+// Stats counts what Restore changed.
+type Stats struct {
+	Subscriptions int
+	// Skipped counts the subscriptions the database had already.
+	Skipped int
+	Decks   int
+	Watched int
+	Queued  int
+}
+
+// Restore loads doc into database in one transaction. It merges: what the
+// database has already is kept as it is, and the backup adds to it —
+// subscriptions it lacks, folder memberships, decks, the columns missing
+// from decks of the same name, mute rules, watched videos and queued ones.
+//
+// With replace, database is a fresh one taking the place of the deck the
+// backup was made of, so the backup's settings and current deck win over the
+// defaults migrations created.
+func Restore(ctx context.Context, database *sql.DB, doc *Document, replace bool) (Stats, error) {
+	var stats Stats
+	tx, err := database.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, err
+	}
+	defer tx.Rollback()
+	r := &restorer{q: db.New(tx), groups: make(map[string]int64)}
+
+	subs, err := r.restoreSubscriptions(ctx, doc.Subscriptions, &stats)
+	if err != nil {
+		return stats, err
+	}
+	for _, name := range doc.Groups {
+		if _, err := r.group(ctx, name); err != nil {
+			return stats, err
+		}
+	}
+	if err := r.restoreDecks(ctx, doc.Decks, subs, replace, &stats); err != nil {
+		return stats, err
+	}
+	if err := r.restoreMuteRules(ctx, doc.MuteRules, subs); err != nil {
+		return stats, err
+	}
+	for key, value := range doc.Settings {
+		if key == currentDeckKey {
+			continue
+		}
+		if _, err := r.q.GetSetting(ctx, key); err == nil && !replace {
+			continue
+		} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return stats, err
+		}
+		if err := r.q.SetSetting(ctx, db.SetSettingParams{Key: key, Value: value}); err != nil {
+			return stats, err
+		}
+	}
+
+	// Watched videos go into the watch history, which marks the stored ones
+	// now and the others once their subscription is fetched.
+	for _, w := range doc.Watched {
+		var at sql.NullTime
+		if w.WatchedAt != nil {
+			at = sql.NullTime{Time: *w.WatchedAt, Valid: true}
+		}
+		if err := r.q.AddWatchHistory(ctx, db.AddWatchHistoryParams{YoutubeID: w.YoutubeID, WatchedAt: at}); err != nil {
+			return stats, err
+		}
+	}
+	marked, err := r.q.MarkWatchHistoryWatched(ctx)
+	if err != nil {
+		return stats, err
+	}
+	stats.Watched = int(marked)
+
+	for _, v := range doc.Queue {
+		subID, ok := subs[v.Subscription]
+		if !ok {
+			continue
+		}
+		videoID, err := r.q.RestoreVideo(ctx, db.RestoreVideoParams{
+			SubscriptionID:  subID,
+			YoutubeID:       v.YoutubeID,
+			Title:           v.Title,
+			ThumbnailUrl:    sql.NullString{String: v.ThumbnailURL, Valid: v.ThumbnailURL != ""},
+			Duration:        sql.NullString{String: v.Duration, Valid: v.Duration != ""},
+			DurationSeconds: toNullInt(v.DurationSeconds),
+			PublishedAt:     toNullTime(v.PublishedAt),
+		})
+		if err != nil {
+			return stats, fmt.Errorf("backup: queue %s: %w", v.YoutubeID, err)
+		}
+		n, err := r.q.EnqueueVideo(ctx, videoID)
+		if err != nil {
+			return stats, err
+		}
+		stats.Queued += int(n)
+	}
+
+	// The restored rules may hide stored videos, and restored videos may be
+	// hidden.
+	if err := r.q.UpdateMutedVideos(ctx, sql.NullInt64{}); err != nil {
+		return stats, err
+	}
+	if err := tx.Commit(); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+type restorer struct {
+	q *db.Queries
+	// groups caches folder IDs by name.
+	groups map[string]int64
+}
+
+// restoreSubscriptions adds the subscriptions the database lacks after the
+// ones it has, keeping their order, and returns the IDs of all of them by
+// YouTube ID.
+func (r *restorer) restoreSubscriptions(ctx context.Context, backup []Subscription, stats *Stats) (map[string]int64, error) {
+	existing, err := r.q.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]int64, len(existing)+len(backup))
+	var offset int64
+	for _, s := range existing {
+		ids[s.YoutubeID] = s.ID
+		offset = max(offset, s.Position.Int64)
+	}
+
+	for _, s := range backup {
+		id, ok := ids[s.YoutubeID]
+		if ok {
+			stats.Skipped++
+		} else {
+			hideShorts := int64(0)
+			if s.HideShorts {
+				hideShorts = 1
+			}
+			id, err = r.q.RestoreSubscription(ctx, db.RestoreSubscriptionParams{
+				Name:               s.Name,
+				YoutubeID:          s.YoutubeID,
+				Type:               s.Type,
+				ThumbnailUrl:       sql.NullString{String: s.ThumbnailURL, Valid: s.ThumbnailURL != ""},
+				Position:           sql.NullInt64{Int64: offset + s.Position, Valid: true},
+				HideShorts:         sql.NullInt64{Int64: hideShorts, Valid: true},
+				RefreshInterval:    toNullInt(s.RefreshInterval),
+				UploadsPlaylistID:  sql.NullString{String: s.UploadsPlaylistID, Valid: s.UploadsPlaylistID != ""},
+				CustomUrl:          sql.NullString{String: s.CustomURL, Valid: s.CustomURL != ""},
+				MinDurationSeconds: toNullInt(s.MinDurationSeconds),
+				MaxDurationSeconds: toNullInt(s.MaxDurationSeconds),
+				UpcomingMode:       s.UpcomingMode,
+				SortOrder:          s.SortOrder,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("backup: subscription %s: %w", s.YoutubeID, err)
+			}
+			ids[s.YoutubeID] = id
+			stats.Subscriptions++
+		}
+		for _, name := range s.Groups {
+			groupID, err := r.group(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if err := r.q.AddSubscriptionGroup(ctx, db.AddSubscriptionGroupParams{SubscriptionID: id, GroupID: groupID}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ids, nil
+}
+
+// group returns the ID of the folder called name, creating it if needed.
+func (r *restorer) group(ctx context.Context, name string) (int64, error) {
+	if id, ok := r.groups[name]; ok {
+		return id, nil
+	}
+	g, err := r.q.GetGroupByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		g, err = r.q.CreateGroup(ctx, name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("backup: folder %q: %w", name, err)
+	}
+	r.groups[name] = g.ID
+	return g.ID, nil
+}
+
+// restoreDecks creates the decks the database lacks and appends to each deck
+// the columns it doesn't show yet.
+func (r *restorer) restoreDecks(ctx context.Context, backup []Deck, subs map[string]int64, replace bool, stats *Stats) error {
+	existing, err := r.q.ListDecks(ctx)
+	if err != nil {
+		return err
+	}
+	decks := make(map[string]int64, len(existing))
+	for _, d := range existing {
+		decks[d.Name] = d.ID
+	}
+
+	for _, d := range backup {
+		deckID, ok := decks[d.Name]
+		if !ok {
+			deck, err := r.q.CreateDeck(ctx, d.Name)
+			if err != nil {
+				return err
+			}
+			deckID = deck.ID
+			decks[d.Name] = deckID
+			stats.Decks++
+		}
+		virtual, err := r.q.ListVirtualColumns(ctx, deckID)
+		if err != nil {
+			return err
+		}
+		for _, c := range d.Columns {
+			if c.Virtual == nil {
+				subID, ok := subs[c.Subscription]
+				if !ok {
+					continue
+				}
+				if err := r.q.AddDeckSubscription(ctx, db.AddDeckSubscriptionParams{DeckID: deckID, SubscriptionID: sql.NullInt64{Int64: subID, Valid: true}}); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := r.restoreVirtualColumn(ctx, deckID, *c.Virtual, virtual); err != nil {
+				return err
+			}
+		}
+		if d.Current && replace {
+			if err := r.q.SetSetting(ctx, db.SetSettingParams{Key: currentDeckKey, Value: strconv.FormatInt(deckID, 10)}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreVirtualColumn adds c to the deck unless the deck has the same
+// column among onDeck already.
+func (r *restorer) restoreVirtualColumn(ctx context.Context, deckID int64, c VirtualColumn, onDeck []db.VirtualColumn) error {
+	var groupID sql.NullInt64
+	if c.Group != "" {
+		id, err := r.group(ctx, c.Group)
+		if err != nil {
+			return err
+		}
+		groupID = sql.NullInt64{Int64: id, Valid: true}
+	}
+	for _, v := range onDeck {
+		if v.Kind == c.Kind && v.Name == c.Name && v.GroupID == groupID {
+			return nil
+		}
+	}
+	vc, err := r.q.CreateVirtualColumn(ctx, db.CreateVirtualColumnParams{Kind: c.Kind, Name: c.Name, GroupID: groupID})
+	if err != nil {
+		return err
+	}
+	return r.q.AddDeckVirtualColumn(ctx, db.AddDeckVirtualColumnParams{DeckID: deckID, VirtualColumnID: sql.NullInt64{Int64: vc.ID, Valid: true}})
+}
+
+// restoreMuteRules adds the rules the database doesn't have yet.
+func (r *restorer) restoreMuteRules(ctx context.Context, backup []MuteRule, subs map[string]int64) error {
+	existing, err := r.q.ListMuteRulesForBackup(ctx)
+	if err != nil {
+		return err
+	}
+	have := make(map[MuteRule]bool, len(existing))
+	for _, m := range existing {
+		have[MuteRule{Subscription: m.SubscriptionYoutubeID.String, Pattern: m.Pattern, IsRegex: m.IsRegex != 0, Action: m.Action}] = true
+	}
+	for _, m := range backup {
+		if have[m] {
+			continue
+		}
+		var subID sql.NullInt64
+		if m.Subscription != "" {
+			id, ok := subs[m.Subscription]
+			if !ok {
+				continue
+			}
+			subID = sql.NullInt64{Int64: id, Valid: true}
+		}
+		isRegex := int64(0)
+		if m.IsRegex {
+			isRegex = 1
+		}
+		if _, err := r.q.CreateMuteRule(ctx, db.CreateMuteRuleParams{SubscriptionID: subID, Pattern: m.Pattern, IsRegex: isRegex, Action: m.Action}); err != nil {
+			return err
+		}
+		have[m] = true
+	}
+	return nil
+}
+
+func toNullInt(n *int64) sql.NullInt64 {
+	if n == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *n, Valid: true}
+}
+
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -1,6 +1,12 @@
 package db
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
 
 func NullInt64Value(n sql.NullInt64, defaultVal int64) int64 {
 	if n.Valid {
@@ -19,3 +25,9 @@ func NullStringValue(n sql.NullString, defaultVal string) string {
 func NullInt64ToBool(n sql.NullInt64) bool {
 	return n.Valid && n.Int64 == 1
 }
+
+// IsUniqueViolation reports whether err is a UNIQUE constraint failure.
+func IsUniqueViolation(err error) bool {
+	var serr *sqlite.Error
+	return errors.As(err, &serr) && serr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS quota_usage;
+DROP TABLE IF EXISTS videos;
+DROP TABLE IF EXISTS subscriptions;
@@ -0,0 +1,43 @@
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    youtube_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK(type IN ('channel', 'playlist')),
+    thumbnail_url TEXT,
+    last_checked DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    position INTEGER DEFAULT 0,
+    active INTEGER DEFAULT 0,
+    page_token TEXT,
+    hide_shorts INTEGER DEFAULT 0,
+    refresh_interval INTEGER,
+    uploads_playlist_id TEXT,
+    custom_url TEXT,
+    subscriber_count INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS videos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    youtube_id TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    thumbnail_url TEXT,
+    duration TEXT,
+    published_at DATETIME,
+    watched INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    is_short INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS quota_usage (
+    day TEXT NOT NULL,
+    call TEXT NOT NULL,
+    units INTEGER NOT NULL DEFAULT 0,
+    calls INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, call)
+);
+
+CREATE INDEX IF NOT EXISTS idx_videos_subscription ON videos(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_videos_watched ON videos(watched);
+CREATE INDEX IF NOT EXISTS idx_videos_sub_watched_short ON videos(subscription_id, watched, is_short);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_active_position ON subscriptions(active, position);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_videos_watched_published;
+DROP TABLE IF EXISTS virtual_columns;
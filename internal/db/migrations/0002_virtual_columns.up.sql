@@ -0,0 +1,11 @@
+-- Virtual columns merge unwatched videos across subscriptions. kind is one of
+-- 'all' or 'today'; a row exists for every virtual column open on the deck.
+CREATE TABLE virtual_columns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_videos_watched_published ON videos(watched, published_at, id);
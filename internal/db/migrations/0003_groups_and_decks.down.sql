@@ -0,0 +1,35 @@
+DROP INDEX IF EXISTS idx_subscriptions_position;
+ALTER TABLE subscriptions ADD COLUMN active INTEGER DEFAULT 0;
+CREATE INDEX idx_subscriptions_active_position ON subscriptions(active, position);
+
+-- Only the current deck survives the downgrade.
+UPDATE subscriptions SET active = 1
+WHERE id IN (
+    SELECT subscription_id FROM deck_columns
+    WHERE deck_id = (SELECT CAST(value AS INTEGER) FROM settings WHERE key = 'current_deck_id')
+);
+DELETE FROM virtual_columns
+WHERE group_id IS NOT NULL
+   OR id NOT IN (
+    SELECT virtual_column_id FROM deck_columns
+    WHERE deck_id = (SELECT CAST(value AS INTEGER) FROM settings WHERE key = 'current_deck_id')
+      AND virtual_column_id IS NOT NULL
+);
+
+DROP TABLE settings;
+DROP TABLE deck_columns;
+DROP TABLE decks;
+-- SQLite can't drop a column that is a foreign key, so rebuild the table.
+CREATE TABLE virtual_columns_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO virtual_columns_old (id, kind, name, position, created_at)
+SELECT id, kind, name, position, created_at FROM virtual_columns;
+DROP TABLE virtual_columns;
+ALTER TABLE virtual_columns_old RENAME TO virtual_columns;
+DROP TABLE subscription_groups;
+DROP TABLE groups;
@@ -0,0 +1,57 @@
+-- Folders group subscriptions; a subscription can be in any number of them.
+CREATE TABLE groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE subscription_groups (
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    PRIMARY KEY (subscription_id, group_id)
+);
+
+CREATE INDEX idx_subscription_groups_group ON subscription_groups(group_id);
+
+-- Folder columns merge the videos of one group.
+ALTER TABLE virtual_columns ADD COLUMN group_id INTEGER REFERENCES groups(id) ON DELETE CASCADE;
+
+-- A deck is a named, ordered set of columns. Each column shows either a
+-- subscription or a virtual column; virtual columns belong to a single deck.
+CREATE TABLE decks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE deck_columns (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    deck_id INTEGER NOT NULL REFERENCES decks(id) ON DELETE CASCADE,
+    subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE CASCADE,
+    virtual_column_id INTEGER REFERENCES virtual_columns(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    CHECK ((subscription_id IS NULL) != (virtual_column_id IS NULL))
+);
+
+CREATE UNIQUE INDEX idx_deck_columns_subscription ON deck_columns(deck_id, subscription_id);
+CREATE UNIQUE INDEX idx_deck_columns_virtual ON deck_columns(virtual_column_id);
+CREATE INDEX idx_deck_columns_deck_position ON deck_columns(deck_id, position);
+
+CREATE TABLE settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
+-- The columns that were active become the first deck.
+INSERT INTO decks (id, name) VALUES (1, 'Main');
+INSERT INTO settings (key, value) VALUES ('current_deck_id', '1');
+INSERT INTO deck_columns (deck_id, subscription_id, position)
+SELECT 1, id, COALESCE(position, 0) FROM subscriptions WHERE active = 1;
+INSERT INTO deck_columns (deck_id, virtual_column_id, position)
+SELECT 1, id, position FROM virtual_columns;
+
+DROP INDEX IF EXISTS idx_subscriptions_active_position;
+ALTER TABLE subscriptions DROP COLUMN active;
+CREATE INDEX idx_subscriptions_position ON subscriptions(position);
@@ -0,0 +1,2 @@
+DELETE FROM virtual_columns WHERE kind = 'queue';
+DROP TABLE queue;
@@ -0,0 +1,8 @@
+-- The watch-later queue, played from the lowest position up.
+CREATE TABLE queue (
+    video_id INTEGER PRIMARY KEY REFERENCES videos(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL DEFAULT 0,
+    added_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_queue_position ON queue(position);
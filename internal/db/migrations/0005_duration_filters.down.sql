@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP COLUMN max_duration_seconds;
+ALTER TABLE subscriptions DROP COLUMN min_duration_seconds;
+ALTER TABLE videos DROP COLUMN duration_seconds;
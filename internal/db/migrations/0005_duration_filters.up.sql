@@ -0,0 +1,7 @@
+-- The length of each video in seconds, parsed from the ISO-8601 duration.
+-- Rows stored before this migration are backfilled at startup.
+ALTER TABLE videos ADD COLUMN duration_seconds INTEGER;
+
+-- Optional bounds on the length of the videos a subscription's column shows.
+ALTER TABLE subscriptions ADD COLUMN min_duration_seconds INTEGER;
+ALTER TABLE subscriptions ADD COLUMN max_duration_seconds INTEGER;
@@ -0,0 +1,2 @@
+DROP VIEW muted_videos;
+DROP TABLE mute_rules;
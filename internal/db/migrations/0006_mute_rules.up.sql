@@ -0,0 +1,36 @@
+-- Title patterns that mute videos. Rules without a subscription apply to
+-- every subscription. An exclude rule mutes the videos it matches; once any
+-- include rule applies, videos matching none of them are muted as well.
+CREATE TABLE mute_rules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER REFERENCES subscriptions(id) ON DELETE CASCADE,
+    pattern TEXT NOT NULL,
+    is_regex INTEGER NOT NULL DEFAULT 0,
+    action TEXT NOT NULL CHECK (action IN ('exclude', 'include')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_mute_rules_subscription ON mute_rules(subscription_id);
+
+-- mute_match(pattern, is_regex, title) was registered by the server and
+-- matched case-insensitively. 0016 replaces this view with videos.muted.
+CREATE VIEW muted_videos AS
+SELECT v.id AS video_id
+FROM videos v
+WHERE EXISTS (
+    SELECT 1 FROM mute_rules r
+    WHERE r.action = 'exclude'
+      AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+      AND mute_match(r.pattern, r.is_regex, v.title)
+) OR (
+    EXISTS (
+        SELECT 1 FROM mute_rules r
+        WHERE r.action = 'include'
+          AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+    ) AND NOT EXISTS (
+        SELECT 1 FROM mute_rules r
+        WHERE r.action = 'include'
+          AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+          AND mute_match(r.pattern, r.is_regex, v.title)
+    )
+);
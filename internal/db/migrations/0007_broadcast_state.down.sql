@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions DROP COLUMN upcoming_mode;
+DROP INDEX idx_videos_broadcast_state;
+ALTER TABLE videos DROP COLUMN scheduled_start_at;
+ALTER TABLE videos DROP COLUMN broadcast_state;
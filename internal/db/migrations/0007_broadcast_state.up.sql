@@ -0,0 +1,11 @@
+-- Livestreams and premieres: none, upcoming, live or completed, and when an
+-- upcoming one is scheduled to start.
+ALTER TABLE videos ADD COLUMN broadcast_state TEXT NOT NULL DEFAULT 'none';
+ALTER TABLE videos ADD COLUMN scheduled_start_at DATETIME;
+
+CREATE INDEX idx_videos_broadcast_state ON videos(broadcast_state) WHERE broadcast_state IN ('upcoming', 'live');
+
+-- How a column shows upcoming broadcasts: in the list (show), in a section
+-- of their own (separate) or not at all (hide).
+ALTER TABLE subscriptions ADD COLUMN upcoming_mode TEXT NOT NULL DEFAULT 'show'
+    CHECK (upcoming_mode IN ('show', 'separate', 'hide'));
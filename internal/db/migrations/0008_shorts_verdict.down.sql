@@ -0,0 +1,5 @@
+DROP INDEX idx_videos_short_unknown;
+UPDATE videos SET is_short = 0 WHERE is_short IS NULL;
+ALTER TABLE videos DROP COLUMN short_attempts;
+ALTER TABLE videos DROP COLUMN short_checked_at;
+ALTER TABLE videos DROP COLUMN short_source;
@@ -0,0 +1,14 @@
+-- is_short becomes tri-state: 1 a Short, 0 not a Short, NULL not known yet.
+-- short_source records which signal decided it (duration, aspect or probe);
+-- unknown videos are probed again with backoff, counting attempts.
+ALTER TABLE videos ADD COLUMN short_source TEXT;
+ALTER TABLE videos ADD COLUMN short_checked_at DATETIME;
+ALTER TABLE videos ADD COLUMN short_attempts INTEGER NOT NULL DEFAULT 0;
+
+-- Only a successful probe ever stored a 1. A 0 may have been a failed probe,
+-- so it is unknown unless the video is too long to be a Short.
+UPDATE videos SET short_source = 'probe' WHERE is_short = 1;
+UPDATE videos SET is_short = NULL WHERE COALESCE(is_short, 0) = 0;
+UPDATE videos SET is_short = 0, short_source = 'duration' WHERE is_short IS NULL AND duration_seconds > 180;
+
+CREATE INDEX idx_videos_short_unknown ON videos(short_checked_at) WHERE is_short IS NULL;
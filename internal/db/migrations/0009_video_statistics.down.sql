@@ -0,0 +1,6 @@
+ALTER TABLE subscriptions DROP COLUMN sort_order;
+ALTER TABLE videos DROP COLUMN stats_updated_at;
+ALTER TABLE videos DROP COLUMN comment_count;
+ALTER TABLE videos DROP COLUMN like_count;
+ALTER TABLE videos DROP COLUMN view_count;
+ALTER TABLE videos DROP COLUMN description;
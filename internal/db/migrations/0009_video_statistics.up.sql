@@ -0,0 +1,10 @@
+-- Public counters and description of a video, as of stats_updated_at.
+ALTER TABLE videos ADD COLUMN description TEXT;
+ALTER TABLE videos ADD COLUMN view_count INTEGER;
+ALTER TABLE videos ADD COLUMN like_count INTEGER;
+ALTER TABLE videos ADD COLUMN comment_count INTEGER;
+ALTER TABLE videos ADD COLUMN stats_updated_at DATETIME;
+
+-- How a column orders its videos: newest first or most viewed first.
+ALTER TABLE subscriptions ADD COLUMN sort_order TEXT NOT NULL DEFAULT 'newest'
+    CHECK (sort_order IN ('newest', 'popular'));
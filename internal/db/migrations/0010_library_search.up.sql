@@ -0,0 +1,24 @@
+-- Full-text index over the titles and descriptions of stored videos. It
+-- reads its content from videos and is kept in sync by triggers.
+CREATE VIRTUAL TABLE videos_fts USING fts5(
+    title,
+    description,
+    content = 'videos',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+INSERT INTO videos_fts (videos_fts) VALUES ('rebuild');
+
+CREATE TRIGGER videos_fts_insert AFTER INSERT ON videos BEGIN
+    INSERT INTO videos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
+
+CREATE TRIGGER videos_fts_delete AFTER DELETE ON videos BEGIN
+    INSERT INTO videos_fts (videos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+END;
+
+CREATE TRIGGER videos_fts_update AFTER UPDATE OF title, description ON videos BEGIN
+    INSERT INTO videos_fts (videos_fts, rowid, title, description) VALUES ('delete', old.id, old.title, old.description);
+    INSERT INTO videos_fts (rowid, title, description) VALUES (new.id, new.title, new.description);
+END;
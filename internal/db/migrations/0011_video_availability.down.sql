@@ -0,0 +1,3 @@
+DROP INDEX idx_videos_unavailable;
+ALTER TABLE videos DROP COLUMN availability_checked_at;
+ALTER TABLE videos DROP COLUMN availability;
@@ -0,0 +1,8 @@
+-- Whether a stored video can still be watched: available, missing (deleted
+-- or private), removed (taken down by YouTube) or blocked (in the configured
+-- region), as of availability_checked_at. Columns only show available ones.
+ALTER TABLE videos ADD COLUMN availability TEXT NOT NULL DEFAULT 'available'
+    CHECK (availability IN ('available', 'missing', 'removed', 'blocked'));
+ALTER TABLE videos ADD COLUMN availability_checked_at DATETIME;
+
+CREATE INDEX idx_videos_unavailable ON videos(subscription_id) WHERE availability != 'available';
@@ -0,0 +1,7 @@
+ALTER TABLE subscriptions ADD COLUMN sort_mode TEXT NOT NULL DEFAULT 'newest'
+    CHECK (sort_mode IN ('newest', 'popular'));
+UPDATE subscriptions SET sort_mode = CASE WHEN sort_order = 'popular' THEN 'popular' ELSE 'newest' END;
+ALTER TABLE subscriptions DROP COLUMN sort_order;
+ALTER TABLE subscriptions RENAME COLUMN sort_mode TO sort_order;
+
+ALTER TABLE videos DROP COLUMN playlist_position;
@@ -0,0 +1,12 @@
+-- Zero-based position of a video in its playlist subscription, as last read
+-- from the playlist. NULL for channel uploads and for videos that are no
+-- longer in the playlist.
+ALTER TABLE videos ADD COLUMN playlist_position INTEGER;
+
+-- SQLite can't change a CHECK constraint, so sort_order is recreated to also
+-- allow oldest first and playlist order.
+ALTER TABLE subscriptions ADD COLUMN sort_mode TEXT NOT NULL DEFAULT 'newest'
+    CHECK (sort_mode IN ('newest', 'oldest', 'popular', 'playlist'));
+UPDATE subscriptions SET sort_mode = sort_order;
+ALTER TABLE subscriptions DROP COLUMN sort_order;
+ALTER TABLE subscriptions RENAME COLUMN sort_mode TO sort_order;
@@ -0,0 +1,6 @@
+-- Videos watched according to an imported YouTube watch history. Stored videos
+-- are marked watched on import, and videos stored later as they arrive.
+CREATE TABLE watch_history (
+    youtube_id TEXT PRIMARY KEY,
+    watched_at DATETIME
+);
@@ -0,0 +1,3 @@
+DROP TABLE sync_rejections;
+DROP TABLE sync_changes;
+DROP TABLE account_subscriptions;
@@ -0,0 +1,30 @@
+-- The channels the signed-in YouTube account subscribed to at the last
+-- sync. A channel that drops out of it was unsubscribed from on YouTube.
+CREATE TABLE account_subscriptions (
+    youtube_id TEXT PRIMARY KEY
+);
+
+-- Differences the last sync found between the account and the stored
+-- subscriptions, waiting to be accepted or rejected: a channel to add or
+-- remove, or a subscription whose name or avatar changed. name and
+-- thumbnail_url are the account's.
+CREATE TABLE sync_changes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL CHECK (kind IN ('add', 'remove', 'rename', 'avatar')),
+    youtube_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    thumbnail_url TEXT,
+    found_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (kind, youtube_id)
+);
+
+-- Changes rejected in review, which later syncs don't propose again. value
+-- is the rejected name or avatar of a rename or avatar change, so that a
+-- different one is proposed anew, and empty otherwise.
+CREATE TABLE sync_rejections (
+    kind TEXT NOT NULL,
+    youtube_id TEXT NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    rejected_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (kind, youtube_id, value)
+);
@@ -0,0 +1,22 @@
+ALTER TABLE videos DROP COLUMN muted;
+
+CREATE VIEW muted_videos AS
+SELECT v.id AS video_id
+FROM videos v
+WHERE EXISTS (
+    SELECT 1 FROM mute_rules r
+    WHERE r.action = 'exclude'
+      AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+      AND mute_match(r.pattern, r.is_regex, v.title)
+) OR (
+    EXISTS (
+        SELECT 1 FROM mute_rules r
+        WHERE r.action = 'include'
+          AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+    ) AND NOT EXISTS (
+        SELECT 1 FROM mute_rules r
+        WHERE r.action = 'include'
+          AND (r.subscription_id IS NULL OR r.subscription_id = v.subscription_id)
+          AND mute_match(r.pattern, r.is_regex, v.title)
+    )
+);
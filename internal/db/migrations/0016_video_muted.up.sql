@@ -0,0 +1,5 @@
+-- Whether the mute rules hide a video. The server sets it as videos are
+-- stored and rules change, in place of the muted_videos view, whose
+-- mute_match function only existed inside the server.
+DROP VIEW muted_videos;
+ALTER TABLE videos ADD COLUMN muted INTEGER NOT NULL DEFAULT 0;
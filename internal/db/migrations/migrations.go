@@ -0,0 +1,294 @@
+// Package migrations versions the SQLite schema.
+//
+// Each change is a pair of numbered files, NNNN_name.up.sql and
+// NNNN_name.down.sql, embedded into the binary. Applied versions are recorded
+// in the schema_migrations table, and every migration runs in a transaction
+// together with its bookkeeping. sqlc reads the same directory, skipping the
+// down files, so the generated queries always match the migrated schema.
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration together with the time it was applied, if it was.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load returns the embedded migrations ordered by version.
+func Load() ([]Migration, error) {
+	return load(files)
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrations: %s: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrations: version %d is used by both %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrations: %04d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return int(a.Version - b.Version)
+	})
+	return migrations, nil
+}
+
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Up applies every pending migration in order and returns the ones applied.
+// It stops at the first failure, leaving the database at the last version
+// that succeeded.
+func Up(ctx context.Context, db *sql.DB) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := prepare(ctx, db); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range all {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := run(ctx, db, m, m.Up, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name)
+			return err
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first, and returns
+// the ones reverted.
+func Down(ctx context.Context, db *sql.DB, steps int) ([]Migration, error) {
+	statuses, err := List(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		m := statuses[i]
+		if !m.Applied {
+			continue
+		}
+		if m.Down == "" {
+			return done, fmt.Errorf("migrations: %04d_%s cannot be reverted", m.Version, m.Name)
+		}
+		if err := run(ctx, db, m.Migration, m.Down, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version)
+			return err
+		}); err != nil {
+			return done, err
+		}
+		done = append(done, m.Migration)
+	}
+	return done, nil
+}
+
+// List returns every known migration and whether it has been applied.
+func List(ctx context.Context, db *sql.DB) ([]Status, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	// Listing must not create schema_migrations: prepare relies on its
+	// absence to recognize databases that predate versioned migrations.
+	tracked, err := tableExists(ctx, db, "schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	applied := map[int64]time.Time{}
+	if tracked {
+		if applied, err = appliedVersions(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, len(all))
+	for i, m := range all {
+		at, ok := applied[m.Version]
+		statuses[i] = Status{Migration: m, Applied: ok, AppliedAt: at}
+	}
+	return statuses, nil
+}
+
+func run(ctx context.Context, db *sql.DB, m Migration, script string, record func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return fmt.Errorf("migrations: %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if err := record(tx); err != nil {
+		return fmt.Errorf("migrations: record %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return tx.Commit()
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB) (map[int64]time.Time, error) {
+	rows, err := db.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("migrations: read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// legacyColumns were added by the ALTER TABLE statements that ran on every
+// startup before migrations were versioned. A database from that era may
+// lack any of them, depending on the release that created it.
+var legacyColumns = []struct{ table, column, definition string }{
+	{"subscriptions", "position", "INTEGER DEFAULT 0"},
+	{"subscriptions", "active", "INTEGER DEFAULT 0"},
+	{"subscriptions", "page_token", "TEXT"},
+	{"subscriptions", "hide_shorts", "INTEGER DEFAULT 0"},
+	{"videos", "is_short", "INTEGER DEFAULT 0"},
+	{"subscriptions", "refresh_interval", "INTEGER"},
+	{"subscriptions", "uploads_playlist_id", "TEXT"},
+	{"subscriptions", "custom_url", "TEXT"},
+	{"subscriptions", "subscriber_count", "INTEGER"},
+}
+
+// prepare creates schema_migrations. When it doesn't exist yet but the app's
+// tables do, the database predates versioned migrations and is first brought
+// up to the shape 0001_initial expects.
+func prepare(ctx context.Context, db *sql.DB) error {
+	tracked, err := tableExists(ctx, db, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		legacy, err := tableExists(ctx, db, "subscriptions")
+		if err != nil {
+			return err
+		}
+		if legacy {
+			if err := upgradeLegacy(ctx, db); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := db.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("migrations: create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func upgradeLegacy(ctx context.Context, db *sql.DB) error {
+	for _, c := range legacyColumns {
+		exists, err := tableExists(ctx, db, c.table)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		var n int
+		if err := db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.column,
+		).Scan(&n); err != nil {
+			return fmt.Errorf("migrations: inspect %s: %w", c.table, err)
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)); err != nil {
+			return fmt.Errorf("migrations: add %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+func tableExists(ctx context.Context, db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("migrations: inspect schema: %w", err)
+	}
+	return n > 0, nil
+}
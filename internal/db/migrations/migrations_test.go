@@ -0,0 +1,197 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUpDownRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	all, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range all {
+		if m.Down == "" {
+			t.Errorf("%04d_%s has no down file", m.Version, m.Name)
+		}
+		if i > 0 && m.Version <= all[i-1].Version {
+			t.Errorf("migrations out of order at %04d", m.Version)
+		}
+	}
+
+	applied, err := Up(ctx, db)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != len(all) {
+		t.Fatalf("Up() applied %d migrations, want %d", len(applied), len(all))
+	}
+	if again, err := Up(ctx, db); err != nil || len(again) != 0 {
+		t.Fatalf("second Up() = %d migrations, %v; want none", len(again), err)
+	}
+
+	reverted, err := Down(ctx, db, len(all))
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if len(reverted) != len(all) {
+		t.Fatalf("Down() reverted %d migrations, want %d", len(reverted), len(all))
+	}
+	statuses, err := List(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Errorf("%04d_%s still applied after Down()", s.Version, s.Name)
+		}
+	}
+
+	if _, err := Up(ctx, db); err != nil {
+		t.Fatalf("Up() after Down() error = %v", err)
+	}
+}
+
+func TestUpAdoptsLegacyDatabase(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// The schema as created by the first release, before any ALTER TABLE.
+	if _, err := db.Exec(`
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    youtube_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK(type IN ('channel', 'playlist')),
+    thumbnail_url TEXT,
+    last_checked DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE videos (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    youtube_id TEXT NOT NULL UNIQUE,
+    title TEXT NOT NULL,
+    thumbnail_url TEXT,
+    duration TEXT,
+    published_at DATETIME,
+    watched INTEGER DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO subscriptions (name, youtube_id, type) VALUES ('Old', 'UCold', 'channel');`); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := List(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses[0].Applied {
+		t.Fatal("legacy database reported as migrated")
+	}
+
+	if _, err := Up(ctx, db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	var name string
+	var hideShorts int64
+	if err := db.QueryRow("SELECT name, hide_shorts FROM subscriptions").Scan(&name, &hideShorts); err != nil {
+		t.Fatalf("legacy row not readable after Up(): %v", err)
+	}
+	if name != "Old" || hideShorts != 0 {
+		t.Errorf("legacy row = %q, hide_shorts %d", name, hideShorts)
+	}
+	if _, err := db.Exec("UPDATE videos SET is_short = 1"); err != nil {
+		t.Errorf("videos.is_short missing: %v", err)
+	}
+}
+
+func TestUpMovesActiveSubscriptionsToDeck(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+
+	// Active columns were a flag on subscriptions until decks replaced them.
+	if _, err := db.Exec(`
+CREATE TABLE subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    youtube_id TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL CHECK(type IN ('channel', 'playlist')),
+    thumbnail_url TEXT,
+    last_checked DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    position INTEGER DEFAULT 0,
+    active INTEGER DEFAULT 0
+);
+INSERT INTO subscriptions (name, youtube_id, type, position, active) VALUES
+    ('Second', 'UCb', 'channel', 2, 1),
+    ('Hidden', 'UCc', 'channel', 3, 0),
+    ('First', 'UCa', 'channel', 1, 1);`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Up(ctx, db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+
+	rows, err := db.Query(`
+SELECT s.name FROM deck_columns dc
+JOIN subscriptions s ON s.id = dc.subscription_id
+WHERE dc.deck_id = (SELECT CAST(value AS INTEGER) FROM settings WHERE key = 'current_deck_id')
+ORDER BY dc.position`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if len(names) != 2 || names[0] != "First" || names[1] != "Second" {
+		t.Errorf("current deck columns = %v, want [First Second]", names)
+	}
+}
+
+// The schema must stay readable by any SQLite client, so it can't call
+// functions only the server registers.
+func TestSchemaNeedsNoServerFunctions(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	if _, err := Up(ctx, db); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	var names string
+	if err := db.QueryRowContext(ctx, "SELECT COALESCE(group_concat(name), '') FROM sqlite_schema WHERE sql LIKE '%mute_match%'").Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "" {
+		t.Errorf("schema objects calling mute_match: %s", names)
+	}
+	var muted int64
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM videos WHERE muted = 1").Scan(&muted); err != nil {
+		t.Errorf("query muted videos: %v", err)
+	}
+}
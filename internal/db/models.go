@@ -8,29 +8,157 @@ import (
 	"database/sql"
 )
 
+type AccountSubscription struct {
+	YoutubeID string `json:"youtube_id"`
+}
+
+type Deck struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Position  int64        `json:"position"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type DeckColumn struct {
+	ID              int64         `json:"id"`
+	DeckID          int64         `json:"deck_id"`
+	SubscriptionID  sql.NullInt64 `json:"subscription_id"`
+	VirtualColumnID sql.NullInt64 `json:"virtual_column_id"`
+	Position        int64         `json:"position"`
+}
+
+type Group struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Position  int64        `json:"position"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type Job struct {
+	ID         int64        `json:"id"`
+	Kind       string       `json:"kind"`
+	Status     string       `json:"status"`
+	Progress   int64        `json:"progress"`
+	Total      int64        `json:"total"`
+	Result     string       `json:"result"`
+	Error      string       `json:"error"`
+	Dismissed  int64        `json:"dismissed"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+	FinishedAt sql.NullTime `json:"finished_at"`
+}
+
+type MuteRule struct {
+	ID             int64         `json:"id"`
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+	Pattern        string        `json:"pattern"`
+	IsRegex        int64         `json:"is_regex"`
+	Action         string        `json:"action"`
+	CreatedAt      sql.NullTime  `json:"created_at"`
+}
+
+type Queue struct {
+	VideoID  int64        `json:"video_id"`
+	Position int64        `json:"position"`
+	AddedAt  sql.NullTime `json:"added_at"`
+}
+
+type QuotaUsage struct {
+	Day   string `json:"day"`
+	Call  string `json:"call"`
+	Units int64  `json:"units"`
+	Calls int64  `json:"calls"`
+}
+
+type Setting struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
 type Subscription struct {
+	ID                 int64          `json:"id"`
+	Name               string         `json:"name"`
+	YoutubeID          string         `json:"youtube_id"`
+	Type               string         `json:"type"`
+	ThumbnailUrl       sql.NullString `json:"thumbnail_url"`
+	LastChecked        sql.NullTime   `json:"last_checked"`
+	CreatedAt          sql.NullTime   `json:"created_at"`
+	Position           sql.NullInt64  `json:"position"`
+	PageToken          sql.NullString `json:"page_token"`
+	HideShorts         sql.NullInt64  `json:"hide_shorts"`
+	RefreshInterval    sql.NullInt64  `json:"refresh_interval"`
+	UploadsPlaylistID  sql.NullString `json:"uploads_playlist_id"`
+	CustomUrl          sql.NullString `json:"custom_url"`
+	SubscriberCount    sql.NullInt64  `json:"subscriber_count"`
+	MinDurationSeconds sql.NullInt64  `json:"min_duration_seconds"`
+	MaxDurationSeconds sql.NullInt64  `json:"max_duration_seconds"`
+	UpcomingMode       string         `json:"upcoming_mode"`
+	SortOrder          string         `json:"sort_order"`
+}
+
+type SubscriptionGroup struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	GroupID        int64 `json:"group_id"`
+}
+
+type SyncChange struct {
 	ID           int64          `json:"id"`
-	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
 	YoutubeID    string         `json:"youtube_id"`
-	Type         string         `json:"type"`
+	Name         string         `json:"name"`
 	ThumbnailUrl sql.NullString `json:"thumbnail_url"`
-	LastChecked  sql.NullTime   `json:"last_checked"`
-	CreatedAt    sql.NullTime   `json:"created_at"`
-	Position     sql.NullInt64  `json:"position"`
-	Active       sql.NullInt64  `json:"active"`
-	PageToken    sql.NullString `json:"page_token"`
-	HideShorts   sql.NullInt64  `json:"hide_shorts"`
+	FoundAt      sql.NullTime   `json:"found_at"`
+}
+
+type SyncRejection struct {
+	Kind       string       `json:"kind"`
+	YoutubeID  string       `json:"youtube_id"`
+	Value      string       `json:"value"`
+	RejectedAt sql.NullTime `json:"rejected_at"`
 }
 
 type Video struct {
-	ID             int64          `json:"id"`
-	SubscriptionID int64          `json:"subscription_id"`
-	YoutubeID      string         `json:"youtube_id"`
-	Title          string         `json:"title"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	Duration       sql.NullString `json:"duration"`
-	PublishedAt    sql.NullTime   `json:"published_at"`
-	Watched        sql.NullInt64  `json:"watched"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	IsShort        sql.NullInt64  `json:"is_short"`
+	ID                    int64          `json:"id"`
+	SubscriptionID        int64          `json:"subscription_id"`
+	YoutubeID             string         `json:"youtube_id"`
+	Title                 string         `json:"title"`
+	ThumbnailUrl          sql.NullString `json:"thumbnail_url"`
+	Duration              sql.NullString `json:"duration"`
+	PublishedAt           sql.NullTime   `json:"published_at"`
+	Watched               sql.NullInt64  `json:"watched"`
+	CreatedAt             sql.NullTime   `json:"created_at"`
+	IsShort               sql.NullInt64  `json:"is_short"`
+	DurationSeconds       sql.NullInt64  `json:"duration_seconds"`
+	BroadcastState        string         `json:"broadcast_state"`
+	ScheduledStartAt      sql.NullTime   `json:"scheduled_start_at"`
+	ShortSource           sql.NullString `json:"short_source"`
+	ShortCheckedAt        sql.NullTime   `json:"short_checked_at"`
+	ShortAttempts         int64          `json:"short_attempts"`
+	Description           sql.NullString `json:"description"`
+	ViewCount             sql.NullInt64  `json:"view_count"`
+	LikeCount             sql.NullInt64  `json:"like_count"`
+	CommentCount          sql.NullInt64  `json:"comment_count"`
+	StatsUpdatedAt        sql.NullTime   `json:"stats_updated_at"`
+	Availability          string         `json:"availability"`
+	AvailabilityCheckedAt sql.NullTime   `json:"availability_checked_at"`
+	PlaylistPosition      sql.NullInt64  `json:"playlist_position"`
+	Muted                 int64          `json:"muted"`
+}
+
+type VideosFt struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type VirtualColumn struct {
+	ID        int64         `json:"id"`
+	Kind      string        `json:"kind"`
+	Name      string        `json:"name"`
+	Position  int64         `json:"position"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+	GroupID   sql.NullInt64 `json:"group_id"`
+}
+
+type WatchHistory struct {
+	YoutubeID string       `json:"youtube_id"`
+	WatchedAt sql.NullTime `json:"watched_at"`
 }
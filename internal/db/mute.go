@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// muteRegexps caches the compiled patterns of regex mute rules by rule ID.
+// Patterns that don't compile are cached as nil and never match. Rules can't
+// be edited, only deleted, which ForgetMuteRule reports.
+var muteRegexps sync.Map
+
+// CompileMutePattern compiles the pattern of a regex mute rule. Matching
+// ignores case.
+func CompileMutePattern(pattern string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// ForgetMuteRule drops the compiled pattern of a deleted rule.
+func ForgetMuteRule(id int64) {
+	muteRegexps.Delete(id)
+}
+
+// Matches reports whether the rule matches title. Keywords match anywhere
+// in the title; both kinds ignore case.
+func (r MuteRule) Matches(title string) bool {
+	if r.Pattern == "" {
+		return false
+	}
+	if r.IsRegex == 0 {
+		return strings.Contains(strings.ToLower(title), strings.ToLower(r.Pattern))
+	}
+	cached, ok := muteRegexps.Load(r.ID)
+	if !ok {
+		re, _ := CompileMutePattern(r.Pattern)
+		cached, _ = muteRegexps.LoadOrStore(r.ID, re)
+	}
+	re := cached.(*regexp.Regexp)
+	return re != nil && re.MatchString(title)
+}
+
+// MuteRules are the rules that apply to a subscription.
+type MuteRules []MuteRule
+
+// Mute reports whether the rules hide a video titled title: an exclude rule
+// matches it, or include rules apply and none of them matches it.
+func (rules MuteRules) Mute(title string) bool {
+	var include, included bool
+	for _, r := range rules {
+		switch r.Action {
+		case "exclude":
+			if r.Matches(title) {
+				return true
+			}
+		case "include":
+			include = true
+			included = included || r.Matches(title)
+		}
+	}
+	return include && !included
+}
+
+// UpdateMutedVideos sets videos.muted for the videos of a subscription, or
+// for every video when subscriptionID is NULL, after the rules that apply to
+// them changed. Only videos whose verdict changed are written.
+func (q *Queries) UpdateMutedVideos(ctx context.Context, subscriptionID sql.NullInt64) error {
+	all, err := q.ListAllMuteRules(ctx)
+	if err != nil {
+		return err
+	}
+	var global MuteRules
+	own := make(map[int64]MuteRules)
+	for _, r := range all {
+		if r.SubscriptionID.Valid {
+			own[r.SubscriptionID.Int64] = append(own[r.SubscriptionID.Int64], r)
+		} else {
+			global = append(global, r)
+		}
+	}
+	rules := make(map[int64]MuteRules)
+
+	videos, err := q.ListVideoTitles(ctx, subscriptionID)
+	if err != nil {
+		return err
+	}
+	for _, v := range videos {
+		applying, ok := rules[v.SubscriptionID]
+		if !ok {
+			applying = append(slices.Clip(global), own[v.SubscriptionID]...)
+			rules[v.SubscriptionID] = applying
+		}
+		var muted int64
+		if applying.Mute(v.Title) {
+			muted = 1
+		}
+		if muted == v.Muted {
+			continue
+		}
+		if err := q.UpdateVideoMuted(ctx, UpdateVideoMutedParams{Muted: muted, ID: v.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package db
+
+import "testing"
+
+func TestMuteRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		isRegex int64
+		title   string
+		want    bool
+	}{
+		{"live q&a", 0, "Weekly LIVE Q&A #12", true},
+		{"#ad", 0, "Unboxing #AD", true},
+		{"#ad", 0, "Unboxing", false},
+		{`^part \d+`, 1, "Part 3: the finale", true},
+		{`^part \d+`, 1, "The finale, part 3", false},
+		{"(", 1, "(", false},
+	}
+	for i, tt := range tests {
+		rule := MuteRule{ID: int64(i + 1), Pattern: tt.pattern, IsRegex: tt.isRegex}
+		if got := rule.Matches(tt.title); got != tt.want {
+			t.Errorf("rule %q (regex %d) matches %q = %v, want %v", tt.pattern, tt.isRegex, tt.title, got, tt.want)
+		}
+		ForgetMuteRule(rule.ID)
+	}
+}
+
+func TestForgetMuteRule(t *testing.T) {
+	rule := MuteRule{ID: 100, Pattern: "^old", IsRegex: 1}
+	if !rule.Matches("old news") {
+		t.Fatal("rule does not match")
+	}
+	ForgetMuteRule(rule.ID)
+	if _, ok := muteRegexps.Load(rule.ID); ok {
+		t.Fatal("forgotten rule still cached")
+	}
+	// A later rule with the same ID compiles its own pattern.
+	rule = MuteRule{ID: 100, Pattern: "^new", IsRegex: 1}
+	if rule.Matches("old news") || !rule.Matches("new old") {
+		t.Error("rule matched with a forgotten pattern")
+	}
+}
+
+func TestMuteRulesMute(t *testing.T) {
+	exclude := MuteRule{ID: 201, Pattern: "#ad", Action: "exclude"}
+	include := MuteRule{ID: 202, Pattern: "talk", Action: "include"}
+	tests := []struct {
+		rules MuteRules
+		title string
+		want  bool
+	}{
+		{nil, "Anything", false},
+		{MuteRules{exclude}, "Unboxing #ad", true},
+		{MuteRules{exclude}, "Unboxing", false},
+		{MuteRules{include}, "A talk", false},
+		{MuteRules{include}, "A vlog", true},
+		{MuteRules{exclude, include}, "A talk #ad", true},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.Mute(tt.title); got != tt.want {
+			t.Errorf("%d rules mute %q = %v, want %v", len(tt.rules), tt.title, got, tt.want)
+		}
+	}
+}
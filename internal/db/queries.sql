@@ -5,8 +5,11 @@ SELECT * FROM subscriptions ORDER BY name;
 SELECT * FROM subscriptions WHERE id = ?;
 
 -- name: CreateSubscription :one
-INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, position, active)
-VALUES (?, ?, ?, ?, COALESCE((SELECT MAX(position) FROM subscriptions), 0) + 1, ?)
+-- sort_order defaults to newest first.
+INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, sort_order, position)
+VALUES (sqlc.arg(name), sqlc.arg(youtube_id), sqlc.arg(type), sqlc.arg(thumbnail_url),
+        COALESCE(CAST(sqlc.narg(sort_order) AS TEXT), 'newest'),
+        COALESCE((SELECT MAX(position) FROM subscriptions), 0) + 1)
 RETURNING *;
 
 -- name: DeleteSubscription :exec
@@ -16,7 +19,16 @@ DELETE FROM subscriptions WHERE id = ?;
 UPDATE subscriptions SET last_checked = CURRENT_TIMESTAMP WHERE id = ?;
 
 -- name: ListVideos :many
-SELECT * FROM videos WHERE subscription_id = ? ORDER BY published_at DESC;
+-- sort is one of the values of subscriptions.sort_order; playlist order puts
+-- videos no longer in the playlist last.
+WITH args AS (SELECT CAST(sqlc.arg(sort) AS TEXT) AS sort)
+SELECT videos.* FROM videos, args
+WHERE subscription_id = sqlc.arg(subscription_id)
+ORDER BY CASE WHEN args.sort = 'popular' THEN view_count END DESC,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position IS NULL END,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position END,
+         CASE WHEN args.sort = 'oldest' THEN published_at END,
+         published_at DESC;
 
 -- name: ListUnwatchedVideos :many
 SELECT * FROM videos WHERE subscription_id = ? AND watched = 0 ORDER BY published_at DESC;
@@ -25,8 +37,10 @@ SELECT * FROM videos WHERE subscription_id = ? AND watched = 0 ORDER BY publishe
 SELECT * FROM videos WHERE id = ?;
 
 -- name: CreateVideo :one
-INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, published_at, is_short)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, duration_seconds, published_at, is_short,
+                    short_source, short_checked_at, short_attempts, broadcast_state, scheduled_start_at,
+                    description, view_count, like_count, comment_count, stats_updated_at, playlist_position, muted)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(youtube_id) DO NOTHING
 RETURNING *;
 
@@ -41,35 +55,51 @@ UPDATE videos SET watched = 1 WHERE id = ?;
 UPDATE videos SET watched = 0 WHERE id = ?;
 
 -- name: CountUnwatchedBySubscription :one
-SELECT COUNT(*) FROM videos WHERE subscription_id = ? AND watched = 0;
+SELECT COUNT(*) FROM videos WHERE subscription_id = ? AND watched = 0 AND availability = 'available';
 
 -- name: VideoExistsByYoutubeID :one
 SELECT EXISTS(SELECT 1 FROM videos WHERE youtube_id = ?);
 
 -- name: ListSubscriptionsWithUnwatchedCount :many
-SELECT s.*, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
+SELECT s.*, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count
 FROM subscriptions s
 LEFT JOIN videos v ON v.subscription_id = s.id
 GROUP BY s.id
 ORDER BY s.name;
 
 -- name: ListAllSubscriptionsOrdered :many
-SELECT s.*, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = sqlc.arg(deck_id) AND dc.subscription_id = s.id) AS in_deck
 FROM subscriptions s
 LEFT JOIN videos v ON v.subscription_id = s.id
 GROUP BY s.id
 ORDER BY s.position, s.name;
 
--- name: ListActiveSubscriptions :many
-SELECT s.*, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
-FROM subscriptions s
+-- name: ListDeckSubscriptions :many
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 1 THEN 1 END) as muted_count,
+       COUNT(CASE WHEN v.watched = 0 AND v.availability != 'available' THEN 1 END) as gone_count
+FROM deck_columns dc
+JOIN subscriptions s ON s.id = dc.subscription_id
 LEFT JOIN videos v ON v.subscription_id = s.id
-WHERE s.active = 1
-GROUP BY s.id
-ORDER BY s.position;
+WHERE dc.deck_id = ?
+GROUP BY dc.id
+ORDER BY dc.position, dc.id;
+
+-- name: AddDeckSubscription :exec
+INSERT INTO deck_columns (deck_id, subscription_id, position)
+VALUES (sqlc.arg(deck_id), sqlc.arg(subscription_id),
+        (SELECT COALESCE(MAX(position), -1) + 1 FROM deck_columns WHERE deck_id = sqlc.arg(deck_id)))
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveDeckSubscription :exec
+DELETE FROM deck_columns WHERE deck_id = ? AND subscription_id = ?;
+
+-- name: IsSubscriptionInDeck :one
+SELECT EXISTS(SELECT 1 FROM deck_columns WHERE deck_id = ? AND subscription_id = ?);
 
--- name: UpdateSubscriptionActive :exec
-UPDATE subscriptions SET active = ? WHERE id = ?;
+-- name: UpdateDeckSubscriptionPosition :exec
+UPDATE deck_columns SET position = ? WHERE deck_id = ? AND subscription_id = ?;
 
 -- name: UpdateSubscriptionPosition :exec
 UPDATE subscriptions SET position = ? WHERE id = ?;
@@ -78,24 +108,47 @@ UPDATE subscriptions SET position = ? WHERE id = ?;
 SELECT CAST(COALESCE(MAX(position), 0) AS INTEGER) as max_position FROM subscriptions;
 
 -- name: FilterSubscriptions :many
-SELECT s.*, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = sqlc.arg(deck_id) AND dc.subscription_id = s.id) AS in_deck
 FROM subscriptions s
 LEFT JOIN videos v ON v.subscription_id = s.id
-WHERE s.name LIKE '%' || ? || '%'
+WHERE s.name LIKE '%' || sqlc.arg(query) || '%'
 GROUP BY s.id
 ORDER BY s.position, s.name
 LIMIT 50;
 
 -- name: ListSubscriptionsPaginated :many
-SELECT s.*, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = sqlc.arg(deck_id) AND dc.subscription_id = s.id) AS in_deck
 FROM subscriptions s
 LEFT JOIN videos v ON v.subscription_id = s.id
 GROUP BY s.id
 ORDER BY s.position, s.name
-LIMIT ? OFFSET ?;
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
 
--- name: CountActiveSubscriptions :one
-SELECT COUNT(*) FROM subscriptions WHERE active = 1;
+-- name: ListGroupSubscriptionsPaginated :many
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = sqlc.arg(deck_id) AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+JOIN subscription_groups sg ON sg.subscription_id = s.id
+LEFT JOIN videos v ON v.subscription_id = s.id
+WHERE sg.group_id = sqlc.arg(group_id)
+GROUP BY s.id
+ORDER BY s.position, s.name
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: ListUngroupedSubscriptionsPaginated :many
+SELECT sqlc.embed(s), COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = sqlc.arg(deck_id) AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+LEFT JOIN videos v ON v.subscription_id = s.id
+WHERE NOT EXISTS(SELECT 1 FROM subscription_groups sg WHERE sg.subscription_id = s.id)
+GROUP BY s.id
+ORDER BY s.position, s.name
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
+
+-- name: CountDeckSubscriptions :one
+SELECT COUNT(*) FROM deck_columns WHERE deck_id = ? AND subscription_id IS NOT NULL;
 
 -- name: ListUnwatchedVideosPaginated :many
 SELECT * FROM videos
@@ -112,19 +165,527 @@ SELECT COUNT(*) FROM videos WHERE subscription_id = ?;
 -- name: UpdateSubscriptionHideShorts :exec
 UPDATE subscriptions SET hide_shorts = ? WHERE id = ?;
 
+-- name: UpdateSubscriptionDurationFilter :exec
+UPDATE subscriptions SET min_duration_seconds = ?, max_duration_seconds = ? WHERE id = ?;
+
 -- name: ListUnwatchedVideosPaginatedFiltered :many
-SELECT * FROM videos
-WHERE subscription_id = ?
+-- Videos whose length or Shorts verdict is not known yet pass the filters. muted
+-- selects either the videos the mute rules hide or the ones they let through;
+-- sort is one of the values of subscriptions.sort_order; playlist order puts
+-- videos no longer in the playlist last.
+WITH args AS (SELECT CAST(sqlc.arg(sort) AS TEXT) AS sort)
+SELECT videos.* FROM videos, args
+WHERE subscription_id = sqlc.arg(subscription_id)
   AND watched = 0
-  AND (? = 0 OR is_short = 0)
-ORDER BY published_at DESC
-LIMIT ? OFFSET ?;
+  AND availability = 'available'
+  AND (CAST(sqlc.arg(hide_shorts) AS INTEGER) = 0 OR COALESCE(is_short, 0) = 0)
+  AND (CAST(sqlc.arg(hide_upcoming) AS INTEGER) = 0 OR broadcast_state != 'upcoming')
+  AND (CAST(sqlc.narg(min_seconds) AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds >= CAST(sqlc.narg(min_seconds) AS INTEGER))
+  AND (CAST(sqlc.narg(max_seconds) AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds <= CAST(sqlc.narg(max_seconds) AS INTEGER))
+  AND videos.muted = CAST(sqlc.arg(muted) AS INTEGER)
+ORDER BY CASE WHEN args.sort = 'popular' THEN view_count END DESC,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position IS NULL END,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position END,
+         CASE WHEN args.sort = 'oldest' THEN published_at END,
+         published_at DESC
+LIMIT sqlc.arg(limit) OFFSET sqlc.arg(offset);
 
 -- name: CountUnwatchedBySubscriptionFiltered :one
 SELECT COUNT(*) FROM videos
+WHERE subscription_id = sqlc.arg(subscription_id)
+  AND watched = 0
+  AND availability = 'available'
+  AND (CAST(sqlc.arg(hide_shorts) AS INTEGER) = 0 OR COALESCE(is_short, 0) = 0)
+  AND (CAST(sqlc.arg(hide_upcoming) AS INTEGER) = 0 OR broadcast_state != 'upcoming')
+  AND (CAST(sqlc.narg(min_seconds) AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds >= CAST(sqlc.narg(min_seconds) AS INTEGER))
+  AND (CAST(sqlc.narg(max_seconds) AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds <= CAST(sqlc.narg(max_seconds) AS INTEGER))
+  AND videos.muted = CAST(sqlc.arg(muted) AS INTEGER);
+
+-- name: ListVideosMissingDurationSeconds :many
+SELECT id, duration FROM videos
+WHERE id > sqlc.arg(after_id)
+  AND duration IS NOT NULL AND duration != ''
+  AND duration_seconds IS NULL
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateVideoDurationSeconds :exec
+UPDATE videos SET duration_seconds = ? WHERE id = ?;
+
+-- name: UpdateSubscriptionUpcomingMode :exec
+UPDATE subscriptions SET upcoming_mode = ? WHERE id = ?;
+
+-- name: ListUpcomingVideos :many
+-- The upcoming broadcasts of a column that shows them separately, soonest
+-- first.
+SELECT * FROM videos
 WHERE subscription_id = ?
   AND watched = 0
-  AND (? = 0 OR is_short = 0);
+  AND availability = 'available'
+  AND broadcast_state = 'upcoming'
+  AND videos.muted = 0
+ORDER BY scheduled_start_at, id;
+
+-- name: ListPendingBroadcasts :many
+-- Videos whose broadcast may have changed state since they were stored.
+SELECT * FROM videos
+WHERE subscription_id = ? AND broadcast_state IN ('upcoming', 'live')
+ORDER BY id;
+
+-- name: UpdateVideoBroadcast :one
+UPDATE videos
+SET broadcast_state = ?, scheduled_start_at = ?, duration = ?, duration_seconds = ?, published_at = ?
+WHERE id = ?
+RETURNING *;
+
+-- name: ListAllMuteRules :many
+SELECT * FROM mute_rules ORDER BY subscription_id IS NOT NULL, id;
+
+-- name: ListVideoTitles :many
+-- The videos of a subscription, or every video when subscription_id is
+-- NULL, for the mute rules to go through.
+SELECT id, subscription_id, title, muted FROM videos
+WHERE sqlc.narg(subscription_id) IS NULL OR subscription_id = sqlc.narg(subscription_id)
+ORDER BY id;
+
+-- name: UpdateVideoMuted :exec
+UPDATE videos SET muted = ? WHERE id = ?;
+
+-- name: ListMuteRules :many
+-- The rules that apply to a subscription, global ones first.
+SELECT * FROM mute_rules
+WHERE subscription_id IS NULL OR subscription_id = ?
+ORDER BY subscription_id IS NOT NULL, id;
+
+-- name: CreateMuteRule :one
+INSERT INTO mute_rules (subscription_id, pattern, is_regex, action)
+VALUES (?, ?, ?, ?)
+RETURNING *;
+
+-- name: DeleteMuteRule :one
+DELETE FROM mute_rules WHERE id = ?
+RETURNING *;
+
+-- name: UpdateSubscriptionSortOrder :exec
+UPDATE subscriptions SET sort_order = ? WHERE id = ?;
+
+-- name: ListVideosForStatsRefresh :many
+-- Videos published since published_since whose statistics are older than
+-- stale_minutes, least recently updated first.
+SELECT * FROM videos
+WHERE published_at >= sqlc.arg(published_since)
+  AND broadcast_state != 'upcoming'
+  AND availability = 'available'
+  AND (stats_updated_at IS NULL
+       OR stats_updated_at <= datetime('now', printf('-%d minutes', CAST(sqlc.arg(stale_minutes) AS INTEGER))))
+ORDER BY stats_updated_at IS NOT NULL, stats_updated_at, id
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateVideoStatistics :one
+UPDATE videos
+SET description = ?, view_count = ?, like_count = ?, comment_count = ?, stats_updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ListPlaylistSubscriptions :many
+SELECT * FROM subscriptions WHERE type = 'playlist' ORDER BY id;
+
+-- name: ClearPlaylistPositions :exec
+UPDATE videos SET playlist_position = NULL WHERE subscription_id = ?;
+
+-- name: UpdateVideoPlaylistPosition :exec
+UPDATE videos SET playlist_position = ?
+WHERE subscription_id = ? AND youtube_id = ?;
+
+-- name: ListVideosForAvailabilityCheck :many
+-- Unwatched videos not checked for stale_minutes, least recently checked
+-- first. Unavailable ones are included so that they come back if restored.
+SELECT id, youtube_id, availability FROM videos
+WHERE watched = 0
+  AND broadcast_state != 'upcoming'
+  AND (availability_checked_at IS NULL
+       OR availability_checked_at <= datetime('now', printf('-%d minutes', CAST(sqlc.arg(stale_minutes) AS INTEGER))))
+ORDER BY availability_checked_at IS NOT NULL, availability_checked_at, id
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateVideoAvailability :one
+UPDATE videos SET availability = ?, availability_checked_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING *;
+
+-- name: ListGoneVideos :many
+-- The unwatched videos of a subscription that can't be watched any more.
+SELECT * FROM videos
+WHERE subscription_id = ? AND watched = 0 AND availability != 'available'
+ORDER BY published_at DESC;
+
+-- name: CountGoneVideos :one
+SELECT COUNT(*) FROM videos
+WHERE subscription_id = ? AND watched = 0 AND availability != 'available';
+
+-- name: ListUnknownShorts :many
+-- Videos whose Shorts verdict is still unknown and that are due for another
+-- probe. The wait after a failed probe doubles with every attempt, starting
+-- from backoff_minutes; after max_attempts the video is given up on.
+SELECT * FROM videos
+WHERE is_short IS NULL
+  AND short_attempts < CAST(sqlc.arg(max_attempts) AS INTEGER)
+  AND (short_checked_at IS NULL
+       OR short_checked_at <= datetime('now',
+            printf('-%d minutes', CAST(sqlc.arg(backoff_minutes) AS INTEGER) << short_attempts)))
+ORDER BY short_checked_at, id
+LIMIT sqlc.arg(limit);
+
+-- name: UpdateVideoShort :one
+UPDATE videos
+SET is_short = ?, short_source = ?, short_checked_at = CURRENT_TIMESTAMP, short_attempts = short_attempts + 1
+WHERE id = ?
+RETURNING *;
+
+-- name: ResetSubscriptionShorts :exec
+-- Forgets the Shorts verdicts of a subscription's videos before they are
+-- classified again.
+UPDATE videos
+SET is_short = NULL, short_source = NULL, short_checked_at = NULL, short_attempts = 0
+WHERE subscription_id = ?;
+
+-- name: ListSubscriptionVideoIDs :many
+SELECT id, youtube_id, duration FROM videos WHERE subscription_id = ? ORDER BY id;
+
+-- name: ListSubscriptionsForRefresh :many
+-- Subscriptions shown on any deck, directly or through a folder column.
+SELECT * FROM subscriptions
+WHERE id IN (SELECT subscription_id FROM deck_columns WHERE subscription_id IS NOT NULL)
+   OR id IN (
+    SELECT sg.subscription_id FROM subscription_groups sg
+    JOIN virtual_columns vc ON vc.group_id = sg.group_id
+)
+ORDER BY last_checked IS NOT NULL, last_checked;
+
+-- name: UpdateSubscriptionRefreshInterval :exec
+UPDATE subscriptions SET refresh_interval = ? WHERE id = ?;
+
+-- name: GetLatestVideoPublishedAt :one
+-- Upcoming broadcasts are dated by their scheduled start and don't count.
+SELECT published_at FROM videos
+WHERE subscription_id = ? AND published_at IS NOT NULL AND broadcast_state != 'upcoming'
+ORDER BY published_at DESC
+LIMIT 1;
+
+-- name: AddQuotaUsage :exec
+INSERT INTO quota_usage (day, call, units, calls)
+VALUES (?, ?, ?, 1)
+ON CONFLICT(day, call) DO UPDATE SET
+    units = units + excluded.units,
+    calls = calls + 1;
+
+-- name: GetQuotaUsed :one
+SELECT CAST(COALESCE(SUM(units), 0) AS INTEGER) AS used FROM quota_usage WHERE day = ?;
+
+-- name: ListQuotaUsage :many
+SELECT * FROM quota_usage WHERE day = ? ORDER BY units DESC;
+
+-- name: UpdateSubscriptionChannelInfo :exec
+UPDATE subscriptions
+SET uploads_playlist_id = ?, custom_url = ?, subscriber_count = ?
+WHERE id = ?;
+
+-- name: ListChannelsMissingUploads :many
+SELECT * FROM subscriptions
+WHERE type = 'channel' AND (uploads_playlist_id IS NULL OR uploads_playlist_id = '')
+ORDER BY id;
+
+-- name: ListVirtualColumns :many
+SELECT vc.* FROM virtual_columns vc
+JOIN deck_columns dc ON dc.virtual_column_id = vc.id
+WHERE dc.deck_id = ?
+ORDER BY dc.position, vc.id;
+
+-- name: GetVirtualColumn :one
+SELECT * FROM virtual_columns WHERE id = ?;
+
+-- name: CreateVirtualColumn :one
+INSERT INTO virtual_columns (kind, name, group_id, position)
+VALUES (?, ?, ?, (SELECT COALESCE(MAX(position), -1) + 1 FROM virtual_columns))
+RETURNING *;
+
+-- name: AddDeckVirtualColumn :exec
+INSERT INTO deck_columns (deck_id, virtual_column_id, position)
+VALUES (sqlc.arg(deck_id), sqlc.arg(virtual_column_id),
+        (SELECT COALESCE(MAX(position), -1) + 1 FROM deck_columns WHERE deck_id = sqlc.arg(deck_id)));
+
+-- name: DeleteVirtualColumn :exec
+DELETE FROM virtual_columns WHERE id = ?;
+
+-- name: ListRiverVideos :many
+SELECT sqlc.embed(videos), subscriptions.name AS channel_name, subscriptions.thumbnail_url AS channel_thumbnail_url
+FROM videos
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+WHERE videos.watched = 0
+  AND videos.availability = 'available'
+  AND (COALESCE(subscriptions.hide_shorts, 0) = 0 OR COALESCE(videos.is_short, 0) = 0)
+  AND (subscriptions.upcoming_mode = 'show' OR videos.broadcast_state != 'upcoming')
+  AND (subscriptions.min_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds >= subscriptions.min_duration_seconds)
+  AND (subscriptions.max_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds <= subscriptions.max_duration_seconds)
+  AND videos.muted = 0
+  AND videos.published_at >= sqlc.arg(since)
+  AND (CAST(sqlc.arg(group_id) AS INTEGER) = 0 OR videos.subscription_id IN (
+    SELECT subscription_id FROM subscription_groups WHERE group_id = sqlc.arg(group_id)))
+  AND (videos.published_at < sqlc.arg(before)
+       OR (videos.published_at = sqlc.arg(before) AND videos.id < sqlc.arg(before_id)))
+ORDER BY videos.published_at DESC, videos.id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: CountRiverVideos :one
+SELECT COUNT(*) FROM videos
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+WHERE videos.watched = 0
+  AND videos.availability = 'available'
+  AND (COALESCE(subscriptions.hide_shorts, 0) = 0 OR COALESCE(videos.is_short, 0) = 0)
+  AND (subscriptions.upcoming_mode = 'show' OR videos.broadcast_state != 'upcoming')
+  AND (subscriptions.min_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds >= subscriptions.min_duration_seconds)
+  AND (subscriptions.max_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds <= subscriptions.max_duration_seconds)
+  AND videos.muted = 0
+  AND videos.published_at >= sqlc.arg(since)
+  AND (CAST(sqlc.arg(group_id) AS INTEGER) = 0 OR videos.subscription_id IN (
+    SELECT subscription_id FROM subscription_groups WHERE group_id = sqlc.arg(group_id)));
+
+-- name: ListGroups :many
+SELECT g.*, COUNT(sg.subscription_id) AS member_count
+FROM groups g
+LEFT JOIN subscription_groups sg ON sg.group_id = g.id
+GROUP BY g.id
+ORDER BY g.position, g.name;
+
+-- name: GetGroup :one
+SELECT * FROM groups WHERE id = ?;
+
+-- name: CreateGroup :one
+INSERT INTO groups (name, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM groups))
+RETURNING *;
+
+-- name: GetGroupByName :one
+SELECT * FROM groups WHERE name = ?;
+
+-- name: ListSubscriptionGroupNames :many
+SELECT sg.subscription_id, g.name AS group_name
+FROM subscription_groups sg
+JOIN groups g ON g.id = sg.group_id
+ORDER BY g.position, g.name;
+
+-- name: DeleteGroup :exec
+DELETE FROM groups WHERE id = ?;
+
+-- name: ListSubscriptionGroupIDs :many
+SELECT group_id FROM subscription_groups WHERE subscription_id = ?;
+
+-- name: AddSubscriptionGroup :exec
+INSERT INTO subscription_groups (subscription_id, group_id)
+VALUES (?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: ClearSubscriptionGroups :exec
+DELETE FROM subscription_groups WHERE subscription_id = ?;
+
+-- name: ListDecks :many
+SELECT * FROM decks ORDER BY position, id;
+
+-- name: GetDeck :one
+SELECT * FROM decks WHERE id = ?;
+
+-- name: CreateDeck :one
+INSERT INTO decks (name, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM decks))
+RETURNING *;
+
+-- name: DeleteDeck :exec
+DELETE FROM decks WHERE id = ?;
+
+-- name: GetSetting :one
+SELECT value FROM settings WHERE key = ?;
+
+-- name: SetSetting :exec
+INSERT INTO settings (key, value) VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value;
+
+-- name: ListQueue :many
+SELECT sqlc.embed(videos), subscriptions.name AS channel_name, subscriptions.thumbnail_url AS channel_thumbnail_url
+FROM queue
+JOIN videos ON videos.id = queue.video_id
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+ORDER BY queue.position, queue.added_at;
+
+-- name: CountQueue :one
+SELECT COUNT(*) FROM queue;
+
+-- name: EnqueueVideo :execrows
+INSERT INTO queue (video_id, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM queue))
+ON CONFLICT DO NOTHING;
+
+-- name: DequeueVideo :execrows
+DELETE FROM queue WHERE video_id = ?;
+
+-- name: UpdateQueuePosition :exec
+UPDATE queue SET position = ? WHERE video_id = ?;
+
+-- name: AddWatchHistory :exec
+INSERT INTO watch_history (youtube_id, watched_at) VALUES (?, ?)
+ON CONFLICT(youtube_id) DO UPDATE SET watched_at = excluded.watched_at
+WHERE excluded.watched_at > watch_history.watched_at;
+
+-- name: MarkWatchHistoryWatched :execrows
+UPDATE videos SET watched = 1
+WHERE watched = 0 AND youtube_id IN (SELECT youtube_id FROM watch_history);
+
+-- name: MarkNewVideosWatchedFromHistory :many
+UPDATE videos SET watched = 1
+WHERE id IN (sqlc.slice(ids)) AND watched = 0
+  AND youtube_id IN (SELECT youtube_id FROM watch_history)
+RETURNING id;
+
+-- name: ListWatchedVideosForExport :many
+SELECT v.youtube_id, v.title, v.duration_seconds, v.published_at,
+       s.name AS subscription_name, s.type AS subscription_type, s.youtube_id AS subscription_youtube_id,
+       h.watched_at
+FROM videos v
+JOIN subscriptions s ON s.id = v.subscription_id
+LEFT JOIN watch_history h ON h.youtube_id = v.youtube_id
+WHERE v.watched = 1
+ORDER BY COALESCE(h.watched_at, v.published_at) DESC;
+
+-- name: ListSettings :many
+SELECT * FROM settings ORDER BY key;
+
+-- name: ListDeckColumnsForBackup :many
+-- Every deck column with the subscription or virtual column it shows, named
+-- by YouTube ID and by kind, name and folder so a backup doesn't depend on
+-- row IDs.
+SELECT dc.deck_id, dc.position,
+       s.youtube_id AS subscription_youtube_id,
+       vc.kind AS virtual_kind, vc.name AS virtual_name, g.name AS group_name
+FROM deck_columns dc
+LEFT JOIN subscriptions s ON s.id = dc.subscription_id
+LEFT JOIN virtual_columns vc ON vc.id = dc.virtual_column_id
+LEFT JOIN groups g ON g.id = vc.group_id
+ORDER BY dc.deck_id, dc.position, dc.id;
+
+-- name: ListMuteRulesForBackup :many
+SELECT r.pattern, r.is_regex, r.action, s.youtube_id AS subscription_youtube_id
+FROM mute_rules r
+LEFT JOIN subscriptions s ON s.id = r.subscription_id
+ORDER BY r.id;
+
+-- name: ListQueueForBackup :many
+SELECT v.youtube_id, v.title, v.thumbnail_url, v.duration, v.duration_seconds, v.published_at,
+       s.youtube_id AS subscription_youtube_id
+FROM queue q
+JOIN videos v ON v.id = q.video_id
+JOIN subscriptions s ON s.id = v.subscription_id
+ORDER BY q.position, q.added_at;
+
+-- name: ListWatchedVideoIDs :many
+SELECT youtube_id FROM videos WHERE watched = 1 ORDER BY youtube_id;
+
+-- name: ListWatchHistory :many
+SELECT * FROM watch_history ORDER BY youtube_id;
+
+-- name: RestoreSubscription :one
+INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, position, hide_shorts, refresh_interval,
+                           uploads_playlist_id, custom_url, min_duration_seconds, max_duration_seconds,
+                           upcoming_mode, sort_order)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: RestoreVideo :one
+-- Stores a queued video of a backup unless it's stored already. Its Shorts
+-- verdict is left unknown, to be probed like any new video's.
+INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, duration_seconds, published_at, is_short)
+VALUES (?, ?, ?, ?, ?, ?, ?, NULL)
+ON CONFLICT(youtube_id) DO UPDATE SET youtube_id = excluded.youtube_id
+RETURNING id;
+
+-- name: GetSubscriptionByYoutubeID :one
+SELECT * FROM subscriptions WHERE youtube_id = ?;
+
+-- name: UpdateSubscriptionName :exec
+UPDATE subscriptions SET name = ? WHERE id = ?;
+
+-- name: UpdateSubscriptionThumbnail :exec
+UPDATE subscriptions SET thumbnail_url = ? WHERE id = ?;
+
+-- name: ListAccountSubscriptions :many
+SELECT youtube_id FROM account_subscriptions;
+
+-- name: ClearAccountSubscriptions :exec
+DELETE FROM account_subscriptions;
+
+-- name: AddAccountSubscription :exec
+INSERT INTO account_subscriptions (youtube_id) VALUES (?)
+ON CONFLICT DO NOTHING;
+
+-- name: ListSyncChanges :many
+-- Pending changes with the subscription they apply to, if it's stored:
+-- additions first, then removals, renames and avatars, each by name.
+SELECT c.*, s.name AS current_name
+FROM sync_changes c
+LEFT JOIN subscriptions s ON s.youtube_id = c.youtube_id
+ORDER BY CASE c.kind WHEN 'add' THEN 0 WHEN 'remove' THEN 1 WHEN 'rename' THEN 2 ELSE 3 END, c.name COLLATE NOCASE;
+
+-- name: GetSyncChange :one
+SELECT * FROM sync_changes WHERE id = ?;
+
+-- name: AddSyncChange :exec
+INSERT INTO sync_changes (kind, youtube_id, name, thumbnail_url) VALUES (?, ?, ?, ?);
+
+-- name: DeleteSyncChange :exec
+DELETE FROM sync_changes WHERE id = ?;
+
+-- name: ClearSyncChanges :exec
+DELETE FROM sync_changes;
+
+-- name: ListSyncRejections :many
+SELECT kind, youtube_id, value FROM sync_rejections;
+
+-- name: AddSyncRejection :exec
+INSERT INTO sync_rejections (kind, youtube_id, value) VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: CreateJob :one
+INSERT INTO jobs (kind) VALUES (?)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = ?;
+
+-- name: ListUndismissedJobs :many
+SELECT * FROM jobs WHERE dismissed = 0 ORDER BY id;
+
+-- name: UpdateJobProgress :exec
+UPDATE jobs SET progress = ?, total = ? WHERE id = ? AND status = 'running';
+
+-- name: FinishJob :exec
+UPDATE jobs SET status = ?, result = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'running';
+
+-- name: FailRunningJobs :execrows
+-- Fails the jobs a previous run of the server left running.
+UPDATE jobs SET status = 'failed', error = ?, finished_at = CURRENT_TIMESTAMP
+WHERE status = 'running';
+
+-- name: DismissJob :exec
+UPDATE jobs SET dismissed = 1 WHERE id = ? AND status != 'running';
 
--- name: UpdateVideoIsShort :exec
-UPDATE videos SET is_short = ? WHERE id = ?;
+-- name: DeleteDismissedJobs :exec
+DELETE FROM jobs
+WHERE dismissed = 1
+  AND finished_at <= datetime('now', printf('-%d days', CAST(sqlc.arg(retention_days) AS INTEGER)));
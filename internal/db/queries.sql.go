@@ -8,14 +8,234 @@ package db
 import (
 	"context"
 	"database/sql"
+	"strings"
 )
 
-const countActiveSubscriptions = `-- name: CountActiveSubscriptions :one
-SELECT COUNT(*) FROM subscriptions WHERE active = 1
+const addAccountSubscription = `-- name: AddAccountSubscription :exec
+INSERT INTO account_subscriptions (youtube_id) VALUES (?)
+ON CONFLICT DO NOTHING
 `
 
-func (q *Queries) CountActiveSubscriptions(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countActiveSubscriptions)
+func (q *Queries) AddAccountSubscription(ctx context.Context, youtubeID string) error {
+	_, err := q.db.ExecContext(ctx, addAccountSubscription, youtubeID)
+	return err
+}
+
+const addDeckSubscription = `-- name: AddDeckSubscription :exec
+INSERT INTO deck_columns (deck_id, subscription_id, position)
+VALUES (?1, ?2,
+        (SELECT COALESCE(MAX(position), -1) + 1 FROM deck_columns WHERE deck_id = ?1))
+ON CONFLICT DO NOTHING
+`
+
+type AddDeckSubscriptionParams struct {
+	DeckID         int64         `json:"deck_id"`
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+}
+
+func (q *Queries) AddDeckSubscription(ctx context.Context, arg AddDeckSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, addDeckSubscription, arg.DeckID, arg.SubscriptionID)
+	return err
+}
+
+const addDeckVirtualColumn = `-- name: AddDeckVirtualColumn :exec
+INSERT INTO deck_columns (deck_id, virtual_column_id, position)
+VALUES (?1, ?2,
+        (SELECT COALESCE(MAX(position), -1) + 1 FROM deck_columns WHERE deck_id = ?1))
+`
+
+type AddDeckVirtualColumnParams struct {
+	DeckID          int64         `json:"deck_id"`
+	VirtualColumnID sql.NullInt64 `json:"virtual_column_id"`
+}
+
+func (q *Queries) AddDeckVirtualColumn(ctx context.Context, arg AddDeckVirtualColumnParams) error {
+	_, err := q.db.ExecContext(ctx, addDeckVirtualColumn, arg.DeckID, arg.VirtualColumnID)
+	return err
+}
+
+const addQuotaUsage = `-- name: AddQuotaUsage :exec
+INSERT INTO quota_usage (day, call, units, calls)
+VALUES (?, ?, ?, 1)
+ON CONFLICT(day, call) DO UPDATE SET
+    units = units + excluded.units,
+    calls = calls + 1
+`
+
+type AddQuotaUsageParams struct {
+	Day   string `json:"day"`
+	Call  string `json:"call"`
+	Units int64  `json:"units"`
+}
+
+func (q *Queries) AddQuotaUsage(ctx context.Context, arg AddQuotaUsageParams) error {
+	_, err := q.db.ExecContext(ctx, addQuotaUsage, arg.Day, arg.Call, arg.Units)
+	return err
+}
+
+const addSubscriptionGroup = `-- name: AddSubscriptionGroup :exec
+INSERT INTO subscription_groups (subscription_id, group_id)
+VALUES (?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type AddSubscriptionGroupParams struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	GroupID        int64 `json:"group_id"`
+}
+
+func (q *Queries) AddSubscriptionGroup(ctx context.Context, arg AddSubscriptionGroupParams) error {
+	_, err := q.db.ExecContext(ctx, addSubscriptionGroup, arg.SubscriptionID, arg.GroupID)
+	return err
+}
+
+const addSyncChange = `-- name: AddSyncChange :exec
+INSERT INTO sync_changes (kind, youtube_id, name, thumbnail_url) VALUES (?, ?, ?, ?)
+`
+
+type AddSyncChangeParams struct {
+	Kind         string         `json:"kind"`
+	YoutubeID    string         `json:"youtube_id"`
+	Name         string         `json:"name"`
+	ThumbnailUrl sql.NullString `json:"thumbnail_url"`
+}
+
+func (q *Queries) AddSyncChange(ctx context.Context, arg AddSyncChangeParams) error {
+	_, err := q.db.ExecContext(ctx, addSyncChange,
+		arg.Kind,
+		arg.YoutubeID,
+		arg.Name,
+		arg.ThumbnailUrl,
+	)
+	return err
+}
+
+const addSyncRejection = `-- name: AddSyncRejection :exec
+INSERT INTO sync_rejections (kind, youtube_id, value) VALUES (?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type AddSyncRejectionParams struct {
+	Kind      string `json:"kind"`
+	YoutubeID string `json:"youtube_id"`
+	Value     string `json:"value"`
+}
+
+func (q *Queries) AddSyncRejection(ctx context.Context, arg AddSyncRejectionParams) error {
+	_, err := q.db.ExecContext(ctx, addSyncRejection, arg.Kind, arg.YoutubeID, arg.Value)
+	return err
+}
+
+const addWatchHistory = `-- name: AddWatchHistory :exec
+INSERT INTO watch_history (youtube_id, watched_at) VALUES (?, ?)
+ON CONFLICT(youtube_id) DO UPDATE SET watched_at = excluded.watched_at
+WHERE excluded.watched_at > watch_history.watched_at
+`
+
+type AddWatchHistoryParams struct {
+	YoutubeID string       `json:"youtube_id"`
+	WatchedAt sql.NullTime `json:"watched_at"`
+}
+
+func (q *Queries) AddWatchHistory(ctx context.Context, arg AddWatchHistoryParams) error {
+	_, err := q.db.ExecContext(ctx, addWatchHistory, arg.YoutubeID, arg.WatchedAt)
+	return err
+}
+
+const clearAccountSubscriptions = `-- name: ClearAccountSubscriptions :exec
+DELETE FROM account_subscriptions
+`
+
+func (q *Queries) ClearAccountSubscriptions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearAccountSubscriptions)
+	return err
+}
+
+const clearPlaylistPositions = `-- name: ClearPlaylistPositions :exec
+UPDATE videos SET playlist_position = NULL WHERE subscription_id = ?
+`
+
+func (q *Queries) ClearPlaylistPositions(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearPlaylistPositions, subscriptionID)
+	return err
+}
+
+const clearSubscriptionGroups = `-- name: ClearSubscriptionGroups :exec
+DELETE FROM subscription_groups WHERE subscription_id = ?
+`
+
+func (q *Queries) ClearSubscriptionGroups(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, clearSubscriptionGroups, subscriptionID)
+	return err
+}
+
+const clearSyncChanges = `-- name: ClearSyncChanges :exec
+DELETE FROM sync_changes
+`
+
+func (q *Queries) ClearSyncChanges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, clearSyncChanges)
+	return err
+}
+
+const countDeckSubscriptions = `-- name: CountDeckSubscriptions :one
+SELECT COUNT(*) FROM deck_columns WHERE deck_id = ? AND subscription_id IS NOT NULL
+`
+
+func (q *Queries) CountDeckSubscriptions(ctx context.Context, deckID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countDeckSubscriptions, deckID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countGoneVideos = `-- name: CountGoneVideos :one
+SELECT COUNT(*) FROM videos
+WHERE subscription_id = ? AND watched = 0 AND availability != 'available'
+`
+
+func (q *Queries) CountGoneVideos(ctx context.Context, subscriptionID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countGoneVideos, subscriptionID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countQueue = `-- name: CountQueue :one
+SELECT COUNT(*) FROM queue
+`
+
+func (q *Queries) CountQueue(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countQueue)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countRiverVideos = `-- name: CountRiverVideos :one
+SELECT COUNT(*) FROM videos
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+WHERE videos.watched = 0
+  AND videos.availability = 'available'
+  AND (COALESCE(subscriptions.hide_shorts, 0) = 0 OR COALESCE(videos.is_short, 0) = 0)
+  AND (subscriptions.upcoming_mode = 'show' OR videos.broadcast_state != 'upcoming')
+  AND (subscriptions.min_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds >= subscriptions.min_duration_seconds)
+  AND (subscriptions.max_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds <= subscriptions.max_duration_seconds)
+  AND videos.muted = 0
+  AND videos.published_at >= ?1
+  AND (CAST(?2 AS INTEGER) = 0 OR videos.subscription_id IN (
+    SELECT subscription_id FROM subscription_groups WHERE group_id = ?2))
+`
+
+type CountRiverVideosParams struct {
+	Since   sql.NullTime `json:"since"`
+	GroupID int64        `json:"group_id"`
+}
+
+func (q *Queries) CountRiverVideos(ctx context.Context, arg CountRiverVideosParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countRiverVideos, arg.Since, arg.GroupID)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -33,7 +253,7 @@ func (q *Queries) CountTotalVideos(ctx context.Context, subscriptionID int64) (i
 }
 
 const countUnwatchedBySubscription = `-- name: CountUnwatchedBySubscription :one
-SELECT COUNT(*) FROM videos WHERE subscription_id = ? AND watched = 0
+SELECT COUNT(*) FROM videos WHERE subscription_id = ? AND watched = 0 AND availability = 'available'
 `
 
 func (q *Queries) CountUnwatchedBySubscription(ctx context.Context, subscriptionID int64) (int64, error) {
@@ -45,27 +265,138 @@ func (q *Queries) CountUnwatchedBySubscription(ctx context.Context, subscription
 
 const countUnwatchedBySubscriptionFiltered = `-- name: CountUnwatchedBySubscriptionFiltered :one
 SELECT COUNT(*) FROM videos
-WHERE subscription_id = ?
+WHERE subscription_id = ?1
   AND watched = 0
-  AND (? = 0 OR is_short = 0)
+  AND availability = 'available'
+  AND (CAST(?2 AS INTEGER) = 0 OR COALESCE(is_short, 0) = 0)
+  AND (CAST(?3 AS INTEGER) = 0 OR broadcast_state != 'upcoming')
+  AND (CAST(?4 AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds >= CAST(?4 AS INTEGER))
+  AND (CAST(?5 AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds <= CAST(?5 AS INTEGER))
+  AND videos.muted = CAST(?6 AS INTEGER)
 `
 
 type CountUnwatchedBySubscriptionFilteredParams struct {
-	SubscriptionID int64       `json:"subscription_id"`
-	Column2        interface{} `json:"column_2"`
+	SubscriptionID int64         `json:"subscription_id"`
+	HideShorts     int64         `json:"hide_shorts"`
+	HideUpcoming   int64         `json:"hide_upcoming"`
+	MinSeconds     sql.NullInt64 `json:"min_seconds"`
+	MaxSeconds     sql.NullInt64 `json:"max_seconds"`
+	Muted          int64         `json:"muted"`
 }
 
 func (q *Queries) CountUnwatchedBySubscriptionFiltered(ctx context.Context, arg CountUnwatchedBySubscriptionFilteredParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countUnwatchedBySubscriptionFiltered, arg.SubscriptionID, arg.Column2)
+	row := q.db.QueryRowContext(ctx, countUnwatchedBySubscriptionFiltered,
+		arg.SubscriptionID,
+		arg.HideShorts,
+		arg.HideUpcoming,
+		arg.MinSeconds,
+		arg.MaxSeconds,
+		arg.Muted,
+	)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
+const createDeck = `-- name: CreateDeck :one
+INSERT INTO decks (name, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM decks))
+RETURNING id, name, position, created_at
+`
+
+func (q *Queries) CreateDeck(ctx context.Context, name string) (Deck, error) {
+	row := q.db.QueryRowContext(ctx, createDeck, name)
+	var i Deck
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createGroup = `-- name: CreateGroup :one
+INSERT INTO groups (name, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM groups))
+RETURNING id, name, position, created_at
+`
+
+func (q *Queries) CreateGroup(ctx context.Context, name string) (Group, error) {
+	row := q.db.QueryRowContext(ctx, createGroup, name)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createJob = `-- name: CreateJob :one
+INSERT INTO jobs (kind) VALUES (?)
+RETURNING id, kind, status, progress, total, result, error, dismissed, created_at, finished_at
+`
+
+func (q *Queries) CreateJob(ctx context.Context, kind string) (Job, error) {
+	row := q.db.QueryRowContext(ctx, createJob, kind)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Status,
+		&i.Progress,
+		&i.Total,
+		&i.Result,
+		&i.Error,
+		&i.Dismissed,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const createMuteRule = `-- name: CreateMuteRule :one
+INSERT INTO mute_rules (subscription_id, pattern, is_regex, action)
+VALUES (?, ?, ?, ?)
+RETURNING id, subscription_id, pattern, is_regex, "action", created_at
+`
+
+type CreateMuteRuleParams struct {
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+	Pattern        string        `json:"pattern"`
+	IsRegex        int64         `json:"is_regex"`
+	Action         string        `json:"action"`
+}
+
+func (q *Queries) CreateMuteRule(ctx context.Context, arg CreateMuteRuleParams) (MuteRule, error) {
+	row := q.db.QueryRowContext(ctx, createMuteRule,
+		arg.SubscriptionID,
+		arg.Pattern,
+		arg.IsRegex,
+		arg.Action,
+	)
+	var i MuteRule
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.Pattern,
+		&i.IsRegex,
+		&i.Action,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
 const createSubscription = `-- name: CreateSubscription :one
-INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, position, active)
-VALUES (?, ?, ?, ?, COALESCE((SELECT MAX(position) FROM subscriptions), 0) + 1, ?)
-RETURNING id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, active, page_token, hide_shorts
+INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, sort_order, position)
+VALUES (?1, ?2, ?3, ?4,
+        COALESCE(CAST(?5 AS TEXT), 'newest'),
+        COALESCE((SELECT MAX(position) FROM subscriptions), 0) + 1)
+RETURNING id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order
 `
 
 type CreateSubscriptionParams struct {
@@ -73,16 +404,17 @@ type CreateSubscriptionParams struct {
 	YoutubeID    string         `json:"youtube_id"`
 	Type         string         `json:"type"`
 	ThumbnailUrl sql.NullString `json:"thumbnail_url"`
-	Active       sql.NullInt64  `json:"active"`
+	SortOrder    sql.NullString `json:"sort_order"`
 }
 
+// sort_order defaults to newest first.
 func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscriptionParams) (Subscription, error) {
 	row := q.db.QueryRowContext(ctx, createSubscription,
 		arg.Name,
 		arg.YoutubeID,
 		arg.Type,
 		arg.ThumbnailUrl,
-		arg.Active,
+		arg.SortOrder,
 	)
 	var i Subscription
 	err := row.Scan(
@@ -94,28 +426,48 @@ func (q *Queries) CreateSubscription(ctx context.Context, arg CreateSubscription
 		&i.LastChecked,
 		&i.CreatedAt,
 		&i.Position,
-		&i.Active,
 		&i.PageToken,
 		&i.HideShorts,
+		&i.RefreshInterval,
+		&i.UploadsPlaylistID,
+		&i.CustomUrl,
+		&i.SubscriberCount,
+		&i.MinDurationSeconds,
+		&i.MaxDurationSeconds,
+		&i.UpcomingMode,
+		&i.SortOrder,
 	)
 	return i, err
 }
 
 const createVideo = `-- name: CreateVideo :one
-INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, published_at, is_short)
-VALUES (?, ?, ?, ?, ?, ?, ?)
+INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, duration_seconds, published_at, is_short,
+                    short_source, short_checked_at, short_attempts, broadcast_state, scheduled_start_at,
+                    description, view_count, like_count, comment_count, stats_updated_at, playlist_position, muted)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 ON CONFLICT(youtube_id) DO NOTHING
-RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
 `
 
 type CreateVideoParams struct {
-	SubscriptionID int64          `json:"subscription_id"`
-	YoutubeID      string         `json:"youtube_id"`
-	Title          string         `json:"title"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	Duration       sql.NullString `json:"duration"`
-	PublishedAt    sql.NullTime   `json:"published_at"`
-	IsShort        sql.NullInt64  `json:"is_short"`
+	SubscriptionID   int64          `json:"subscription_id"`
+	YoutubeID        string         `json:"youtube_id"`
+	Title            string         `json:"title"`
+	ThumbnailUrl     sql.NullString `json:"thumbnail_url"`
+	Duration         sql.NullString `json:"duration"`
+	DurationSeconds  sql.NullInt64  `json:"duration_seconds"`
+	PublishedAt      sql.NullTime   `json:"published_at"`
+	IsShort          sql.NullInt64  `json:"is_short"`
+	ShortSource      sql.NullString `json:"short_source"`
+	BroadcastState   string         `json:"broadcast_state"`
+	ScheduledStartAt sql.NullTime   `json:"scheduled_start_at"`
+	Description      sql.NullString `json:"description"`
+	ViewCount        sql.NullInt64  `json:"view_count"`
+	LikeCount        sql.NullInt64  `json:"like_count"`
+	CommentCount     sql.NullInt64  `json:"comment_count"`
+	StatsUpdatedAt   sql.NullTime   `json:"stats_updated_at"`
+	PlaylistPosition sql.NullInt64  `json:"playlist_position"`
+	Muted            int64          `json:"muted"`
 }
 
 func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video, error) {
@@ -125,8 +477,19 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		arg.Title,
 		arg.ThumbnailUrl,
 		arg.Duration,
+		arg.DurationSeconds,
 		arg.PublishedAt,
 		arg.IsShort,
+		arg.ShortSource,
+		arg.BroadcastState,
+		arg.ScheduledStartAt,
+		arg.Description,
+		arg.ViewCount,
+		arg.LikeCount,
+		arg.CommentCount,
+		arg.StatsUpdatedAt,
+		arg.PlaylistPosition,
+		arg.Muted,
 	)
 	var i Video
 	err := row.Scan(
@@ -140,6 +503,95 @@ func (q *Queries) CreateVideo(ctx context.Context, arg CreateVideoParams) (Video
 		&i.Watched,
 		&i.CreatedAt,
 		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const createVirtualColumn = `-- name: CreateVirtualColumn :one
+INSERT INTO virtual_columns (kind, name, group_id, position)
+VALUES (?, ?, ?, (SELECT COALESCE(MAX(position), -1) + 1 FROM virtual_columns))
+RETURNING id, kind, name, position, created_at, group_id
+`
+
+type CreateVirtualColumnParams struct {
+	Kind    string        `json:"kind"`
+	Name    string        `json:"name"`
+	GroupID sql.NullInt64 `json:"group_id"`
+}
+
+func (q *Queries) CreateVirtualColumn(ctx context.Context, arg CreateVirtualColumnParams) (VirtualColumn, error) {
+	row := q.db.QueryRowContext(ctx, createVirtualColumn, arg.Kind, arg.Name, arg.GroupID)
+	var i VirtualColumn
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+		&i.GroupID,
+	)
+	return i, err
+}
+
+const deleteDeck = `-- name: DeleteDeck :exec
+DELETE FROM decks WHERE id = ?
+`
+
+func (q *Queries) DeleteDeck(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteDeck, id)
+	return err
+}
+
+const deleteDismissedJobs = `-- name: DeleteDismissedJobs :exec
+DELETE FROM jobs
+WHERE dismissed = 1
+  AND finished_at <= datetime('now', printf('-%d days', CAST(?1 AS INTEGER)))
+`
+
+func (q *Queries) DeleteDismissedJobs(ctx context.Context, retentionDays int64) error {
+	_, err := q.db.ExecContext(ctx, deleteDismissedJobs, retentionDays)
+	return err
+}
+
+const deleteGroup = `-- name: DeleteGroup :exec
+DELETE FROM groups WHERE id = ?
+`
+
+func (q *Queries) DeleteGroup(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteGroup, id)
+	return err
+}
+
+const deleteMuteRule = `-- name: DeleteMuteRule :one
+DELETE FROM mute_rules WHERE id = ?
+RETURNING id, subscription_id, pattern, is_regex, "action", created_at
+`
+
+func (q *Queries) DeleteMuteRule(ctx context.Context, id int64) (MuteRule, error) {
+	row := q.db.QueryRowContext(ctx, deleteMuteRule, id)
+	var i MuteRule
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.Pattern,
+		&i.IsRegex,
+		&i.Action,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -153,33 +605,97 @@ func (q *Queries) DeleteSubscription(ctx context.Context, id int64) error {
 	return err
 }
 
+const deleteSyncChange = `-- name: DeleteSyncChange :exec
+DELETE FROM sync_changes WHERE id = ?
+`
+
+func (q *Queries) DeleteSyncChange(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteSyncChange, id)
+	return err
+}
+
+const deleteVirtualColumn = `-- name: DeleteVirtualColumn :exec
+DELETE FROM virtual_columns WHERE id = ?
+`
+
+func (q *Queries) DeleteVirtualColumn(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, deleteVirtualColumn, id)
+	return err
+}
+
+const dequeueVideo = `-- name: DequeueVideo :execrows
+DELETE FROM queue WHERE video_id = ?
+`
+
+func (q *Queries) DequeueVideo(ctx context.Context, videoID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, dequeueVideo, videoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const dismissJob = `-- name: DismissJob :exec
+UPDATE jobs SET dismissed = 1 WHERE id = ? AND status != 'running'
+`
+
+func (q *Queries) DismissJob(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, dismissJob, id)
+	return err
+}
+
+const enqueueVideo = `-- name: EnqueueVideo :execrows
+INSERT INTO queue (video_id, position)
+VALUES (?, (SELECT COALESCE(MAX(position), -1) + 1 FROM queue))
+ON CONFLICT DO NOTHING
+`
+
+func (q *Queries) EnqueueVideo(ctx context.Context, videoID int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enqueueVideo, videoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const failRunningJobs = `-- name: FailRunningJobs :execrows
+UPDATE jobs SET status = 'failed', error = ?, finished_at = CURRENT_TIMESTAMP
+WHERE status = 'running'
+`
+
+// Fails the jobs a previous run of the server left running.
+func (q *Queries) FailRunningJobs(ctx context.Context, error string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, failRunningJobs, error)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const filterSubscriptions = `-- name: FilterSubscriptions :many
-SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.active, s.page_token, s.hide_shorts, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = ?1 AND dc.subscription_id = s.id) AS in_deck
 FROM subscriptions s
 LEFT JOIN videos v ON v.subscription_id = s.id
-WHERE s.name LIKE '%' || ? || '%'
+WHERE s.name LIKE '%' || ?2 || '%'
 GROUP BY s.id
 ORDER BY s.position, s.name
 LIMIT 50
 `
 
+type FilterSubscriptionsParams struct {
+	DeckID int64          `json:"deck_id"`
+	Query  sql.NullString `json:"query"`
+}
+
 type FilterSubscriptionsRow struct {
-	ID             int64          `json:"id"`
-	Name           string         `json:"name"`
-	YoutubeID      string         `json:"youtube_id"`
-	Type           string         `json:"type"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	LastChecked    sql.NullTime   `json:"last_checked"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	Position       sql.NullInt64  `json:"position"`
-	Active         sql.NullInt64  `json:"active"`
-	PageToken      sql.NullString `json:"page_token"`
-	HideShorts     sql.NullInt64  `json:"hide_shorts"`
-	UnwatchedCount int64          `json:"unwatched_count"`
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	InDeck         int64        `json:"in_deck"`
 }
 
-func (q *Queries) FilterSubscriptions(ctx context.Context, dollar_1 sql.NullString) ([]FilterSubscriptionsRow, error) {
-	rows, err := q.db.QueryContext(ctx, filterSubscriptions, dollar_1)
+func (q *Queries) FilterSubscriptions(ctx context.Context, arg FilterSubscriptionsParams) ([]FilterSubscriptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, filterSubscriptions, arg.DeckID, arg.Query)
 	if err != nil {
 		return nil, err
 	}
@@ -188,18 +704,26 @@ func (q *Queries) FilterSubscriptions(ctx context.Context, dollar_1 sql.NullStri
 	for rows.Next() {
 		var i FilterSubscriptionsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.YoutubeID,
-			&i.Type,
-			&i.ThumbnailUrl,
-			&i.LastChecked,
-			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
 			&i.UnwatchedCount,
+			&i.InDeck,
 		); err != nil {
 			return nil, err
 		}
@@ -214,42 +738,226 @@ func (q *Queries) FilterSubscriptions(ctx context.Context, dollar_1 sql.NullStri
 	return items, nil
 }
 
-const getMaxPosition = `-- name: GetMaxPosition :one
-SELECT CAST(COALESCE(MAX(position), 0) AS INTEGER) as max_position FROM subscriptions
+const finishJob = `-- name: FinishJob :exec
+UPDATE jobs SET status = ?, result = ?, error = ?, finished_at = CURRENT_TIMESTAMP
+WHERE id = ? AND status = 'running'
 `
 
-func (q *Queries) GetMaxPosition(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getMaxPosition)
-	var max_position int64
-	err := row.Scan(&max_position)
-	return max_position, err
+type FinishJobParams struct {
+	Status string `json:"status"`
+	Result string `json:"result"`
+	Error  string `json:"error"`
+	ID     int64  `json:"id"`
 }
 
-const getSubscription = `-- name: GetSubscription :one
-SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, active, page_token, hide_shorts FROM subscriptions WHERE id = ?
+func (q *Queries) FinishJob(ctx context.Context, arg FinishJobParams) error {
+	_, err := q.db.ExecContext(ctx, finishJob,
+		arg.Status,
+		arg.Result,
+		arg.Error,
+		arg.ID,
+	)
+	return err
+}
+
+const getDeck = `-- name: GetDeck :one
+SELECT id, name, position, created_at FROM decks WHERE id = ?
 `
 
-func (q *Queries) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
-	row := q.db.QueryRowContext(ctx, getSubscription, id)
-	var i Subscription
+func (q *Queries) GetDeck(ctx context.Context, id int64) (Deck, error) {
+	row := q.db.QueryRowContext(ctx, getDeck, id)
+	var i Deck
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.YoutubeID,
-		&i.Type,
-		&i.ThumbnailUrl,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGroup = `-- name: GetGroup :one
+SELECT id, name, position, created_at FROM groups WHERE id = ?
+`
+
+func (q *Queries) GetGroup(ctx context.Context, id int64) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroup, id)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGroupByName = `-- name: GetGroupByName :one
+SELECT id, name, position, created_at FROM groups WHERE name = ?
+`
+
+func (q *Queries) GetGroupByName(ctx context.Context, name string) (Group, error) {
+	row := q.db.QueryRowContext(ctx, getGroupByName, name)
+	var i Group
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getJob = `-- name: GetJob :one
+SELECT id, kind, status, progress, total, result, error, dismissed, created_at, finished_at FROM jobs WHERE id = ?
+`
+
+func (q *Queries) GetJob(ctx context.Context, id int64) (Job, error) {
+	row := q.db.QueryRowContext(ctx, getJob, id)
+	var i Job
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Status,
+		&i.Progress,
+		&i.Total,
+		&i.Result,
+		&i.Error,
+		&i.Dismissed,
+		&i.CreatedAt,
+		&i.FinishedAt,
+	)
+	return i, err
+}
+
+const getLatestVideoPublishedAt = `-- name: GetLatestVideoPublishedAt :one
+SELECT published_at FROM videos
+WHERE subscription_id = ? AND published_at IS NOT NULL AND broadcast_state != 'upcoming'
+ORDER BY published_at DESC
+LIMIT 1
+`
+
+// Upcoming broadcasts are dated by their scheduled start and don't count.
+func (q *Queries) GetLatestVideoPublishedAt(ctx context.Context, subscriptionID int64) (sql.NullTime, error) {
+	row := q.db.QueryRowContext(ctx, getLatestVideoPublishedAt, subscriptionID)
+	var published_at sql.NullTime
+	err := row.Scan(&published_at)
+	return published_at, err
+}
+
+const getMaxPosition = `-- name: GetMaxPosition :one
+SELECT CAST(COALESCE(MAX(position), 0) AS INTEGER) as max_position FROM subscriptions
+`
+
+func (q *Queries) GetMaxPosition(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getMaxPosition)
+	var max_position int64
+	err := row.Scan(&max_position)
+	return max_position, err
+}
+
+const getQuotaUsed = `-- name: GetQuotaUsed :one
+SELECT CAST(COALESCE(SUM(units), 0) AS INTEGER) AS used FROM quota_usage WHERE day = ?
+`
+
+func (q *Queries) GetQuotaUsed(ctx context.Context, day string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getQuotaUsed, day)
+	var used int64
+	err := row.Scan(&used)
+	return used, err
+}
+
+const getSetting = `-- name: GetSetting :one
+SELECT value FROM settings WHERE key = ?
+`
+
+func (q *Queries) GetSetting(ctx context.Context, key string) (string, error) {
+	row := q.db.QueryRowContext(ctx, getSetting, key)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
+const getSubscription = `-- name: GetSubscription :one
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions WHERE id = ?
+`
+
+func (q *Queries) GetSubscription(ctx context.Context, id int64) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getSubscription, id)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.YoutubeID,
+		&i.Type,
+		&i.ThumbnailUrl,
+		&i.LastChecked,
+		&i.CreatedAt,
+		&i.Position,
+		&i.PageToken,
+		&i.HideShorts,
+		&i.RefreshInterval,
+		&i.UploadsPlaylistID,
+		&i.CustomUrl,
+		&i.SubscriberCount,
+		&i.MinDurationSeconds,
+		&i.MaxDurationSeconds,
+		&i.UpcomingMode,
+		&i.SortOrder,
+	)
+	return i, err
+}
+
+const getSubscriptionByYoutubeID = `-- name: GetSubscriptionByYoutubeID :one
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions WHERE youtube_id = ?
+`
+
+func (q *Queries) GetSubscriptionByYoutubeID(ctx context.Context, youtubeID string) (Subscription, error) {
+	row := q.db.QueryRowContext(ctx, getSubscriptionByYoutubeID, youtubeID)
+	var i Subscription
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.YoutubeID,
+		&i.Type,
+		&i.ThumbnailUrl,
 		&i.LastChecked,
 		&i.CreatedAt,
 		&i.Position,
-		&i.Active,
 		&i.PageToken,
 		&i.HideShorts,
+		&i.RefreshInterval,
+		&i.UploadsPlaylistID,
+		&i.CustomUrl,
+		&i.SubscriberCount,
+		&i.MinDurationSeconds,
+		&i.MaxDurationSeconds,
+		&i.UpcomingMode,
+		&i.SortOrder,
+	)
+	return i, err
+}
+
+const getSyncChange = `-- name: GetSyncChange :one
+SELECT id, kind, youtube_id, name, thumbnail_url, found_at FROM sync_changes WHERE id = ?
+`
+
+func (q *Queries) GetSyncChange(ctx context.Context, id int64) (SyncChange, error) {
+	row := q.db.QueryRowContext(ctx, getSyncChange, id)
+	var i SyncChange
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.YoutubeID,
+		&i.Name,
+		&i.ThumbnailUrl,
+		&i.FoundAt,
 	)
 	return i, err
 }
 
 const getVideo = `-- name: GetVideo :one
-SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short FROM videos WHERE id = ?
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos WHERE id = ?
 `
 
 func (q *Queries) GetVideo(ctx context.Context, id int64) (Video, error) {
@@ -266,56 +974,1690 @@ func (q *Queries) GetVideo(ctx context.Context, id int64) (Video, error) {
 		&i.Watched,
 		&i.CreatedAt,
 		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const getVirtualColumn = `-- name: GetVirtualColumn :one
+SELECT id, kind, name, position, created_at, group_id FROM virtual_columns WHERE id = ?
+`
+
+func (q *Queries) GetVirtualColumn(ctx context.Context, id int64) (VirtualColumn, error) {
+	row := q.db.QueryRowContext(ctx, getVirtualColumn, id)
+	var i VirtualColumn
+	err := row.Scan(
+		&i.ID,
+		&i.Kind,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+		&i.GroupID,
 	)
 	return i, err
 }
 
-const listActiveSubscriptions = `-- name: ListActiveSubscriptions :many
-SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.active, s.page_token, s.hide_shorts, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
-FROM subscriptions s
-LEFT JOIN videos v ON v.subscription_id = s.id
-WHERE s.active = 1
-GROUP BY s.id
-ORDER BY s.position
+const isSubscriptionInDeck = `-- name: IsSubscriptionInDeck :one
+SELECT EXISTS(SELECT 1 FROM deck_columns WHERE deck_id = ? AND subscription_id = ?)
+`
+
+type IsSubscriptionInDeckParams struct {
+	DeckID         int64         `json:"deck_id"`
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+}
+
+func (q *Queries) IsSubscriptionInDeck(ctx context.Context, arg IsSubscriptionInDeckParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, isSubscriptionInDeck, arg.DeckID, arg.SubscriptionID)
+	var column_1 int64
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const listAccountSubscriptions = `-- name: ListAccountSubscriptions :many
+SELECT youtube_id FROM account_subscriptions
+`
+
+func (q *Queries) ListAccountSubscriptions(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var youtube_id string
+		if err := rows.Scan(&youtube_id); err != nil {
+			return nil, err
+		}
+		items = append(items, youtube_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllMuteRules = `-- name: ListAllMuteRules :many
+SELECT id, subscription_id, pattern, is_regex, "action", created_at FROM mute_rules ORDER BY subscription_id IS NOT NULL, id
+`
+
+func (q *Queries) ListAllMuteRules(ctx context.Context) ([]MuteRule, error) {
+	rows, err := q.db.QueryContext(ctx, listAllMuteRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MuteRule{}
+	for rows.Next() {
+		var i MuteRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Pattern,
+			&i.IsRegex,
+			&i.Action,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAllSubscriptionsOrdered = `-- name: ListAllSubscriptionsOrdered :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = ?1 AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+LEFT JOIN videos v ON v.subscription_id = s.id
+GROUP BY s.id
+ORDER BY s.position, s.name
+`
+
+type ListAllSubscriptionsOrderedRow struct {
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	InDeck         int64        `json:"in_deck"`
+}
+
+func (q *Queries) ListAllSubscriptionsOrdered(ctx context.Context, deckID int64) ([]ListAllSubscriptionsOrderedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAllSubscriptionsOrdered, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAllSubscriptionsOrderedRow{}
+	for rows.Next() {
+		var i ListAllSubscriptionsOrderedRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
+			&i.UnwatchedCount,
+			&i.InDeck,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChannelsMissingUploads = `-- name: ListChannelsMissingUploads :many
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions
+WHERE type = 'channel' AND (uploads_playlist_id IS NULL OR uploads_playlist_id = '')
+ORDER BY id
+`
+
+func (q *Queries) ListChannelsMissingUploads(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listChannelsMissingUploads)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YoutubeID,
+			&i.Type,
+			&i.ThumbnailUrl,
+			&i.LastChecked,
+			&i.CreatedAt,
+			&i.Position,
+			&i.PageToken,
+			&i.HideShorts,
+			&i.RefreshInterval,
+			&i.UploadsPlaylistID,
+			&i.CustomUrl,
+			&i.SubscriberCount,
+			&i.MinDurationSeconds,
+			&i.MaxDurationSeconds,
+			&i.UpcomingMode,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeckColumnsForBackup = `-- name: ListDeckColumnsForBackup :many
+SELECT dc.deck_id, dc.position,
+       s.youtube_id AS subscription_youtube_id,
+       vc.kind AS virtual_kind, vc.name AS virtual_name, g.name AS group_name
+FROM deck_columns dc
+LEFT JOIN subscriptions s ON s.id = dc.subscription_id
+LEFT JOIN virtual_columns vc ON vc.id = dc.virtual_column_id
+LEFT JOIN groups g ON g.id = vc.group_id
+ORDER BY dc.deck_id, dc.position, dc.id
+`
+
+type ListDeckColumnsForBackupRow struct {
+	DeckID                int64          `json:"deck_id"`
+	Position              int64          `json:"position"`
+	SubscriptionYoutubeID sql.NullString `json:"subscription_youtube_id"`
+	VirtualKind           sql.NullString `json:"virtual_kind"`
+	VirtualName           sql.NullString `json:"virtual_name"`
+	GroupName             sql.NullString `json:"group_name"`
+}
+
+// Every deck column with the subscription or virtual column it shows, named
+// by YouTube ID and by kind, name and folder so a backup doesn't depend on
+// row IDs.
+func (q *Queries) ListDeckColumnsForBackup(ctx context.Context) ([]ListDeckColumnsForBackupRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDeckColumnsForBackup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeckColumnsForBackupRow{}
+	for rows.Next() {
+		var i ListDeckColumnsForBackupRow
+		if err := rows.Scan(
+			&i.DeckID,
+			&i.Position,
+			&i.SubscriptionYoutubeID,
+			&i.VirtualKind,
+			&i.VirtualName,
+			&i.GroupName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeckSubscriptions = `-- name: ListDeckSubscriptions :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 1 THEN 1 END) as muted_count,
+       COUNT(CASE WHEN v.watched = 0 AND v.availability != 'available' THEN 1 END) as gone_count
+FROM deck_columns dc
+JOIN subscriptions s ON s.id = dc.subscription_id
+LEFT JOIN videos v ON v.subscription_id = s.id
+WHERE dc.deck_id = ?
+GROUP BY dc.id
+ORDER BY dc.position, dc.id
+`
+
+type ListDeckSubscriptionsRow struct {
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	MutedCount     int64        `json:"muted_count"`
+	GoneCount      int64        `json:"gone_count"`
+}
+
+func (q *Queries) ListDeckSubscriptions(ctx context.Context, deckID int64) ([]ListDeckSubscriptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDeckSubscriptions, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListDeckSubscriptionsRow{}
+	for rows.Next() {
+		var i ListDeckSubscriptionsRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
+			&i.UnwatchedCount,
+			&i.MutedCount,
+			&i.GoneCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDecks = `-- name: ListDecks :many
+SELECT id, name, position, created_at FROM decks ORDER BY position, id
+`
+
+func (q *Queries) ListDecks(ctx context.Context) ([]Deck, error) {
+	rows, err := q.db.QueryContext(ctx, listDecks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Deck{}
+	for rows.Next() {
+		var i Deck
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGoneVideos = `-- name: ListGoneVideos :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE subscription_id = ? AND watched = 0 AND availability != 'available'
+ORDER BY published_at DESC
+`
+
+// The unwatched videos of a subscription that can't be watched any more.
+func (q *Queries) ListGoneVideos(ctx context.Context, subscriptionID int64) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listGoneVideos, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Video{}
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
+			&i.CreatedAt,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroupSubscriptionsPaginated = `-- name: ListGroupSubscriptionsPaginated :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = ?1 AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+JOIN subscription_groups sg ON sg.subscription_id = s.id
+LEFT JOIN videos v ON v.subscription_id = s.id
+WHERE sg.group_id = ?2
+GROUP BY s.id
+ORDER BY s.position, s.name
+LIMIT ?4 OFFSET ?3
+`
+
+type ListGroupSubscriptionsPaginatedParams struct {
+	DeckID  int64 `json:"deck_id"`
+	GroupID int64 `json:"group_id"`
+	Offset  int64 `json:"offset"`
+	Limit   int64 `json:"limit"`
+}
+
+type ListGroupSubscriptionsPaginatedRow struct {
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	InDeck         int64        `json:"in_deck"`
+}
+
+func (q *Queries) ListGroupSubscriptionsPaginated(ctx context.Context, arg ListGroupSubscriptionsPaginatedParams) ([]ListGroupSubscriptionsPaginatedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGroupSubscriptionsPaginated,
+		arg.DeckID,
+		arg.GroupID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListGroupSubscriptionsPaginatedRow{}
+	for rows.Next() {
+		var i ListGroupSubscriptionsPaginatedRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
+			&i.UnwatchedCount,
+			&i.InDeck,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGroups = `-- name: ListGroups :many
+SELECT g.id, g.name, g.position, g.created_at, COUNT(sg.subscription_id) AS member_count
+FROM groups g
+LEFT JOIN subscription_groups sg ON sg.group_id = g.id
+GROUP BY g.id
+ORDER BY g.position, g.name
+`
+
+type ListGroupsRow struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Position    int64        `json:"position"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	MemberCount int64        `json:"member_count"`
+}
+
+func (q *Queries) ListGroups(ctx context.Context) ([]ListGroupsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listGroups)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListGroupsRow{}
+	for rows.Next() {
+		var i ListGroupsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Position,
+			&i.CreatedAt,
+			&i.MemberCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMuteRules = `-- name: ListMuteRules :many
+SELECT id, subscription_id, pattern, is_regex, "action", created_at FROM mute_rules
+WHERE subscription_id IS NULL OR subscription_id = ?
+ORDER BY subscription_id IS NOT NULL, id
+`
+
+// The rules that apply to a subscription, global ones first.
+func (q *Queries) ListMuteRules(ctx context.Context, subscriptionID sql.NullInt64) ([]MuteRule, error) {
+	rows, err := q.db.QueryContext(ctx, listMuteRules, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MuteRule{}
+	for rows.Next() {
+		var i MuteRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.Pattern,
+			&i.IsRegex,
+			&i.Action,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMuteRulesForBackup = `-- name: ListMuteRulesForBackup :many
+SELECT r.pattern, r.is_regex, r.action, s.youtube_id AS subscription_youtube_id
+FROM mute_rules r
+LEFT JOIN subscriptions s ON s.id = r.subscription_id
+ORDER BY r.id
+`
+
+type ListMuteRulesForBackupRow struct {
+	Pattern               string         `json:"pattern"`
+	IsRegex               int64          `json:"is_regex"`
+	Action                string         `json:"action"`
+	SubscriptionYoutubeID sql.NullString `json:"subscription_youtube_id"`
+}
+
+func (q *Queries) ListMuteRulesForBackup(ctx context.Context) ([]ListMuteRulesForBackupRow, error) {
+	rows, err := q.db.QueryContext(ctx, listMuteRulesForBackup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMuteRulesForBackupRow{}
+	for rows.Next() {
+		var i ListMuteRulesForBackupRow
+		if err := rows.Scan(
+			&i.Pattern,
+			&i.IsRegex,
+			&i.Action,
+			&i.SubscriptionYoutubeID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingBroadcasts = `-- name: ListPendingBroadcasts :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE subscription_id = ? AND broadcast_state IN ('upcoming', 'live')
+ORDER BY id
+`
+
+// Videos whose broadcast may have changed state since they were stored.
+func (q *Queries) ListPendingBroadcasts(ctx context.Context, subscriptionID int64) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingBroadcasts, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Video{}
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
+			&i.CreatedAt,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPlaylistSubscriptions = `-- name: ListPlaylistSubscriptions :many
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions WHERE type = 'playlist' ORDER BY id
+`
+
+func (q *Queries) ListPlaylistSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listPlaylistSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YoutubeID,
+			&i.Type,
+			&i.ThumbnailUrl,
+			&i.LastChecked,
+			&i.CreatedAt,
+			&i.Position,
+			&i.PageToken,
+			&i.HideShorts,
+			&i.RefreshInterval,
+			&i.UploadsPlaylistID,
+			&i.CustomUrl,
+			&i.SubscriberCount,
+			&i.MinDurationSeconds,
+			&i.MaxDurationSeconds,
+			&i.UpcomingMode,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQueue = `-- name: ListQueue :many
+SELECT videos.id, videos.subscription_id, videos.youtube_id, videos.title, videos.thumbnail_url, videos.duration, videos.published_at, videos.watched, videos.created_at, videos.is_short, videos.duration_seconds, videos.broadcast_state, videos.scheduled_start_at, videos.short_source, videos.short_checked_at, videos.short_attempts, videos.description, videos.view_count, videos.like_count, videos.comment_count, videos.stats_updated_at, videos.availability, videos.availability_checked_at, videos.playlist_position, videos.muted, subscriptions.name AS channel_name, subscriptions.thumbnail_url AS channel_thumbnail_url
+FROM queue
+JOIN videos ON videos.id = queue.video_id
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+ORDER BY queue.position, queue.added_at
+`
+
+type ListQueueRow struct {
+	Video               Video          `json:"video"`
+	ChannelName         string         `json:"channel_name"`
+	ChannelThumbnailUrl sql.NullString `json:"channel_thumbnail_url"`
+}
+
+func (q *Queries) ListQueue(ctx context.Context) ([]ListQueueRow, error) {
+	rows, err := q.db.QueryContext(ctx, listQueue)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListQueueRow{}
+	for rows.Next() {
+		var i ListQueueRow
+		if err := rows.Scan(
+			&i.Video.ID,
+			&i.Video.SubscriptionID,
+			&i.Video.YoutubeID,
+			&i.Video.Title,
+			&i.Video.ThumbnailUrl,
+			&i.Video.Duration,
+			&i.Video.PublishedAt,
+			&i.Video.Watched,
+			&i.Video.CreatedAt,
+			&i.Video.IsShort,
+			&i.Video.DurationSeconds,
+			&i.Video.BroadcastState,
+			&i.Video.ScheduledStartAt,
+			&i.Video.ShortSource,
+			&i.Video.ShortCheckedAt,
+			&i.Video.ShortAttempts,
+			&i.Video.Description,
+			&i.Video.ViewCount,
+			&i.Video.LikeCount,
+			&i.Video.CommentCount,
+			&i.Video.StatsUpdatedAt,
+			&i.Video.Availability,
+			&i.Video.AvailabilityCheckedAt,
+			&i.Video.PlaylistPosition,
+			&i.Video.Muted,
+			&i.ChannelName,
+			&i.ChannelThumbnailUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQueueForBackup = `-- name: ListQueueForBackup :many
+SELECT v.youtube_id, v.title, v.thumbnail_url, v.duration, v.duration_seconds, v.published_at,
+       s.youtube_id AS subscription_youtube_id
+FROM queue q
+JOIN videos v ON v.id = q.video_id
+JOIN subscriptions s ON s.id = v.subscription_id
+ORDER BY q.position, q.added_at
+`
+
+type ListQueueForBackupRow struct {
+	YoutubeID             string         `json:"youtube_id"`
+	Title                 string         `json:"title"`
+	ThumbnailUrl          sql.NullString `json:"thumbnail_url"`
+	Duration              sql.NullString `json:"duration"`
+	DurationSeconds       sql.NullInt64  `json:"duration_seconds"`
+	PublishedAt           sql.NullTime   `json:"published_at"`
+	SubscriptionYoutubeID string         `json:"subscription_youtube_id"`
+}
+
+func (q *Queries) ListQueueForBackup(ctx context.Context) ([]ListQueueForBackupRow, error) {
+	rows, err := q.db.QueryContext(ctx, listQueueForBackup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListQueueForBackupRow{}
+	for rows.Next() {
+		var i ListQueueForBackupRow
+		if err := rows.Scan(
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.DurationSeconds,
+			&i.PublishedAt,
+			&i.SubscriptionYoutubeID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listQuotaUsage = `-- name: ListQuotaUsage :many
+SELECT day, call, units, calls FROM quota_usage WHERE day = ? ORDER BY units DESC
+`
+
+func (q *Queries) ListQuotaUsage(ctx context.Context, day string) ([]QuotaUsage, error) {
+	rows, err := q.db.QueryContext(ctx, listQuotaUsage, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QuotaUsage{}
+	for rows.Next() {
+		var i QuotaUsage
+		if err := rows.Scan(
+			&i.Day,
+			&i.Call,
+			&i.Units,
+			&i.Calls,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRiverVideos = `-- name: ListRiverVideos :many
+SELECT videos.id, videos.subscription_id, videos.youtube_id, videos.title, videos.thumbnail_url, videos.duration, videos.published_at, videos.watched, videos.created_at, videos.is_short, videos.duration_seconds, videos.broadcast_state, videos.scheduled_start_at, videos.short_source, videos.short_checked_at, videos.short_attempts, videos.description, videos.view_count, videos.like_count, videos.comment_count, videos.stats_updated_at, videos.availability, videos.availability_checked_at, videos.playlist_position, videos.muted, subscriptions.name AS channel_name, subscriptions.thumbnail_url AS channel_thumbnail_url
+FROM videos
+JOIN subscriptions ON subscriptions.id = videos.subscription_id
+WHERE videos.watched = 0
+  AND videos.availability = 'available'
+  AND (COALESCE(subscriptions.hide_shorts, 0) = 0 OR COALESCE(videos.is_short, 0) = 0)
+  AND (subscriptions.upcoming_mode = 'show' OR videos.broadcast_state != 'upcoming')
+  AND (subscriptions.min_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds >= subscriptions.min_duration_seconds)
+  AND (subscriptions.max_duration_seconds IS NULL OR videos.duration_seconds IS NULL
+       OR videos.duration_seconds <= subscriptions.max_duration_seconds)
+  AND videos.muted = 0
+  AND videos.published_at >= ?1
+  AND (CAST(?2 AS INTEGER) = 0 OR videos.subscription_id IN (
+    SELECT subscription_id FROM subscription_groups WHERE group_id = ?2))
+  AND (videos.published_at < ?3
+       OR (videos.published_at = ?3 AND videos.id < ?4))
+ORDER BY videos.published_at DESC, videos.id DESC
+LIMIT ?5
+`
+
+type ListRiverVideosParams struct {
+	Since    sql.NullTime `json:"since"`
+	GroupID  int64        `json:"group_id"`
+	Before   sql.NullTime `json:"before"`
+	BeforeID int64        `json:"before_id"`
+	Limit    int64        `json:"limit"`
+}
+
+type ListRiverVideosRow struct {
+	Video               Video          `json:"video"`
+	ChannelName         string         `json:"channel_name"`
+	ChannelThumbnailUrl sql.NullString `json:"channel_thumbnail_url"`
+}
+
+func (q *Queries) ListRiverVideos(ctx context.Context, arg ListRiverVideosParams) ([]ListRiverVideosRow, error) {
+	rows, err := q.db.QueryContext(ctx, listRiverVideos,
+		arg.Since,
+		arg.GroupID,
+		arg.Before,
+		arg.BeforeID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListRiverVideosRow{}
+	for rows.Next() {
+		var i ListRiverVideosRow
+		if err := rows.Scan(
+			&i.Video.ID,
+			&i.Video.SubscriptionID,
+			&i.Video.YoutubeID,
+			&i.Video.Title,
+			&i.Video.ThumbnailUrl,
+			&i.Video.Duration,
+			&i.Video.PublishedAt,
+			&i.Video.Watched,
+			&i.Video.CreatedAt,
+			&i.Video.IsShort,
+			&i.Video.DurationSeconds,
+			&i.Video.BroadcastState,
+			&i.Video.ScheduledStartAt,
+			&i.Video.ShortSource,
+			&i.Video.ShortCheckedAt,
+			&i.Video.ShortAttempts,
+			&i.Video.Description,
+			&i.Video.ViewCount,
+			&i.Video.LikeCount,
+			&i.Video.CommentCount,
+			&i.Video.StatsUpdatedAt,
+			&i.Video.Availability,
+			&i.Video.AvailabilityCheckedAt,
+			&i.Video.PlaylistPosition,
+			&i.Video.Muted,
+			&i.ChannelName,
+			&i.ChannelThumbnailUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSettings = `-- name: ListSettings :many
+SELECT "key", value FROM settings ORDER BY key
+`
+
+func (q *Queries) ListSettings(ctx context.Context) ([]Setting, error) {
+	rows, err := q.db.QueryContext(ctx, listSettings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Setting{}
+	for rows.Next() {
+		var i Setting
+		if err := rows.Scan(&i.Key, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionGroupIDs = `-- name: ListSubscriptionGroupIDs :many
+SELECT group_id FROM subscription_groups WHERE subscription_id = ?
+`
+
+func (q *Queries) ListSubscriptionGroupIDs(ctx context.Context, subscriptionID int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionGroupIDs, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int64{}
+	for rows.Next() {
+		var group_id int64
+		if err := rows.Scan(&group_id); err != nil {
+			return nil, err
+		}
+		items = append(items, group_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionGroupNames = `-- name: ListSubscriptionGroupNames :many
+SELECT sg.subscription_id, g.name AS group_name
+FROM subscription_groups sg
+JOIN groups g ON g.id = sg.group_id
+ORDER BY g.position, g.name
+`
+
+type ListSubscriptionGroupNamesRow struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	GroupName      string `json:"group_name"`
+}
+
+func (q *Queries) ListSubscriptionGroupNames(ctx context.Context) ([]ListSubscriptionGroupNamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionGroupNames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSubscriptionGroupNamesRow{}
+	for rows.Next() {
+		var i ListSubscriptionGroupNamesRow
+		if err := rows.Scan(&i.SubscriptionID, &i.GroupName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionVideoIDs = `-- name: ListSubscriptionVideoIDs :many
+SELECT id, youtube_id, duration FROM videos WHERE subscription_id = ? ORDER BY id
+`
+
+type ListSubscriptionVideoIDsRow struct {
+	ID        int64          `json:"id"`
+	YoutubeID string         `json:"youtube_id"`
+	Duration  sql.NullString `json:"duration"`
+}
+
+func (q *Queries) ListSubscriptionVideoIDs(ctx context.Context, subscriptionID int64) ([]ListSubscriptionVideoIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionVideoIDs, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSubscriptionVideoIDsRow{}
+	for rows.Next() {
+		var i ListSubscriptionVideoIDsRow
+		if err := rows.Scan(&i.ID, &i.YoutubeID, &i.Duration); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptions = `-- name: ListSubscriptions :many
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions ORDER BY name
+`
+
+func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YoutubeID,
+			&i.Type,
+			&i.ThumbnailUrl,
+			&i.LastChecked,
+			&i.CreatedAt,
+			&i.Position,
+			&i.PageToken,
+			&i.HideShorts,
+			&i.RefreshInterval,
+			&i.UploadsPlaylistID,
+			&i.CustomUrl,
+			&i.SubscriberCount,
+			&i.MinDurationSeconds,
+			&i.MaxDurationSeconds,
+			&i.UpcomingMode,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsForRefresh = `-- name: ListSubscriptionsForRefresh :many
+SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, page_token, hide_shorts, refresh_interval, uploads_playlist_id, custom_url, subscriber_count, min_duration_seconds, max_duration_seconds, upcoming_mode, sort_order FROM subscriptions
+WHERE id IN (SELECT subscription_id FROM deck_columns WHERE subscription_id IS NOT NULL)
+   OR id IN (
+    SELECT sg.subscription_id FROM subscription_groups sg
+    JOIN virtual_columns vc ON vc.group_id = sg.group_id
+)
+ORDER BY last_checked IS NOT NULL, last_checked
+`
+
+// Subscriptions shown on any deck, directly or through a folder column.
+func (q *Queries) ListSubscriptionsForRefresh(ctx context.Context) ([]Subscription, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsForRefresh)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Subscription{}
+	for rows.Next() {
+		var i Subscription
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YoutubeID,
+			&i.Type,
+			&i.ThumbnailUrl,
+			&i.LastChecked,
+			&i.CreatedAt,
+			&i.Position,
+			&i.PageToken,
+			&i.HideShorts,
+			&i.RefreshInterval,
+			&i.UploadsPlaylistID,
+			&i.CustomUrl,
+			&i.SubscriberCount,
+			&i.MinDurationSeconds,
+			&i.MaxDurationSeconds,
+			&i.UpcomingMode,
+			&i.SortOrder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsPaginated = `-- name: ListSubscriptionsPaginated :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = ?1 AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+LEFT JOIN videos v ON v.subscription_id = s.id
+GROUP BY s.id
+ORDER BY s.position, s.name
+LIMIT ?3 OFFSET ?2
+`
+
+type ListSubscriptionsPaginatedParams struct {
+	DeckID int64 `json:"deck_id"`
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+type ListSubscriptionsPaginatedRow struct {
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	InDeck         int64        `json:"in_deck"`
+}
+
+func (q *Queries) ListSubscriptionsPaginated(ctx context.Context, arg ListSubscriptionsPaginatedParams) ([]ListSubscriptionsPaginatedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsPaginated, arg.DeckID, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSubscriptionsPaginatedRow{}
+	for rows.Next() {
+		var i ListSubscriptionsPaginatedRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
+			&i.UnwatchedCount,
+			&i.InDeck,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubscriptionsWithUnwatchedCount = `-- name: ListSubscriptionsWithUnwatchedCount :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count
+FROM subscriptions s
+LEFT JOIN videos v ON v.subscription_id = s.id
+GROUP BY s.id
+ORDER BY s.name
+`
+
+type ListSubscriptionsWithUnwatchedCountRow struct {
+	ID                 int64          `json:"id"`
+	Name               string         `json:"name"`
+	YoutubeID          string         `json:"youtube_id"`
+	Type               string         `json:"type"`
+	ThumbnailUrl       sql.NullString `json:"thumbnail_url"`
+	LastChecked        sql.NullTime   `json:"last_checked"`
+	CreatedAt          sql.NullTime   `json:"created_at"`
+	Position           sql.NullInt64  `json:"position"`
+	PageToken          sql.NullString `json:"page_token"`
+	HideShorts         sql.NullInt64  `json:"hide_shorts"`
+	RefreshInterval    sql.NullInt64  `json:"refresh_interval"`
+	UploadsPlaylistID  sql.NullString `json:"uploads_playlist_id"`
+	CustomUrl          sql.NullString `json:"custom_url"`
+	SubscriberCount    sql.NullInt64  `json:"subscriber_count"`
+	MinDurationSeconds sql.NullInt64  `json:"min_duration_seconds"`
+	MaxDurationSeconds sql.NullInt64  `json:"max_duration_seconds"`
+	UpcomingMode       string         `json:"upcoming_mode"`
+	SortOrder          string         `json:"sort_order"`
+	UnwatchedCount     int64          `json:"unwatched_count"`
+}
+
+func (q *Queries) ListSubscriptionsWithUnwatchedCount(ctx context.Context) ([]ListSubscriptionsWithUnwatchedCountRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSubscriptionsWithUnwatchedCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSubscriptionsWithUnwatchedCountRow{}
+	for rows.Next() {
+		var i ListSubscriptionsWithUnwatchedCountRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.YoutubeID,
+			&i.Type,
+			&i.ThumbnailUrl,
+			&i.LastChecked,
+			&i.CreatedAt,
+			&i.Position,
+			&i.PageToken,
+			&i.HideShorts,
+			&i.RefreshInterval,
+			&i.UploadsPlaylistID,
+			&i.CustomUrl,
+			&i.SubscriberCount,
+			&i.MinDurationSeconds,
+			&i.MaxDurationSeconds,
+			&i.UpcomingMode,
+			&i.SortOrder,
+			&i.UnwatchedCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSyncChanges = `-- name: ListSyncChanges :many
+SELECT c.id, c.kind, c.youtube_id, c.name, c.thumbnail_url, c.found_at, s.name AS current_name
+FROM sync_changes c
+LEFT JOIN subscriptions s ON s.youtube_id = c.youtube_id
+ORDER BY CASE c.kind WHEN 'add' THEN 0 WHEN 'remove' THEN 1 WHEN 'rename' THEN 2 ELSE 3 END, c.name COLLATE NOCASE
+`
+
+type ListSyncChangesRow struct {
+	ID           int64          `json:"id"`
+	Kind         string         `json:"kind"`
+	YoutubeID    string         `json:"youtube_id"`
+	Name         string         `json:"name"`
+	ThumbnailUrl sql.NullString `json:"thumbnail_url"`
+	FoundAt      sql.NullTime   `json:"found_at"`
+	CurrentName  sql.NullString `json:"current_name"`
+}
+
+// Pending changes with the subscription they apply to, if it's stored:
+// additions first, then removals, renames and avatars, each by name.
+func (q *Queries) ListSyncChanges(ctx context.Context) ([]ListSyncChangesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSyncChanges)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSyncChangesRow{}
+	for rows.Next() {
+		var i ListSyncChangesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.YoutubeID,
+			&i.Name,
+			&i.ThumbnailUrl,
+			&i.FoundAt,
+			&i.CurrentName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSyncRejections = `-- name: ListSyncRejections :many
+SELECT kind, youtube_id, value FROM sync_rejections
+`
+
+type ListSyncRejectionsRow struct {
+	Kind      string `json:"kind"`
+	YoutubeID string `json:"youtube_id"`
+	Value     string `json:"value"`
+}
+
+func (q *Queries) ListSyncRejections(ctx context.Context) ([]ListSyncRejectionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSyncRejections)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSyncRejectionsRow{}
+	for rows.Next() {
+		var i ListSyncRejectionsRow
+		if err := rows.Scan(&i.Kind, &i.YoutubeID, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUndismissedJobs = `-- name: ListUndismissedJobs :many
+SELECT id, kind, status, progress, total, result, error, dismissed, created_at, finished_at FROM jobs WHERE dismissed = 0 ORDER BY id
+`
+
+func (q *Queries) ListUndismissedJobs(ctx context.Context) ([]Job, error) {
+	rows, err := q.db.QueryContext(ctx, listUndismissedJobs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Job{}
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.Status,
+			&i.Progress,
+			&i.Total,
+			&i.Result,
+			&i.Error,
+			&i.Dismissed,
+			&i.CreatedAt,
+			&i.FinishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUngroupedSubscriptionsPaginated = `-- name: ListUngroupedSubscriptionsPaginated :many
+SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.page_token, s.hide_shorts, s.refresh_interval, s.uploads_playlist_id, s.custom_url, s.subscriber_count, s.min_duration_seconds, s.max_duration_seconds, s.upcoming_mode, s.sort_order, COUNT(CASE WHEN v.watched = 0 AND v.availability = 'available' AND v.muted = 0 THEN 1 END) as unwatched_count,
+       EXISTS(SELECT 1 FROM deck_columns dc WHERE dc.deck_id = ?1 AND dc.subscription_id = s.id) AS in_deck
+FROM subscriptions s
+LEFT JOIN videos v ON v.subscription_id = s.id
+WHERE NOT EXISTS(SELECT 1 FROM subscription_groups sg WHERE sg.subscription_id = s.id)
+GROUP BY s.id
+ORDER BY s.position, s.name
+LIMIT ?3 OFFSET ?2
+`
+
+type ListUngroupedSubscriptionsPaginatedParams struct {
+	DeckID int64 `json:"deck_id"`
+	Offset int64 `json:"offset"`
+	Limit  int64 `json:"limit"`
+}
+
+type ListUngroupedSubscriptionsPaginatedRow struct {
+	Subscription   Subscription `json:"subscription"`
+	UnwatchedCount int64        `json:"unwatched_count"`
+	InDeck         int64        `json:"in_deck"`
+}
+
+func (q *Queries) ListUngroupedSubscriptionsPaginated(ctx context.Context, arg ListUngroupedSubscriptionsPaginatedParams) ([]ListUngroupedSubscriptionsPaginatedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUngroupedSubscriptionsPaginated, arg.DeckID, arg.Offset, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUngroupedSubscriptionsPaginatedRow{}
+	for rows.Next() {
+		var i ListUngroupedSubscriptionsPaginatedRow
+		if err := rows.Scan(
+			&i.Subscription.ID,
+			&i.Subscription.Name,
+			&i.Subscription.YoutubeID,
+			&i.Subscription.Type,
+			&i.Subscription.ThumbnailUrl,
+			&i.Subscription.LastChecked,
+			&i.Subscription.CreatedAt,
+			&i.Subscription.Position,
+			&i.Subscription.PageToken,
+			&i.Subscription.HideShorts,
+			&i.Subscription.RefreshInterval,
+			&i.Subscription.UploadsPlaylistID,
+			&i.Subscription.CustomUrl,
+			&i.Subscription.SubscriberCount,
+			&i.Subscription.MinDurationSeconds,
+			&i.Subscription.MaxDurationSeconds,
+			&i.Subscription.UpcomingMode,
+			&i.Subscription.SortOrder,
+			&i.UnwatchedCount,
+			&i.InDeck,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnknownShorts = `-- name: ListUnknownShorts :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE is_short IS NULL
+  AND short_attempts < CAST(?1 AS INTEGER)
+  AND (short_checked_at IS NULL
+       OR short_checked_at <= datetime('now',
+            printf('-%d minutes', CAST(?2 AS INTEGER) << short_attempts)))
+ORDER BY short_checked_at, id
+LIMIT ?3
+`
+
+type ListUnknownShortsParams struct {
+	MaxAttempts    int64 `json:"max_attempts"`
+	BackoffMinutes int64 `json:"backoff_minutes"`
+	Limit          int64 `json:"limit"`
+}
+
+// Videos whose Shorts verdict is still unknown and that are due for another
+// probe. The wait after a failed probe doubles with every attempt, starting
+// from backoff_minutes; after max_attempts the video is given up on.
+func (q *Queries) ListUnknownShorts(ctx context.Context, arg ListUnknownShortsParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listUnknownShorts, arg.MaxAttempts, arg.BackoffMinutes, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Video{}
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
+			&i.CreatedAt,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnwatchedVideos = `-- name: ListUnwatchedVideos :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos WHERE subscription_id = ? AND watched = 0 ORDER BY published_at DESC
+`
+
+func (q *Queries) ListUnwatchedVideos(ctx context.Context, subscriptionID int64) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listUnwatchedVideos, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Video{}
+	for rows.Next() {
+		var i Video
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
+			&i.CreatedAt,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnwatchedVideosPaginated = `-- name: ListUnwatchedVideosPaginated :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE subscription_id = ? AND watched = 0
+ORDER BY published_at DESC
+LIMIT ? OFFSET ?
 `
 
-type ListActiveSubscriptionsRow struct {
-	ID             int64          `json:"id"`
-	Name           string         `json:"name"`
-	YoutubeID      string         `json:"youtube_id"`
-	Type           string         `json:"type"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	LastChecked    sql.NullTime   `json:"last_checked"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	Position       sql.NullInt64  `json:"position"`
-	Active         sql.NullInt64  `json:"active"`
-	PageToken      sql.NullString `json:"page_token"`
-	HideShorts     sql.NullInt64  `json:"hide_shorts"`
-	UnwatchedCount int64          `json:"unwatched_count"`
+type ListUnwatchedVideosPaginatedParams struct {
+	SubscriptionID int64 `json:"subscription_id"`
+	Limit          int64 `json:"limit"`
+	Offset         int64 `json:"offset"`
 }
 
-func (q *Queries) ListActiveSubscriptions(ctx context.Context) ([]ListActiveSubscriptionsRow, error) {
-	rows, err := q.db.QueryContext(ctx, listActiveSubscriptions)
+func (q *Queries) ListUnwatchedVideosPaginated(ctx context.Context, arg ListUnwatchedVideosPaginatedParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listUnwatchedVideosPaginated, arg.SubscriptionID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListActiveSubscriptionsRow{}
+	items := []Video{}
 	for rows.Next() {
-		var i ListActiveSubscriptionsRow
+		var i Video
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.SubscriptionID,
 			&i.YoutubeID,
-			&i.Type,
+			&i.Title,
 			&i.ThumbnailUrl,
-			&i.LastChecked,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
 			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
-			&i.UnwatchedCount,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -330,51 +2672,88 @@ func (q *Queries) ListActiveSubscriptions(ctx context.Context) ([]ListActiveSubs
 	return items, nil
 }
 
-const listAllSubscriptionsOrdered = `-- name: ListAllSubscriptionsOrdered :many
-SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.active, s.page_token, s.hide_shorts, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
-FROM subscriptions s
-LEFT JOIN videos v ON v.subscription_id = s.id
-GROUP BY s.id
-ORDER BY s.position, s.name
+const listUnwatchedVideosPaginatedFiltered = `-- name: ListUnwatchedVideosPaginatedFiltered :many
+WITH args AS (SELECT CAST(?9 AS TEXT) AS sort)
+SELECT videos.id, videos.subscription_id, videos.youtube_id, videos.title, videos.thumbnail_url, videos.duration, videos.published_at, videos.watched, videos.created_at, videos.is_short, videos.duration_seconds, videos.broadcast_state, videos.scheduled_start_at, videos.short_source, videos.short_checked_at, videos.short_attempts, videos.description, videos.view_count, videos.like_count, videos.comment_count, videos.stats_updated_at, videos.availability, videos.availability_checked_at, videos.playlist_position, videos.muted FROM videos, args
+WHERE subscription_id = ?1
+  AND watched = 0
+  AND availability = 'available'
+  AND (CAST(?2 AS INTEGER) = 0 OR COALESCE(is_short, 0) = 0)
+  AND (CAST(?3 AS INTEGER) = 0 OR broadcast_state != 'upcoming')
+  AND (CAST(?4 AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds >= CAST(?4 AS INTEGER))
+  AND (CAST(?5 AS INTEGER) IS NULL OR duration_seconds IS NULL
+       OR duration_seconds <= CAST(?5 AS INTEGER))
+  AND videos.muted = CAST(?6 AS INTEGER)
+ORDER BY CASE WHEN args.sort = 'popular' THEN view_count END DESC,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position IS NULL END,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position END,
+         CASE WHEN args.sort = 'oldest' THEN published_at END,
+         published_at DESC
+LIMIT ?8 OFFSET ?7
 `
 
-type ListAllSubscriptionsOrderedRow struct {
-	ID             int64          `json:"id"`
-	Name           string         `json:"name"`
-	YoutubeID      string         `json:"youtube_id"`
-	Type           string         `json:"type"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	LastChecked    sql.NullTime   `json:"last_checked"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	Position       sql.NullInt64  `json:"position"`
-	Active         sql.NullInt64  `json:"active"`
-	PageToken      sql.NullString `json:"page_token"`
-	HideShorts     sql.NullInt64  `json:"hide_shorts"`
-	UnwatchedCount int64          `json:"unwatched_count"`
+type ListUnwatchedVideosPaginatedFilteredParams struct {
+	SubscriptionID int64         `json:"subscription_id"`
+	HideShorts     int64         `json:"hide_shorts"`
+	HideUpcoming   int64         `json:"hide_upcoming"`
+	MinSeconds     sql.NullInt64 `json:"min_seconds"`
+	MaxSeconds     sql.NullInt64 `json:"max_seconds"`
+	Muted          int64         `json:"muted"`
+	Offset         int64         `json:"offset"`
+	Limit          int64         `json:"limit"`
+	Sort           string        `json:"sort"`
 }
 
-func (q *Queries) ListAllSubscriptionsOrdered(ctx context.Context) ([]ListAllSubscriptionsOrderedRow, error) {
-	rows, err := q.db.QueryContext(ctx, listAllSubscriptionsOrdered)
+// Videos whose length or Shorts verdict is not known yet pass the filters. muted
+// selects either the videos the mute rules hide or the ones they let through;
+// sort is one of the values of subscriptions.sort_order; playlist order puts
+// videos no longer in the playlist last.
+func (q *Queries) ListUnwatchedVideosPaginatedFiltered(ctx context.Context, arg ListUnwatchedVideosPaginatedFilteredParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listUnwatchedVideosPaginatedFiltered,
+		arg.SubscriptionID,
+		arg.HideShorts,
+		arg.HideUpcoming,
+		arg.MinSeconds,
+		arg.MaxSeconds,
+		arg.Muted,
+		arg.Offset,
+		arg.Limit,
+		arg.Sort,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListAllSubscriptionsOrderedRow{}
+	items := []Video{}
 	for rows.Next() {
-		var i ListAllSubscriptionsOrderedRow
+		var i Video
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.SubscriptionID,
 			&i.YoutubeID,
-			&i.Type,
+			&i.Title,
 			&i.ThumbnailUrl,
-			&i.LastChecked,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
 			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
-			&i.UnwatchedCount,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -389,31 +2768,53 @@ func (q *Queries) ListAllSubscriptionsOrdered(ctx context.Context) ([]ListAllSub
 	return items, nil
 }
 
-const listSubscriptions = `-- name: ListSubscriptions :many
-SELECT id, name, youtube_id, type, thumbnail_url, last_checked, created_at, position, active, page_token, hide_shorts FROM subscriptions ORDER BY name
+const listUpcomingVideos = `-- name: ListUpcomingVideos :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE subscription_id = ?
+  AND watched = 0
+  AND availability = 'available'
+  AND broadcast_state = 'upcoming'
+  AND videos.muted = 0
+ORDER BY scheduled_start_at, id
 `
 
-func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error) {
-	rows, err := q.db.QueryContext(ctx, listSubscriptions)
+// The upcoming broadcasts of a column that shows them separately, soonest
+// first.
+func (q *Queries) ListUpcomingVideos(ctx context.Context, subscriptionID int64) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listUpcomingVideos, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Subscription{}
+	items := []Video{}
 	for rows.Next() {
-		var i Subscription
+		var i Video
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.SubscriptionID,
 			&i.YoutubeID,
-			&i.Type,
+			&i.Title,
 			&i.ThumbnailUrl,
-			&i.LastChecked,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
 			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -428,57 +2829,35 @@ func (q *Queries) ListSubscriptions(ctx context.Context) ([]Subscription, error)
 	return items, nil
 }
 
-const listSubscriptionsPaginated = `-- name: ListSubscriptionsPaginated :many
-SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.active, s.page_token, s.hide_shorts, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
-FROM subscriptions s
-LEFT JOIN videos v ON v.subscription_id = s.id
-GROUP BY s.id
-ORDER BY s.position, s.name
-LIMIT ? OFFSET ?
+const listVideoTitles = `-- name: ListVideoTitles :many
+SELECT id, subscription_id, title, muted FROM videos
+WHERE ?1 IS NULL OR subscription_id = ?1
+ORDER BY id
 `
 
-type ListSubscriptionsPaginatedParams struct {
-	Limit  int64 `json:"limit"`
-	Offset int64 `json:"offset"`
-}
-
-type ListSubscriptionsPaginatedRow struct {
-	ID             int64          `json:"id"`
-	Name           string         `json:"name"`
-	YoutubeID      string         `json:"youtube_id"`
-	Type           string         `json:"type"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	LastChecked    sql.NullTime   `json:"last_checked"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	Position       sql.NullInt64  `json:"position"`
-	Active         sql.NullInt64  `json:"active"`
-	PageToken      sql.NullString `json:"page_token"`
-	HideShorts     sql.NullInt64  `json:"hide_shorts"`
-	UnwatchedCount int64          `json:"unwatched_count"`
+type ListVideoTitlesRow struct {
+	ID             int64  `json:"id"`
+	SubscriptionID int64  `json:"subscription_id"`
+	Title          string `json:"title"`
+	Muted          int64  `json:"muted"`
 }
 
-func (q *Queries) ListSubscriptionsPaginated(ctx context.Context, arg ListSubscriptionsPaginatedParams) ([]ListSubscriptionsPaginatedRow, error) {
-	rows, err := q.db.QueryContext(ctx, listSubscriptionsPaginated, arg.Limit, arg.Offset)
+// The videos of a subscription, or every video when subscription_id is
+// NULL, for the mute rules to go through.
+func (q *Queries) ListVideoTitles(ctx context.Context, subscriptionID interface{}) ([]ListVideoTitlesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVideoTitles, subscriptionID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListSubscriptionsPaginatedRow{}
+	items := []ListVideoTitlesRow{}
 	for rows.Next() {
-		var i ListSubscriptionsPaginatedRow
+		var i ListVideoTitlesRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
-			&i.YoutubeID,
-			&i.Type,
-			&i.ThumbnailUrl,
-			&i.LastChecked,
-			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
-			&i.UnwatchedCount,
+			&i.SubscriptionID,
+			&i.Title,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -493,51 +2872,59 @@ func (q *Queries) ListSubscriptionsPaginated(ctx context.Context, arg ListSubscr
 	return items, nil
 }
 
-const listSubscriptionsWithUnwatchedCount = `-- name: ListSubscriptionsWithUnwatchedCount :many
-SELECT s.id, s.name, s.youtube_id, s.type, s.thumbnail_url, s.last_checked, s.created_at, s.position, s.active, s.page_token, s.hide_shorts, COUNT(CASE WHEN v.watched = 0 THEN 1 END) as unwatched_count
-FROM subscriptions s
-LEFT JOIN videos v ON v.subscription_id = s.id
-GROUP BY s.id
-ORDER BY s.name
+const listVideos = `-- name: ListVideos :many
+WITH args AS (SELECT CAST(?2 AS TEXT) AS sort)
+SELECT videos.id, videos.subscription_id, videos.youtube_id, videos.title, videos.thumbnail_url, videos.duration, videos.published_at, videos.watched, videos.created_at, videos.is_short, videos.duration_seconds, videos.broadcast_state, videos.scheduled_start_at, videos.short_source, videos.short_checked_at, videos.short_attempts, videos.description, videos.view_count, videos.like_count, videos.comment_count, videos.stats_updated_at, videos.availability, videos.availability_checked_at, videos.playlist_position, videos.muted FROM videos, args
+WHERE subscription_id = ?1
+ORDER BY CASE WHEN args.sort = 'popular' THEN view_count END DESC,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position IS NULL END,
+         CASE WHEN args.sort = 'playlist' THEN playlist_position END,
+         CASE WHEN args.sort = 'oldest' THEN published_at END,
+         published_at DESC
 `
 
-type ListSubscriptionsWithUnwatchedCountRow struct {
-	ID             int64          `json:"id"`
-	Name           string         `json:"name"`
-	YoutubeID      string         `json:"youtube_id"`
-	Type           string         `json:"type"`
-	ThumbnailUrl   sql.NullString `json:"thumbnail_url"`
-	LastChecked    sql.NullTime   `json:"last_checked"`
-	CreatedAt      sql.NullTime   `json:"created_at"`
-	Position       sql.NullInt64  `json:"position"`
-	Active         sql.NullInt64  `json:"active"`
-	PageToken      sql.NullString `json:"page_token"`
-	HideShorts     sql.NullInt64  `json:"hide_shorts"`
-	UnwatchedCount int64          `json:"unwatched_count"`
+type ListVideosParams struct {
+	SubscriptionID int64  `json:"subscription_id"`
+	Sort           string `json:"sort"`
 }
 
-func (q *Queries) ListSubscriptionsWithUnwatchedCount(ctx context.Context) ([]ListSubscriptionsWithUnwatchedCountRow, error) {
-	rows, err := q.db.QueryContext(ctx, listSubscriptionsWithUnwatchedCount)
+// sort is one of the values of subscriptions.sort_order; playlist order puts
+// videos no longer in the playlist last.
+func (q *Queries) ListVideos(ctx context.Context, arg ListVideosParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listVideos, arg.SubscriptionID, arg.Sort)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListSubscriptionsWithUnwatchedCountRow{}
+	items := []Video{}
 	for rows.Next() {
-		var i ListSubscriptionsWithUnwatchedCountRow
+		var i Video
 		if err := rows.Scan(
 			&i.ID,
-			&i.Name,
+			&i.SubscriptionID,
 			&i.YoutubeID,
-			&i.Type,
+			&i.Title,
 			&i.ThumbnailUrl,
-			&i.LastChecked,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
 			&i.CreatedAt,
-			&i.Position,
-			&i.Active,
-			&i.PageToken,
-			&i.HideShorts,
-			&i.UnwatchedCount,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -552,12 +2939,73 @@ func (q *Queries) ListSubscriptionsWithUnwatchedCount(ctx context.Context) ([]Li
 	return items, nil
 }
 
-const listUnwatchedVideos = `-- name: ListUnwatchedVideos :many
-SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short FROM videos WHERE subscription_id = ? AND watched = 0 ORDER BY published_at DESC
+const listVideosForAvailabilityCheck = `-- name: ListVideosForAvailabilityCheck :many
+SELECT id, youtube_id, availability FROM videos
+WHERE watched = 0
+  AND broadcast_state != 'upcoming'
+  AND (availability_checked_at IS NULL
+       OR availability_checked_at <= datetime('now', printf('-%d minutes', CAST(?1 AS INTEGER))))
+ORDER BY availability_checked_at IS NOT NULL, availability_checked_at, id
+LIMIT ?2
 `
 
-func (q *Queries) ListUnwatchedVideos(ctx context.Context, subscriptionID int64) ([]Video, error) {
-	rows, err := q.db.QueryContext(ctx, listUnwatchedVideos, subscriptionID)
+type ListVideosForAvailabilityCheckParams struct {
+	StaleMinutes int64 `json:"stale_minutes"`
+	Limit        int64 `json:"limit"`
+}
+
+type ListVideosForAvailabilityCheckRow struct {
+	ID           int64  `json:"id"`
+	YoutubeID    string `json:"youtube_id"`
+	Availability string `json:"availability"`
+}
+
+// Unwatched videos not checked for stale_minutes, least recently checked
+// first. Unavailable ones are included so that they come back if restored.
+func (q *Queries) ListVideosForAvailabilityCheck(ctx context.Context, arg ListVideosForAvailabilityCheckParams) ([]ListVideosForAvailabilityCheckRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVideosForAvailabilityCheck, arg.StaleMinutes, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListVideosForAvailabilityCheckRow{}
+	for rows.Next() {
+		var i ListVideosForAvailabilityCheckRow
+		if err := rows.Scan(&i.ID, &i.YoutubeID, &i.Availability); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVideosForStatsRefresh = `-- name: ListVideosForStatsRefresh :many
+SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted FROM videos
+WHERE published_at >= ?1
+  AND broadcast_state != 'upcoming'
+  AND availability = 'available'
+  AND (stats_updated_at IS NULL
+       OR stats_updated_at <= datetime('now', printf('-%d minutes', CAST(?2 AS INTEGER))))
+ORDER BY stats_updated_at IS NOT NULL, stats_updated_at, id
+LIMIT ?3
+`
+
+type ListVideosForStatsRefreshParams struct {
+	PublishedSince sql.NullTime `json:"published_since"`
+	StaleMinutes   int64        `json:"stale_minutes"`
+	Limit          int64        `json:"limit"`
+}
+
+// Videos published since published_since whose statistics are older than
+// stale_minutes, least recently updated first.
+func (q *Queries) ListVideosForStatsRefresh(ctx context.Context, arg ListVideosForStatsRefreshParams) ([]Video, error) {
+	rows, err := q.db.QueryContext(ctx, listVideosForStatsRefresh, arg.PublishedSince, arg.StaleMinutes, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
@@ -576,6 +3024,21 @@ func (q *Queries) ListUnwatchedVideos(ctx context.Context, subscriptionID int64)
 			&i.Watched,
 			&i.CreatedAt,
 			&i.IsShort,
+			&i.DurationSeconds,
+			&i.BroadcastState,
+			&i.ScheduledStartAt,
+			&i.ShortSource,
+			&i.ShortCheckedAt,
+			&i.ShortAttempts,
+			&i.Description,
+			&i.ViewCount,
+			&i.LikeCount,
+			&i.CommentCount,
+			&i.StatsUpdatedAt,
+			&i.Availability,
+			&i.AvailabilityCheckedAt,
+			&i.PlaylistPosition,
+			&i.Muted,
 		); err != nil {
 			return nil, err
 		}
@@ -590,43 +3053,129 @@ func (q *Queries) ListUnwatchedVideos(ctx context.Context, subscriptionID int64)
 	return items, nil
 }
 
-const listUnwatchedVideosPaginated = `-- name: ListUnwatchedVideosPaginated :many
-SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short FROM videos
-WHERE subscription_id = ? AND watched = 0
-ORDER BY published_at DESC
-LIMIT ? OFFSET ?
+const listVideosMissingDurationSeconds = `-- name: ListVideosMissingDurationSeconds :many
+SELECT id, duration FROM videos
+WHERE id > ?1
+  AND duration IS NOT NULL AND duration != ''
+  AND duration_seconds IS NULL
+ORDER BY id
+LIMIT ?2
 `
 
-type ListUnwatchedVideosPaginatedParams struct {
-	SubscriptionID int64 `json:"subscription_id"`
-	Limit          int64 `json:"limit"`
-	Offset         int64 `json:"offset"`
+type ListVideosMissingDurationSecondsParams struct {
+	AfterID int64 `json:"after_id"`
+	Limit   int64 `json:"limit"`
 }
 
-func (q *Queries) ListUnwatchedVideosPaginated(ctx context.Context, arg ListUnwatchedVideosPaginatedParams) ([]Video, error) {
-	rows, err := q.db.QueryContext(ctx, listUnwatchedVideosPaginated, arg.SubscriptionID, arg.Limit, arg.Offset)
+type ListVideosMissingDurationSecondsRow struct {
+	ID       int64          `json:"id"`
+	Duration sql.NullString `json:"duration"`
+}
+
+func (q *Queries) ListVideosMissingDurationSeconds(ctx context.Context, arg ListVideosMissingDurationSecondsParams) ([]ListVideosMissingDurationSecondsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listVideosMissingDurationSeconds, arg.AfterID, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Video{}
+	items := []ListVideosMissingDurationSecondsRow{}
 	for rows.Next() {
-		var i Video
+		var i ListVideosMissingDurationSecondsRow
+		if err := rows.Scan(&i.ID, &i.Duration); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVirtualColumns = `-- name: ListVirtualColumns :many
+SELECT vc.id, vc.kind, vc.name, vc.position, vc.created_at, vc.group_id FROM virtual_columns vc
+JOIN deck_columns dc ON dc.virtual_column_id = vc.id
+WHERE dc.deck_id = ?
+ORDER BY dc.position, vc.id
+`
+
+func (q *Queries) ListVirtualColumns(ctx context.Context, deckID int64) ([]VirtualColumn, error) {
+	rows, err := q.db.QueryContext(ctx, listVirtualColumns, deckID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []VirtualColumn{}
+	for rows.Next() {
+		var i VirtualColumn
 		if err := rows.Scan(
 			&i.ID,
-			&i.SubscriptionID,
-			&i.YoutubeID,
-			&i.Title,
-			&i.ThumbnailUrl,
-			&i.Duration,
-			&i.PublishedAt,
-			&i.Watched,
+			&i.Kind,
+			&i.Name,
+			&i.Position,
 			&i.CreatedAt,
-			&i.IsShort,
+			&i.GroupID,
 		); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchHistory = `-- name: ListWatchHistory :many
+SELECT youtube_id, watched_at FROM watch_history ORDER BY youtube_id
+`
+
+func (q *Queries) ListWatchHistory(ctx context.Context) ([]WatchHistory, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchHistory)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WatchHistory{}
+	for rows.Next() {
+		var i WatchHistory
+		if err := rows.Scan(&i.YoutubeID, &i.WatchedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchedVideoIDs = `-- name: ListWatchedVideoIDs :many
+SELECT youtube_id FROM videos WHERE watched = 1 ORDER BY youtube_id
+`
+
+func (q *Queries) ListWatchedVideoIDs(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchedVideoIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var youtube_id string
+		if err := rows.Scan(&youtube_id); err != nil {
+			return nil, err
+		}
+		items = append(items, youtube_id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -637,47 +3186,46 @@ func (q *Queries) ListUnwatchedVideosPaginated(ctx context.Context, arg ListUnwa
 	return items, nil
 }
 
-const listUnwatchedVideosPaginatedFiltered = `-- name: ListUnwatchedVideosPaginatedFiltered :many
-SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short FROM videos
-WHERE subscription_id = ?
-  AND watched = 0
-  AND (? = 0 OR is_short = 0)
-ORDER BY published_at DESC
-LIMIT ? OFFSET ?
+const listWatchedVideosForExport = `-- name: ListWatchedVideosForExport :many
+SELECT v.youtube_id, v.title, v.duration_seconds, v.published_at,
+       s.name AS subscription_name, s.type AS subscription_type, s.youtube_id AS subscription_youtube_id,
+       h.watched_at
+FROM videos v
+JOIN subscriptions s ON s.id = v.subscription_id
+LEFT JOIN watch_history h ON h.youtube_id = v.youtube_id
+WHERE v.watched = 1
+ORDER BY COALESCE(h.watched_at, v.published_at) DESC
 `
 
-type ListUnwatchedVideosPaginatedFilteredParams struct {
-	SubscriptionID int64       `json:"subscription_id"`
-	Column2        interface{} `json:"column_2"`
-	Limit          int64       `json:"limit"`
-	Offset         int64       `json:"offset"`
+type ListWatchedVideosForExportRow struct {
+	YoutubeID             string        `json:"youtube_id"`
+	Title                 string        `json:"title"`
+	DurationSeconds       sql.NullInt64 `json:"duration_seconds"`
+	PublishedAt           sql.NullTime  `json:"published_at"`
+	SubscriptionName      string        `json:"subscription_name"`
+	SubscriptionType      string        `json:"subscription_type"`
+	SubscriptionYoutubeID string        `json:"subscription_youtube_id"`
+	WatchedAt             sql.NullTime  `json:"watched_at"`
 }
 
-func (q *Queries) ListUnwatchedVideosPaginatedFiltered(ctx context.Context, arg ListUnwatchedVideosPaginatedFilteredParams) ([]Video, error) {
-	rows, err := q.db.QueryContext(ctx, listUnwatchedVideosPaginatedFiltered,
-		arg.SubscriptionID,
-		arg.Column2,
-		arg.Limit,
-		arg.Offset,
-	)
+func (q *Queries) ListWatchedVideosForExport(ctx context.Context) ([]ListWatchedVideosForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchedVideosForExport)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Video{}
+	items := []ListWatchedVideosForExportRow{}
 	for rows.Next() {
-		var i Video
+		var i ListWatchedVideosForExportRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.SubscriptionID,
 			&i.YoutubeID,
 			&i.Title,
-			&i.ThumbnailUrl,
-			&i.Duration,
+			&i.DurationSeconds,
 			&i.PublishedAt,
-			&i.Watched,
-			&i.CreatedAt,
-			&i.IsShort,
+			&i.SubscriptionName,
+			&i.SubscriptionType,
+			&i.SubscriptionYoutubeID,
+			&i.WatchedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -692,34 +3240,36 @@ func (q *Queries) ListUnwatchedVideosPaginatedFiltered(ctx context.Context, arg
 	return items, nil
 }
 
-const listVideos = `-- name: ListVideos :many
-SELECT id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short FROM videos WHERE subscription_id = ? ORDER BY published_at DESC
+const markNewVideosWatchedFromHistory = `-- name: MarkNewVideosWatchedFromHistory :many
+UPDATE videos SET watched = 1
+WHERE id IN (/*SLICE:ids*/?) AND watched = 0
+  AND youtube_id IN (SELECT youtube_id FROM watch_history)
+RETURNING id
 `
 
-func (q *Queries) ListVideos(ctx context.Context, subscriptionID int64) ([]Video, error) {
-	rows, err := q.db.QueryContext(ctx, listVideos, subscriptionID)
+func (q *Queries) MarkNewVideosWatchedFromHistory(ctx context.Context, ids []int64) ([]int64, error) {
+	query := markNewVideosWatchedFromHistory
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []Video{}
+	items := []int64{}
 	for rows.Next() {
-		var i Video
-		if err := rows.Scan(
-			&i.ID,
-			&i.SubscriptionID,
-			&i.YoutubeID,
-			&i.Title,
-			&i.ThumbnailUrl,
-			&i.Duration,
-			&i.PublishedAt,
-			&i.Watched,
-			&i.CreatedAt,
-			&i.IsShort,
-		); err != nil {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, id)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -739,6 +3289,19 @@ func (q *Queries) MarkUnwatched(ctx context.Context, id int64) error {
 	return err
 }
 
+const markWatchHistoryWatched = `-- name: MarkWatchHistoryWatched :execrows
+UPDATE videos SET watched = 1
+WHERE watched = 0 AND youtube_id IN (SELECT youtube_id FROM watch_history)
+`
+
+func (q *Queries) MarkWatchHistoryWatched(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, markWatchHistoryWatched)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const markWatched = `-- name: MarkWatched :exec
 UPDATE videos SET watched = 1 WHERE id = ?
 `
@@ -748,9 +3311,130 @@ func (q *Queries) MarkWatched(ctx context.Context, id int64) error {
 	return err
 }
 
+const removeDeckSubscription = `-- name: RemoveDeckSubscription :exec
+DELETE FROM deck_columns WHERE deck_id = ? AND subscription_id = ?
+`
+
+type RemoveDeckSubscriptionParams struct {
+	DeckID         int64         `json:"deck_id"`
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+}
+
+func (q *Queries) RemoveDeckSubscription(ctx context.Context, arg RemoveDeckSubscriptionParams) error {
+	_, err := q.db.ExecContext(ctx, removeDeckSubscription, arg.DeckID, arg.SubscriptionID)
+	return err
+}
+
+const resetSubscriptionShorts = `-- name: ResetSubscriptionShorts :exec
+UPDATE videos
+SET is_short = NULL, short_source = NULL, short_checked_at = NULL, short_attempts = 0
+WHERE subscription_id = ?
+`
+
+// Forgets the Shorts verdicts of a subscription's videos before they are
+// classified again.
+func (q *Queries) ResetSubscriptionShorts(ctx context.Context, subscriptionID int64) error {
+	_, err := q.db.ExecContext(ctx, resetSubscriptionShorts, subscriptionID)
+	return err
+}
+
+const restoreSubscription = `-- name: RestoreSubscription :one
+INSERT INTO subscriptions (name, youtube_id, type, thumbnail_url, position, hide_shorts, refresh_interval,
+                           uploads_playlist_id, custom_url, min_duration_seconds, max_duration_seconds,
+                           upcoming_mode, sort_order)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type RestoreSubscriptionParams struct {
+	Name               string         `json:"name"`
+	YoutubeID          string         `json:"youtube_id"`
+	Type               string         `json:"type"`
+	ThumbnailUrl       sql.NullString `json:"thumbnail_url"`
+	Position           sql.NullInt64  `json:"position"`
+	HideShorts         sql.NullInt64  `json:"hide_shorts"`
+	RefreshInterval    sql.NullInt64  `json:"refresh_interval"`
+	UploadsPlaylistID  sql.NullString `json:"uploads_playlist_id"`
+	CustomUrl          sql.NullString `json:"custom_url"`
+	MinDurationSeconds sql.NullInt64  `json:"min_duration_seconds"`
+	MaxDurationSeconds sql.NullInt64  `json:"max_duration_seconds"`
+	UpcomingMode       string         `json:"upcoming_mode"`
+	SortOrder          string         `json:"sort_order"`
+}
+
+func (q *Queries) RestoreSubscription(ctx context.Context, arg RestoreSubscriptionParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, restoreSubscription,
+		arg.Name,
+		arg.YoutubeID,
+		arg.Type,
+		arg.ThumbnailUrl,
+		arg.Position,
+		arg.HideShorts,
+		arg.RefreshInterval,
+		arg.UploadsPlaylistID,
+		arg.CustomUrl,
+		arg.MinDurationSeconds,
+		arg.MaxDurationSeconds,
+		arg.UpcomingMode,
+		arg.SortOrder,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const restoreVideo = `-- name: RestoreVideo :one
+INSERT INTO videos (subscription_id, youtube_id, title, thumbnail_url, duration, duration_seconds, published_at, is_short)
+VALUES (?, ?, ?, ?, ?, ?, ?, NULL)
+ON CONFLICT(youtube_id) DO UPDATE SET youtube_id = excluded.youtube_id
+RETURNING id
+`
+
+type RestoreVideoParams struct {
+	SubscriptionID  int64          `json:"subscription_id"`
+	YoutubeID       string         `json:"youtube_id"`
+	Title           string         `json:"title"`
+	ThumbnailUrl    sql.NullString `json:"thumbnail_url"`
+	Duration        sql.NullString `json:"duration"`
+	DurationSeconds sql.NullInt64  `json:"duration_seconds"`
+	PublishedAt     sql.NullTime   `json:"published_at"`
+}
+
+// Stores a queued video of a backup unless it's stored already. Its Shorts
+// verdict is left unknown, to be probed like any new video's.
+func (q *Queries) RestoreVideo(ctx context.Context, arg RestoreVideoParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, restoreVideo,
+		arg.SubscriptionID,
+		arg.YoutubeID,
+		arg.Title,
+		arg.ThumbnailUrl,
+		arg.Duration,
+		arg.DurationSeconds,
+		arg.PublishedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const setSetting = `-- name: SetSetting :exec
+INSERT INTO settings (key, value) VALUES (?, ?)
+ON CONFLICT(key) DO UPDATE SET value = excluded.value
+`
+
+type SetSettingParams struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (q *Queries) SetSetting(ctx context.Context, arg SetSettingParams) error {
+	_, err := q.db.ExecContext(ctx, setSetting, arg.Key, arg.Value)
+	return err
+}
+
 const toggleWatched = `-- name: ToggleWatched :one
 UPDATE videos SET watched = NOT watched WHERE id = ?
-RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
 `
 
 func (q *Queries) ToggleWatched(ctx context.Context, id int64) (Video, error) {
@@ -767,21 +3451,89 @@ func (q *Queries) ToggleWatched(ctx context.Context, id int64) (Video, error) {
 		&i.Watched,
 		&i.CreatedAt,
 		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
 	)
 	return i, err
 }
 
-const updateSubscriptionActive = `-- name: UpdateSubscriptionActive :exec
-UPDATE subscriptions SET active = ? WHERE id = ?
+const updateDeckSubscriptionPosition = `-- name: UpdateDeckSubscriptionPosition :exec
+UPDATE deck_columns SET position = ? WHERE deck_id = ? AND subscription_id = ?
+`
+
+type UpdateDeckSubscriptionPositionParams struct {
+	Position       int64         `json:"position"`
+	DeckID         int64         `json:"deck_id"`
+	SubscriptionID sql.NullInt64 `json:"subscription_id"`
+}
+
+func (q *Queries) UpdateDeckSubscriptionPosition(ctx context.Context, arg UpdateDeckSubscriptionPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateDeckSubscriptionPosition, arg.Position, arg.DeckID, arg.SubscriptionID)
+	return err
+}
+
+const updateJobProgress = `-- name: UpdateJobProgress :exec
+UPDATE jobs SET progress = ?, total = ? WHERE id = ? AND status = 'running'
+`
+
+type UpdateJobProgressParams struct {
+	Progress int64 `json:"progress"`
+	Total    int64 `json:"total"`
+	ID       int64 `json:"id"`
+}
+
+func (q *Queries) UpdateJobProgress(ctx context.Context, arg UpdateJobProgressParams) error {
+	_, err := q.db.ExecContext(ctx, updateJobProgress, arg.Progress, arg.Total, arg.ID)
+	return err
+}
+
+const updateQueuePosition = `-- name: UpdateQueuePosition :exec
+UPDATE queue SET position = ? WHERE video_id = ?
+`
+
+type UpdateQueuePositionParams struct {
+	Position int64 `json:"position"`
+	VideoID  int64 `json:"video_id"`
+}
+
+func (q *Queries) UpdateQueuePosition(ctx context.Context, arg UpdateQueuePositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateQueuePosition, arg.Position, arg.VideoID)
+	return err
+}
+
+const updateSubscriptionChannelInfo = `-- name: UpdateSubscriptionChannelInfo :exec
+UPDATE subscriptions
+SET uploads_playlist_id = ?, custom_url = ?, subscriber_count = ?
+WHERE id = ?
 `
 
-type UpdateSubscriptionActiveParams struct {
-	Active sql.NullInt64 `json:"active"`
-	ID     int64         `json:"id"`
+type UpdateSubscriptionChannelInfoParams struct {
+	UploadsPlaylistID sql.NullString `json:"uploads_playlist_id"`
+	CustomUrl         sql.NullString `json:"custom_url"`
+	SubscriberCount   sql.NullInt64  `json:"subscriber_count"`
+	ID                int64          `json:"id"`
 }
 
-func (q *Queries) UpdateSubscriptionActive(ctx context.Context, arg UpdateSubscriptionActiveParams) error {
-	_, err := q.db.ExecContext(ctx, updateSubscriptionActive, arg.Active, arg.ID)
+func (q *Queries) UpdateSubscriptionChannelInfo(ctx context.Context, arg UpdateSubscriptionChannelInfoParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionChannelInfo,
+		arg.UploadsPlaylistID,
+		arg.CustomUrl,
+		arg.SubscriberCount,
+		arg.ID,
+	)
 	return err
 }
 
@@ -794,6 +3546,21 @@ func (q *Queries) UpdateSubscriptionChecked(ctx context.Context, id int64) error
 	return err
 }
 
+const updateSubscriptionDurationFilter = `-- name: UpdateSubscriptionDurationFilter :exec
+UPDATE subscriptions SET min_duration_seconds = ?, max_duration_seconds = ? WHERE id = ?
+`
+
+type UpdateSubscriptionDurationFilterParams struct {
+	MinDurationSeconds sql.NullInt64 `json:"min_duration_seconds"`
+	MaxDurationSeconds sql.NullInt64 `json:"max_duration_seconds"`
+	ID                 int64         `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionDurationFilter(ctx context.Context, arg UpdateSubscriptionDurationFilterParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionDurationFilter, arg.MinDurationSeconds, arg.MaxDurationSeconds, arg.ID)
+	return err
+}
+
 const updateSubscriptionHideShorts = `-- name: UpdateSubscriptionHideShorts :exec
 UPDATE subscriptions SET hide_shorts = ? WHERE id = ?
 `
@@ -808,6 +3575,20 @@ func (q *Queries) UpdateSubscriptionHideShorts(ctx context.Context, arg UpdateSu
 	return err
 }
 
+const updateSubscriptionName = `-- name: UpdateSubscriptionName :exec
+UPDATE subscriptions SET name = ? WHERE id = ?
+`
+
+type UpdateSubscriptionNameParams struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionName(ctx context.Context, arg UpdateSubscriptionNameParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionName, arg.Name, arg.ID)
+	return err
+}
+
 const updateSubscriptionPageToken = `-- name: UpdateSubscriptionPageToken :exec
 UPDATE subscriptions SET page_token = ? WHERE id = ?
 `
@@ -836,20 +3617,306 @@ func (q *Queries) UpdateSubscriptionPosition(ctx context.Context, arg UpdateSubs
 	return err
 }
 
-const updateVideoIsShort = `-- name: UpdateVideoIsShort :exec
-UPDATE videos SET is_short = ? WHERE id = ?
+const updateSubscriptionRefreshInterval = `-- name: UpdateSubscriptionRefreshInterval :exec
+UPDATE subscriptions SET refresh_interval = ? WHERE id = ?
+`
+
+type UpdateSubscriptionRefreshIntervalParams struct {
+	RefreshInterval sql.NullInt64 `json:"refresh_interval"`
+	ID              int64         `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionRefreshInterval(ctx context.Context, arg UpdateSubscriptionRefreshIntervalParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionRefreshInterval, arg.RefreshInterval, arg.ID)
+	return err
+}
+
+const updateSubscriptionSortOrder = `-- name: UpdateSubscriptionSortOrder :exec
+UPDATE subscriptions SET sort_order = ? WHERE id = ?
+`
+
+type UpdateSubscriptionSortOrderParams struct {
+	SortOrder string `json:"sort_order"`
+	ID        int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionSortOrder(ctx context.Context, arg UpdateSubscriptionSortOrderParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionSortOrder, arg.SortOrder, arg.ID)
+	return err
+}
+
+const updateSubscriptionThumbnail = `-- name: UpdateSubscriptionThumbnail :exec
+UPDATE subscriptions SET thumbnail_url = ? WHERE id = ?
+`
+
+type UpdateSubscriptionThumbnailParams struct {
+	ThumbnailUrl sql.NullString `json:"thumbnail_url"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionThumbnail(ctx context.Context, arg UpdateSubscriptionThumbnailParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionThumbnail, arg.ThumbnailUrl, arg.ID)
+	return err
+}
+
+const updateSubscriptionUpcomingMode = `-- name: UpdateSubscriptionUpcomingMode :exec
+UPDATE subscriptions SET upcoming_mode = ? WHERE id = ?
+`
+
+type UpdateSubscriptionUpcomingModeParams struct {
+	UpcomingMode string `json:"upcoming_mode"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) UpdateSubscriptionUpcomingMode(ctx context.Context, arg UpdateSubscriptionUpcomingModeParams) error {
+	_, err := q.db.ExecContext(ctx, updateSubscriptionUpcomingMode, arg.UpcomingMode, arg.ID)
+	return err
+}
+
+const updateVideoAvailability = `-- name: UpdateVideoAvailability :one
+UPDATE videos SET availability = ?, availability_checked_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
+`
+
+type UpdateVideoAvailabilityParams struct {
+	Availability string `json:"availability"`
+	ID           int64  `json:"id"`
+}
+
+func (q *Queries) UpdateVideoAvailability(ctx context.Context, arg UpdateVideoAvailabilityParams) (Video, error) {
+	row := q.db.QueryRowContext(ctx, updateVideoAvailability, arg.Availability, arg.ID)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.YoutubeID,
+		&i.Title,
+		&i.ThumbnailUrl,
+		&i.Duration,
+		&i.PublishedAt,
+		&i.Watched,
+		&i.CreatedAt,
+		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const updateVideoBroadcast = `-- name: UpdateVideoBroadcast :one
+UPDATE videos
+SET broadcast_state = ?, scheduled_start_at = ?, duration = ?, duration_seconds = ?, published_at = ?
+WHERE id = ?
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
+`
+
+type UpdateVideoBroadcastParams struct {
+	BroadcastState   string         `json:"broadcast_state"`
+	ScheduledStartAt sql.NullTime   `json:"scheduled_start_at"`
+	Duration         sql.NullString `json:"duration"`
+	DurationSeconds  sql.NullInt64  `json:"duration_seconds"`
+	PublishedAt      sql.NullTime   `json:"published_at"`
+	ID               int64          `json:"id"`
+}
+
+func (q *Queries) UpdateVideoBroadcast(ctx context.Context, arg UpdateVideoBroadcastParams) (Video, error) {
+	row := q.db.QueryRowContext(ctx, updateVideoBroadcast,
+		arg.BroadcastState,
+		arg.ScheduledStartAt,
+		arg.Duration,
+		arg.DurationSeconds,
+		arg.PublishedAt,
+		arg.ID,
+	)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.YoutubeID,
+		&i.Title,
+		&i.ThumbnailUrl,
+		&i.Duration,
+		&i.PublishedAt,
+		&i.Watched,
+		&i.CreatedAt,
+		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const updateVideoDurationSeconds = `-- name: UpdateVideoDurationSeconds :exec
+UPDATE videos SET duration_seconds = ? WHERE id = ?
+`
+
+type UpdateVideoDurationSecondsParams struct {
+	DurationSeconds sql.NullInt64 `json:"duration_seconds"`
+	ID              int64         `json:"id"`
+}
+
+func (q *Queries) UpdateVideoDurationSeconds(ctx context.Context, arg UpdateVideoDurationSecondsParams) error {
+	_, err := q.db.ExecContext(ctx, updateVideoDurationSeconds, arg.DurationSeconds, arg.ID)
+	return err
+}
+
+const updateVideoMuted = `-- name: UpdateVideoMuted :exec
+UPDATE videos SET muted = ? WHERE id = ?
 `
 
-type UpdateVideoIsShortParams struct {
-	IsShort sql.NullInt64 `json:"is_short"`
-	ID      int64         `json:"id"`
+type UpdateVideoMutedParams struct {
+	Muted int64 `json:"muted"`
+	ID    int64 `json:"id"`
 }
 
-func (q *Queries) UpdateVideoIsShort(ctx context.Context, arg UpdateVideoIsShortParams) error {
-	_, err := q.db.ExecContext(ctx, updateVideoIsShort, arg.IsShort, arg.ID)
+func (q *Queries) UpdateVideoMuted(ctx context.Context, arg UpdateVideoMutedParams) error {
+	_, err := q.db.ExecContext(ctx, updateVideoMuted, arg.Muted, arg.ID)
 	return err
 }
 
+const updateVideoPlaylistPosition = `-- name: UpdateVideoPlaylistPosition :exec
+UPDATE videos SET playlist_position = ?
+WHERE subscription_id = ? AND youtube_id = ?
+`
+
+type UpdateVideoPlaylistPositionParams struct {
+	PlaylistPosition sql.NullInt64 `json:"playlist_position"`
+	SubscriptionID   int64         `json:"subscription_id"`
+	YoutubeID        string        `json:"youtube_id"`
+}
+
+func (q *Queries) UpdateVideoPlaylistPosition(ctx context.Context, arg UpdateVideoPlaylistPositionParams) error {
+	_, err := q.db.ExecContext(ctx, updateVideoPlaylistPosition, arg.PlaylistPosition, arg.SubscriptionID, arg.YoutubeID)
+	return err
+}
+
+const updateVideoShort = `-- name: UpdateVideoShort :one
+UPDATE videos
+SET is_short = ?, short_source = ?, short_checked_at = CURRENT_TIMESTAMP, short_attempts = short_attempts + 1
+WHERE id = ?
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
+`
+
+type UpdateVideoShortParams struct {
+	IsShort     sql.NullInt64  `json:"is_short"`
+	ShortSource sql.NullString `json:"short_source"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) UpdateVideoShort(ctx context.Context, arg UpdateVideoShortParams) (Video, error) {
+	row := q.db.QueryRowContext(ctx, updateVideoShort, arg.IsShort, arg.ShortSource, arg.ID)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.YoutubeID,
+		&i.Title,
+		&i.ThumbnailUrl,
+		&i.Duration,
+		&i.PublishedAt,
+		&i.Watched,
+		&i.CreatedAt,
+		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
+const updateVideoStatistics = `-- name: UpdateVideoStatistics :one
+UPDATE videos
+SET description = ?, view_count = ?, like_count = ?, comment_count = ?, stats_updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+RETURNING id, subscription_id, youtube_id, title, thumbnail_url, duration, published_at, watched, created_at, is_short, duration_seconds, broadcast_state, scheduled_start_at, short_source, short_checked_at, short_attempts, description, view_count, like_count, comment_count, stats_updated_at, availability, availability_checked_at, playlist_position, muted
+`
+
+type UpdateVideoStatisticsParams struct {
+	Description  sql.NullString `json:"description"`
+	ViewCount    sql.NullInt64  `json:"view_count"`
+	LikeCount    sql.NullInt64  `json:"like_count"`
+	CommentCount sql.NullInt64  `json:"comment_count"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) UpdateVideoStatistics(ctx context.Context, arg UpdateVideoStatisticsParams) (Video, error) {
+	row := q.db.QueryRowContext(ctx, updateVideoStatistics,
+		arg.Description,
+		arg.ViewCount,
+		arg.LikeCount,
+		arg.CommentCount,
+		arg.ID,
+	)
+	var i Video
+	err := row.Scan(
+		&i.ID,
+		&i.SubscriptionID,
+		&i.YoutubeID,
+		&i.Title,
+		&i.ThumbnailUrl,
+		&i.Duration,
+		&i.PublishedAt,
+		&i.Watched,
+		&i.CreatedAt,
+		&i.IsShort,
+		&i.DurationSeconds,
+		&i.BroadcastState,
+		&i.ScheduledStartAt,
+		&i.ShortSource,
+		&i.ShortCheckedAt,
+		&i.ShortAttempts,
+		&i.Description,
+		&i.ViewCount,
+		&i.LikeCount,
+		&i.CommentCount,
+		&i.StatsUpdatedAt,
+		&i.Availability,
+		&i.AvailabilityCheckedAt,
+		&i.PlaylistPosition,
+		&i.Muted,
+	)
+	return i, err
+}
+
 const videoExistsByYoutubeID = `-- name: VideoExistsByYoutubeID :one
 SELECT EXISTS(SELECT 1 FROM videos WHERE youtube_id = ?)
 `
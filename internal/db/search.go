@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+)
+
+// Markers that SearchLibrary puts around matched terms in TitleHighlight and
+// DescriptionSnippet. Control characters can't occur in titles, so they
+// survive until the template turns them into markup.
+const (
+	HighlightStart = "\x02"
+	HighlightEnd   = "\x03"
+)
+
+// LibraryQuery turns what a user typed into an FTS5 query: every word has to
+// match, the last one as a prefix so that results follow along while typing.
+// Quoting each word keeps FTS5 syntax such as AND, NEAR or column filters
+// from being interpreted. An empty string means there is nothing to search.
+func LibraryQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	for i, w := range words {
+		words[i] = `"` + w + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// sqlc can't see the hidden columns and auxiliary functions of FTS5 tables,
+// so the library search is written by hand.
+const searchLibrary = `SELECT v.id, v.subscription_id, v.youtube_id, v.title, v.thumbnail_url, v.duration,
+       v.published_at, v.watched, v.is_short, v.duration_seconds, v.view_count,
+       s.name,
+       highlight(videos_fts, 0, char(2), char(3)),
+       snippet(videos_fts, 1, char(2), char(3), '…', 24)
+FROM videos_fts
+JOIN videos v ON v.id = videos_fts.rowid
+JOIN subscriptions s ON s.id = v.subscription_id
+WHERE videos_fts MATCH ?1
+  AND (?2 IS NULL OR v.subscription_id = ?2)
+  AND (?3 IS NULL OR COALESCE(v.watched, 0) = ?3)
+  AND (?4 IS NULL OR COALESCE(v.is_short, 0) = ?4)
+  AND (?5 IS NULL OR v.published_at >= ?5)
+  AND (?6 IS NULL OR v.published_at < ?6)
+ORDER BY bm25(videos_fts, 10.0, 1.0), v.published_at DESC
+LIMIT ?7 OFFSET ?8`
+
+// SearchLibraryParams filters a library search. Filters left NULL don't
+// apply; Shorts is 1 for only Shorts and 0 for everything else.
+type SearchLibraryParams struct {
+	Query           string
+	SubscriptionID  sql.NullInt64
+	Watched         sql.NullInt64
+	Shorts          sql.NullInt64
+	PublishedAfter  sql.NullTime
+	PublishedBefore sql.NullTime
+	Limit           int64
+	Offset          int64
+}
+
+type SearchLibraryRow struct {
+	ID                 int64          `json:"id"`
+	SubscriptionID     int64          `json:"subscription_id"`
+	YoutubeID          string         `json:"youtube_id"`
+	Title              string         `json:"title"`
+	ThumbnailUrl       sql.NullString `json:"thumbnail_url"`
+	Duration           sql.NullString `json:"duration"`
+	PublishedAt        sql.NullTime   `json:"published_at"`
+	Watched            sql.NullInt64  `json:"watched"`
+	IsShort            sql.NullInt64  `json:"is_short"`
+	DurationSeconds    sql.NullInt64  `json:"duration_seconds"`
+	ViewCount          sql.NullInt64  `json:"view_count"`
+	ChannelName        string         `json:"channel_name"`
+	TitleHighlight     string         `json:"title_highlight"`
+	DescriptionSnippet string         `json:"description_snippet"`
+}
+
+// SearchLibrary ranks the stored videos matching arg.Query, an FTS5 query as
+// built by LibraryQuery, best first. Titles weigh ten times as much as
+// descriptions.
+func (q *Queries) SearchLibrary(ctx context.Context, arg SearchLibraryParams) ([]SearchLibraryRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchLibrary,
+		arg.Query,
+		arg.SubscriptionID,
+		arg.Watched,
+		arg.Shorts,
+		arg.PublishedAfter,
+		arg.PublishedBefore,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchLibraryRow{}
+	for rows.Next() {
+		var i SearchLibraryRow
+		var snippet sql.NullString
+		if err := rows.Scan(
+			&i.ID,
+			&i.SubscriptionID,
+			&i.YoutubeID,
+			&i.Title,
+			&i.ThumbnailUrl,
+			&i.Duration,
+			&i.PublishedAt,
+			&i.Watched,
+			&i.IsShort,
+			&i.DurationSeconds,
+			&i.ViewCount,
+			&i.ChannelName,
+			&i.TitleHighlight,
+			&snippet,
+		); err != nil {
+			return nil, err
+		}
+		i.DescriptionSnippet = snippet.String
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,24 @@
+package db
+
+import "testing"
+
+func TestLibraryQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"  ", ""},
+		{"rust", `"rust"*`},
+		{"rust async", `"rust" "async"*`},
+		{`title:"x" AND NEAR(y`, `"title" "x" "AND" "NEAR" "y"*`},
+		{"don't panic", `"don" "t" "panic"*`},
+		{"Ünïcode café", `"Ünïcode" "café"*`},
+		{"-- *", ""},
+	}
+	for _, tt := range tests {
+		if got := LibraryQuery(tt.text); got != tt.want {
+			t.Errorf("LibraryQuery(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Package events fans deck updates out to every open browser tab over
+// Server-Sent Events.
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	clientBuffer      = 32
+	keepAliveInterval = 25 * time.Second
+)
+
+// Hub is an in-process pub/sub hub. Published messages are HTML fragments
+// carrying hx-swap-oob attributes, so the browser can apply them as-is.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[chan string]struct{}
+	closed  bool
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: make(map[chan string]struct{})}
+}
+
+// Subscribe registers a new listener. The returned function unregisters it
+// and must be called once the listener goes away.
+func (h *Hub) Subscribe() (<-chan string, func()) {
+	ch := make(chan string, clientBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.clients[ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[ch]; ok {
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends msg to every listener. Listeners that have fallen behind
+// miss the message rather than blocking the publisher; the browser reloads
+// its columns when it reconnects.
+func (h *Hub) Publish(msg string) {
+	if msg == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// Close disconnects every listener so that open streams don't hold up server
+// shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// ServeHTTP streams published messages to the client as Server-Sent Events.
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	msgs, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	_, _ = fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprint(w, format(msg)); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// format encodes msg as a single SSE message. Each line of a multi-line
+// payload needs its own "data:" prefix.
+func format(msg string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(msg, "\n") {
+		b.WriteString("data: ")
+		b.WriteString(strings.TrimSuffix(line, "\r"))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
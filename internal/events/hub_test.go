@@ -0,0 +1,146 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+		return ""
+	}
+}
+
+func TestPublishReachesEverySubscriber(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	a, unsubA := h.Subscribe()
+	defer unsubA()
+	b, unsubB := h.Subscribe()
+	defer unsubB()
+
+	h.Publish("<div>update</div>")
+	h.Publish("") // ignored
+	if got := receive(t, a); got != "<div>update</div>" {
+		t.Errorf("a got %q", got)
+	}
+	if got := receive(t, b); got != "<div>update</div>" {
+		t.Errorf("b got %q", got)
+	}
+	select {
+	case msg := <-a:
+		t.Errorf("empty message delivered: %q", msg)
+	default:
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	ch, unsubscribe := h.Subscribe()
+	unsubscribe()
+	unsubscribe() // twice is harmless
+
+	if _, ok := <-ch; ok {
+		t.Error("channel open after unsubscribing")
+	}
+	h.Publish("after") // must not send on the closed channel
+}
+
+func TestSlowSubscriberDoesNotBlockPublish(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	slow, unsubSlow := h.Subscribe()
+	defer unsubSlow()
+	fast, unsubFast := h.Subscribe()
+	defer unsubFast()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Nobody reads slow, so its buffer fills up and it misses the rest.
+		for range clientBuffer + 10 {
+			h.Publish("msg")
+			<-fast
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a subscriber that doesn't read")
+	}
+	if len(slow) != clientBuffer {
+		t.Errorf("slow subscriber buffered %d messages, want %d", len(slow), clientBuffer)
+	}
+}
+
+func TestCloseDisconnectsSubscribers(t *testing.T) {
+	h := NewHub()
+	ch, unsubscribe := h.Subscribe()
+	h.Close()
+	if _, ok := <-ch; ok {
+		t.Error("channel open after Close")
+	}
+	unsubscribe()
+	h.Publish("after close")
+
+	late, _ := h.Subscribe()
+	if _, ok := <-late; ok {
+		t.Error("Subscribe after Close returned an open channel")
+	}
+}
+
+func TestServeHTTPStreamsEvents(t *testing.T) {
+	h := NewHub()
+	defer h.Close()
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	// The retry hint comes first; once it's read the client is subscribed.
+	if line, _ := r.ReadString('\n'); line != "retry: 3000\n" {
+		t.Fatalf("first line = %q", line)
+	}
+	r.ReadString('\n')
+	h.Publish("<p>one</p>\n<p>two</p>")
+
+	var event strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "\n" {
+			break
+		}
+		event.WriteString(line)
+	}
+	if got := event.String(); got != "data: <p>one</p>\ndata: <p>two</p>\n" {
+		t.Errorf("event = %q", got)
+	}
+}
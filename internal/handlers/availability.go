@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+const (
+	// availabilityStaleMinutes is how long a video's availability is trusted
+	// before it is checked again.
+	availabilityStaleMinutes = 24 * 60
+	// availabilityCheckBatch caps the videos checked in one pass, ten
+	// videos.list calls.
+	availabilityCheckBatch = 500
+)
+
+// ReconcileAvailability looks up unwatched videos that weren't checked
+// lately and records whether they can still be watched. Videos the Data API
+// no longer returns were deleted or made private. Videos that are gone leave
+// their columns for the gone section, and ones that came back return to it.
+// It does nothing once the API budget runs low.
+func (h *Handlers) ReconcileAvailability(ctx context.Context) error {
+	if h.quota.Degraded(ctx) {
+		return nil
+	}
+	videos, err := h.queries.ListVideosForAvailabilityCheck(ctx, db.ListVideosForAvailabilityCheckParams{
+		StaleMinutes: availabilityStaleMinutes,
+		Limit:        availabilityCheckBatch,
+	})
+	if err != nil || len(videos) == 0 {
+		return err
+	}
+
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.YoutubeID
+	}
+	infos, err := h.yt.GetVideos(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]youtube.VideoInfo, len(infos))
+	for _, info := range infos {
+		byID[info.ID] = info
+	}
+
+	gone := make(map[int64][]int64)
+	changed := make(map[int64]bool)
+	for _, v := range videos {
+		availability := youtube.AvailabilityMissing
+		if info, ok := byID[v.YoutubeID]; ok {
+			availability = youtube.Availability(info, h.region)
+		}
+		updated, err := h.queries.UpdateVideoAvailability(ctx, db.UpdateVideoAvailabilityParams{
+			Availability: availability,
+			ID:           v.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if availability == v.Availability {
+			continue
+		}
+		changed[updated.SubscriptionID] = true
+		if v.Availability == youtube.AvailabilityAvailable {
+			gone[updated.SubscriptionID] = append(gone[updated.SubscriptionID], updated.ID)
+		}
+	}
+
+	for subID := range changed {
+		h.publishAvailabilityChanged(ctx, subID, gone[subID])
+	}
+	h.log.Info("reconciled video availability", "checked", len(videos), "changed", len(changed))
+	return nil
+}
+
+// publishAvailabilityChanged takes the videos that just went away off a
+// subscription's column and refreshes its counts and gone section. Videos
+// that came back show up the next time the column loads.
+func (h *Handlers) publishAvailabilityChanged(ctx context.Context, subID int64, goneIDs []int64) {
+	sub, err := h.queries.GetSubscription(ctx, subID)
+	if err != nil {
+		log.Printf("load subscription %d: %v", subID, err)
+		return
+	}
+	count, err := h.countColumnVideos(ctx, sub)
+	if err != nil {
+		log.Printf("count unwatched error: %v", err)
+		return
+	}
+	goneCount, err := h.queries.CountGoneVideos(ctx, subID)
+	if err != nil {
+		log.Printf("count gone error: %v", err)
+		return
+	}
+	for _, id := range goneIDs {
+		h.publish(ctx, templates.VideoWatchedOOB(id, subID, count))
+	}
+	if len(goneIDs) == 0 {
+		h.publish(ctx, templates.UnwatchedCountsOOB(subID, count))
+	}
+	h.publish(ctx, templates.GoneRevealOOB(subID, goneCount))
+}
+
+// HandleGoneVideos lists the unwatched videos of a subscription that were
+// deleted, made private or blocked since they were stored.
+func (h *Handlers) HandleGoneVideos(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	videos, err := h.queries.ListGoneVideos(r.Context(), id)
+	if err != nil {
+		log.Printf("list gone videos error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.GoneVideos(id, videos).Render(r.Context(), w)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"youtube-deck-go/internal/backup"
+)
+
+// HandleBackup serves a snapshot of the database, or with format=json the
+// portable backup of the deck. Either is restored by the restore subcommand.
+func (h *Handlers) HandleBackup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := "youtube-deck-" + time.Now().Format("20060102-150405")
+
+	if r.URL.Query().Get("format") == "json" {
+		doc, err := backup.Export(ctx, h.db)
+		if err != nil {
+			log.Printf("export backup: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		serveExport(w, name+".json", "application/json", func(w io.Writer) error {
+			return backup.Write(w, doc)
+		})
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "youtube-deck-backup-")
+	if err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup.db")
+	if err := backup.Snapshot(ctx, h.db, path); err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Printf("backup: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	serveExport(w, name+".db", "application/vnd.sqlite3", func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+}
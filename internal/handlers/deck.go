@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
@@ -13,57 +15,174 @@ import (
 )
 
 func (h *Handlers) HandleDeck(w http.ResponseWriter, r *http.Request) {
-	sidebarRows, err := h.queries.ListSubscriptionsPaginated(r.Context(), db.ListSubscriptionsPaginatedParams{
-		Limit:  sidebarPageSize + 1,
-		Offset: 0,
-	})
+	deck, err := h.currentDeck(r.Context())
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	h.renderDeck(w, r, deck)
+}
 
-	hasMore := len(sidebarRows) > sidebarPageSize
-	if hasMore {
-		sidebarRows = sidebarRows[:sidebarPageSize]
+// renderDeck renders the deck page showing the columns of deck.
+func (h *Handlers) renderDeck(w http.ResponseWriter, r *http.Request, deck db.Deck) {
+	decks, err := h.queries.ListDecks(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	currentID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	// With folders, the sidebar loads each one when it is expanded.
+	groups, err := h.queries.ListGroups(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	var sidebarSubs []templates.SubscriptionWithCount
+	var hasMore bool
+	if len(groups) == 0 {
+		sidebarSubs, hasMore, err = h.listSidebarPage(r.Context(), deck.ID, "", 0)
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
 	}
 
-	activeRows, err := h.queries.ListActiveSubscriptions(r.Context())
+	deckRows, err := h.queries.ListDeckSubscriptions(r.Context(), deck.ID)
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	columns := make([]templates.SubscriptionWithCount, len(deckRows))
+	for i, row := range deckRows {
+		columns[i] = subWithCount(row.Subscription, row.UnwatchedCount, 1)
+		columns[i].MutedCount = row.MutedCount
+		columns[i].GoneCount = row.GoneCount
+	}
 
-	sidebarSubs := h.rowsToSubs(sidebarRows)
-	activeSubs := h.activeRowsToSubs(activeRows)
-	nextOffset := int64(len(sidebarSubs))
+	rivers, err := h.listRiverColumns(r.Context(), deck.ID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
 
-	isAuth := h.auth != nil && h.auth.IsAuthenticated()
-	_ = templates.Deck(sidebarSubs, activeSubs, hasMore, nextOffset, isAuth).Render(r.Context(), w)
+	_ = templates.Deck(templates.DeckPage{
+		Deck:            deck,
+		Decks:           decks,
+		Groups:          groups,
+		Subs:            sidebarSubs,
+		HasMore:         hasMore,
+		NextOffset:      int64(len(sidebarSubs)),
+		Columns:         columns,
+		Rivers:          rivers,
+		IsAuthenticated: h.auth != nil && h.auth.IsAuthenticated(),
+		Current:         deck.ID == currentID,
+	}).Render(r.Context(), w)
 }
 
-func (h *Handlers) activeRowsToSubs(rows []db.ListActiveSubscriptionsRow) []templates.SubscriptionWithCount {
-	subs := make([]templates.SubscriptionWithCount, len(rows))
-	for i, row := range rows {
-		subs[i] = templates.SubscriptionWithCount{
-			Subscription: db.Subscription{
-				ID:           row.ID,
-				Name:         row.Name,
-				YoutubeID:    row.YoutubeID,
-				Type:         row.Type,
-				ThumbnailUrl: row.ThumbnailUrl,
-				LastChecked:  row.LastChecked,
-				CreatedAt:    row.CreatedAt,
-				Position:     row.Position,
-				Active:       row.Active,
-			},
-			UnwatchedCount: row.UnwatchedCount,
-		}
+func subWithCount(sub db.Subscription, unwatchedCount, inDeck int64) templates.SubscriptionWithCount {
+	return templates.SubscriptionWithCount{
+		Subscription:   sub,
+		UnwatchedCount: unwatchedCount,
+		InDeck:         inDeck == 1,
 	}
-	return subs
 }
 
 const columnVideoPageSize = 10
 
+// hideShortsParam returns the hide_shorts flag of sub as a query argument.
+func hideShortsParam(sub db.Subscription) int64 {
+	if sub.HideShorts.Valid {
+		return sub.HideShorts.Int64
+	}
+	return 0
+}
+
+// upcomingModes are the ways a column can show upcoming broadcasts.
+var upcomingModes = map[string]bool{"show": true, "separate": true, "hide": true}
+
+// hideUpcomingParam reports as a query argument whether upcoming broadcasts
+// are left out of sub's video list, either hidden or shown separately.
+func hideUpcomingParam(sub db.Subscription) int64 {
+	if sub.UpcomingMode != "show" {
+		return 1
+	}
+	return 0
+}
+
+// columnShows reports whether v passes the filters of sub's column, matching
+// the conditions of ListUnwatchedVideosPaginatedFiltered.
+func columnShows(sub db.Subscription, v db.Video) bool {
+	if hideShortsParam(sub) == 1 && v.IsShort.Valid && v.IsShort.Int64 == 1 {
+		return false
+	}
+	if hideUpcomingParam(sub) == 1 && v.BroadcastState == youtube.BroadcastUpcoming {
+		return false
+	}
+	if !v.DurationSeconds.Valid {
+		return true
+	}
+	if sub.MinDurationSeconds.Valid && v.DurationSeconds.Int64 < sub.MinDurationSeconds.Int64 {
+		return false
+	}
+	if sub.MaxDurationSeconds.Valid && v.DurationSeconds.Int64 > sub.MaxDurationSeconds.Int64 {
+		return false
+	}
+	return true
+}
+
+// countColumnVideos counts the unwatched videos shown in sub's column, taking
+// the column filters and mute rules into account.
+func (h *Handlers) countColumnVideos(ctx context.Context, sub db.Subscription) (int64, error) {
+	return h.queries.CountUnwatchedBySubscriptionFiltered(ctx, db.CountUnwatchedBySubscriptionFilteredParams{
+		SubscriptionID: sub.ID,
+		HideShorts:     hideShortsParam(sub),
+		HideUpcoming:   hideUpcomingParam(sub),
+		MinSeconds:     sub.MinDurationSeconds,
+		MaxSeconds:     sub.MaxDurationSeconds,
+	})
+}
+
+// countMutedVideos counts the unwatched videos that pass sub's column filters
+// but are hidden by mute rules.
+func (h *Handlers) countMutedVideos(ctx context.Context, sub db.Subscription) (int64, error) {
+	return h.queries.CountUnwatchedBySubscriptionFiltered(ctx, db.CountUnwatchedBySubscriptionFilteredParams{
+		SubscriptionID: sub.ID,
+		HideShorts:     hideShortsParam(sub),
+		HideUpcoming:   hideUpcomingParam(sub),
+		MinSeconds:     sub.MinDurationSeconds,
+		MaxSeconds:     sub.MaxDurationSeconds,
+		Muted:          1,
+	})
+}
+
+// listColumnVideos returns one page of sub's column starting at offset and
+// reports whether more videos are stored after it.
+func (h *Handlers) listColumnVideos(ctx context.Context, sub db.Subscription, offset int64) ([]db.Video, bool, error) {
+	videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(ctx, db.ListUnwatchedVideosPaginatedFilteredParams{
+		SubscriptionID: sub.ID,
+		HideShorts:     hideShortsParam(sub),
+		HideUpcoming:   hideUpcomingParam(sub),
+		MinSeconds:     sub.MinDurationSeconds,
+		MaxSeconds:     sub.MaxDurationSeconds,
+		Sort:           sub.SortOrder,
+		Limit:          columnVideoPageSize + 1,
+		Offset:         offset,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(videos) > columnVideoPageSize
+	if hasMore {
+		videos = videos[:columnVideoPageSize]
+	}
+	return videos, hasMore, nil
+}
+
 func (h *Handlers) HandleColumnVideos(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -81,27 +200,12 @@ func (h *Handlers) HandleColumnVideos(w http.ResponseWriter, r *http.Request) {
 	offsetStr := r.URL.Query().Get("offset")
 	offset, _ := strconv.ParseInt(offsetStr, 10, 64)
 
-	hideShorts := int64(0)
-	if sub.HideShorts.Valid {
-		hideShorts = sub.HideShorts.Int64
-	}
-
-	videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
-		SubscriptionID: id,
-		Column2:        hideShorts,
-		Limit:          columnVideoPageSize + 1,
-		Offset:         offset,
-	})
+	videos, hasMoreDB, err := h.listColumnVideos(r.Context(), sub, offset)
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	hasMoreDB := len(videos) > columnVideoPageSize
-	if hasMoreDB {
-		videos = videos[:columnVideoPageSize]
-	}
-
 	canFetchMore := false
 	if !hasMoreDB {
 		// Allow fetching more if we have a next page token OR if we haven't fetched yet (NULL token)
@@ -132,34 +236,28 @@ func (h *Handlers) HandleFetchMoreVideos(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	hideShorts := int64(0)
-	if sub.HideShorts.Valid {
-		hideShorts = sub.HideShorts.Int64
+	// Digging into older videos is non-essential; stop once the daily API
+	// budget runs low and leave the quota for refreshes.
+	if h.quota.Degraded(r.Context()) {
+		_ = templates.ColumnQuotaNotice().Render(r.Context(), w)
+		return
 	}
 
 	// Count existing unwatched videos before fetching more (filtered)
-	existingCount, _ := h.queries.CountUnwatchedBySubscriptionFiltered(r.Context(), db.CountUnwatchedBySubscriptionFilteredParams{
-		SubscriptionID: id,
-		Column2:        hideShorts,
-	})
+	existingCount, _ := h.countColumnVideos(r.Context(), sub)
 
 	pageToken := ""
 	if sub.PageToken.Valid {
 		pageToken = sub.PageToken.String
 	}
 
-	var result *youtube.FetchResult
-	if sub.Type == "channel" {
-		result, err = h.yt.FetchChannelVideosWithToken(r.Context(), sub.YoutubeID, pageToken, 20)
-	} else {
-		result, err = h.yt.FetchPlaylistVideosWithToken(r.Context(), sub.YoutubeID, pageToken, 20)
-	}
+	result, err := h.fetchVideos(r.Context(), &sub, pageToken)
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	if err := h.saveVideos(r, sub.ID, result.Videos); err != nil {
+	if err := h.saveVideos(r.Context(), sub, result.Videos); err != nil {
 		log.Printf("save videos error: %v", err)
 	}
 
@@ -172,30 +270,17 @@ func (h *Handlers) HandleFetchMoreVideos(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Get new total count after saving videos (filtered)
-	newCount, err := h.queries.CountUnwatchedBySubscriptionFiltered(r.Context(), db.CountUnwatchedBySubscriptionFilteredParams{
-		SubscriptionID: id,
-		Column2:        hideShorts,
-	})
+	newCount, err := h.countColumnVideos(r.Context(), sub)
 	if err != nil {
 		log.Printf("count unwatched error: %v", err)
 	}
 
 	// Query starting from where we left off (after existing filtered videos)
-	videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
-		SubscriptionID: id,
-		Column2:        hideShorts,
-		Limit:          columnVideoPageSize + 1,
-		Offset:         existingCount,
-	})
+	videos, hasMoreDB, err := h.listColumnVideos(r.Context(), sub, existingCount)
 	if err != nil {
 		log.Printf("list videos error: %v", err)
 	}
 
-	hasMoreDB := len(videos) > columnVideoPageSize
-	if hasMoreDB {
-		videos = videos[:columnVideoPageSize]
-	}
-
 	canFetchMore := result.NextPageToken != ""
 
 	nextOffset := existingCount + int64(len(videos))
@@ -203,6 +288,8 @@ func (h *Handlers) HandleFetchMoreVideos(w http.ResponseWriter, r *http.Request)
 	_ = templates.UnwatchedCountsOOB(id, newCount).Render(r.Context(), w)
 }
 
+// HandleToggleActive adds a subscription's column to the current deck or
+// removes it.
 func (h *Handlers) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -214,10 +301,23 @@ func (h *Handlers) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 	activeParam := r.URL.Query().Get("active")
 	active, _ := strconv.ParseInt(activeParam, 10, 64)
 
-	err = h.queries.UpdateSubscriptionActive(r.Context(), db.UpdateSubscriptionActiveParams{
-		Active: sql.NullInt64{Int64: active, Valid: true},
-		ID:     id,
-	})
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if active == 1 {
+		err = h.queries.AddDeckSubscription(r.Context(), db.AddDeckSubscriptionParams{
+			DeckID:         deckID,
+			SubscriptionID: sql.NullInt64{Int64: id, Valid: true},
+		})
+	} else {
+		err = h.queries.RemoveDeckSubscription(r.Context(), db.RemoveDeckSubscriptionParams{
+			DeckID:         deckID,
+			SubscriptionID: sql.NullInt64{Int64: id, Valid: true},
+		})
+	}
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
@@ -231,14 +331,9 @@ func (h *Handlers) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Fetch videos from YouTube when adding to deck
-		var result *youtube.FetchResult
-		if sub.Type == "channel" {
-			result, err = h.yt.FetchChannelVideosWithToken(r.Context(), sub.YoutubeID, "", 20)
-		} else {
-			result, err = h.yt.FetchPlaylistVideosWithToken(r.Context(), sub.YoutubeID, "", 20)
-		}
+		result, err := h.fetchVideos(r.Context(), &sub, "")
 		if err == nil {
-			if err := h.saveVideos(r, sub.ID, result.Videos); err != nil {
+			if err := h.saveVideos(r.Context(), sub, result.Videos); err != nil {
 				log.Printf("save videos error: %v", err)
 			}
 			newToken := sql.NullString{String: result.NextPageToken, Valid: result.NextPageToken != ""}
@@ -253,68 +348,42 @@ func (h *Handlers) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 			log.Printf("update subscription checked error: %v", err)
 		}
 
-		hideShorts := int64(0)
-		if sub.HideShorts.Valid {
-			hideShorts = sub.HideShorts.Int64
-		}
-
-		activeCount, err := h.queries.CountActiveSubscriptions(r.Context())
+		activeCount, err := h.queries.CountDeckSubscriptions(r.Context(), deckID)
 		if err != nil {
 			log.Printf("count active error: %v", err)
 		}
-		count, err := h.queries.CountUnwatchedBySubscriptionFiltered(r.Context(), db.CountUnwatchedBySubscriptionFilteredParams{
-			SubscriptionID: id,
-			Column2:        hideShorts,
-		})
+		count, err := h.countColumnVideos(r.Context(), sub)
 		if err != nil {
 			log.Printf("count unwatched error: %v", err)
 		}
 
-		videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
-			SubscriptionID: id,
-			Column2:        hideShorts,
-			Limit:          columnVideoPageSize + 1,
-			Offset:         0,
-		})
+		videos, hasMoreDB, err := h.listColumnVideos(r.Context(), sub, 0)
 		if err != nil {
 			log.Printf("list videos error: %v", err)
 		}
 
-		hasMoreDB := len(videos) > columnVideoPageSize
-		if hasMoreDB {
-			videos = videos[:columnVideoPageSize]
-		}
-
 		canFetchMore := !sub.PageToken.Valid || sub.PageToken.String != ""
 
-		_ = templates.ColumnWithVideosAndChip(templates.SubscriptionWithCount{
-			Subscription:   sub,
-			UnwatchedCount: count,
-		}, videos, hasMoreDB, canFetchMore, int64(len(videos)), activeCount).Render(r.Context(), w)
+		swc := subWithCount(sub, count, 1)
+		if swc.MutedCount, err = h.countMutedVideos(r.Context(), sub); err != nil {
+			log.Printf("count muted error: %v", err)
+		}
+		if swc.GoneCount, err = h.queries.CountGoneVideos(r.Context(), sub.ID); err != nil {
+			log.Printf("count gone error: %v", err)
+		}
+		_ = templates.ColumnWithVideosAndChip(swc,
+			videos, hasMoreDB, canFetchMore, int64(len(videos)), activeCount).Render(r.Context(), w)
 		_ = templates.SidebarCountOOB(id, count).Render(r.Context(), w)
 	} else {
-		activeCount, err := h.queries.CountActiveSubscriptions(r.Context())
+		activeCount, err := h.queries.CountDeckSubscriptions(r.Context(), deckID)
 		if err != nil {
 			log.Printf("count active error: %v", err)
 		}
-		rows, err := h.queries.ListAllSubscriptionsOrdered(r.Context())
+		rows, err := h.queries.ListAllSubscriptionsOrdered(r.Context(), deckID)
 		if err == nil {
 			for _, row := range rows {
-				if row.ID == id {
-					_ = templates.SidebarItem(templates.SubscriptionWithCount{
-						Subscription: db.Subscription{
-							ID:           row.ID,
-							Name:         row.Name,
-							YoutubeID:    row.YoutubeID,
-							Type:         row.Type,
-							ThumbnailUrl: row.ThumbnailUrl,
-							LastChecked:  row.LastChecked,
-							CreatedAt:    row.CreatedAt,
-							Position:     row.Position,
-							Active:       row.Active,
-						},
-						UnwatchedCount: row.UnwatchedCount,
-					}).Render(r.Context(), w)
+				if row.Subscription.ID == id {
+					_ = templates.SidebarItem(subWithCount(row.Subscription, row.UnwatchedCount, row.InDeck)).Render(r.Context(), w)
 					break
 				}
 			}
@@ -325,83 +394,119 @@ func (h *Handlers) HandleToggleActive(w http.ResponseWriter, r *http.Request) {
 
 const sidebarPageSize = 50
 
+// HandleFilterSubscriptions renders the sidebar list: the subscriptions
+// matching q, one page of a folder (group is a group ID, or "none" for the
+// subscriptions in no folder), or the sidebar's first page.
 func (h *Handlers) HandleFilterSubscriptions(w http.ResponseWriter, r *http.Request) {
 	q := r.URL.Query().Get("q")
+	group := r.URL.Query().Get("group")
 	offsetStr := r.URL.Query().Get("offset")
 	offset, _ := strconv.ParseInt(offsetStr, 10, 64)
 
+	if q == "" && group == "" && offset == 0 {
+		h.renderSidebarList(w, r)
+		return
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
 	if q != "" {
-		rows, err := h.queries.FilterSubscriptions(r.Context(), sql.NullString{String: q, Valid: true})
+		rows, err := h.queries.FilterSubscriptions(r.Context(), db.FilterSubscriptionsParams{
+			DeckID: deckID,
+			Query:  sql.NullString{String: q, Valid: true},
+		})
 		if err != nil {
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
-		subs := h.filterRowsToSubs(rows)
-		_ = templates.SidebarList(subs, false, 0).Render(r.Context(), w)
+		subs := make([]templates.SubscriptionWithCount, len(rows))
+		for i, row := range rows {
+			subs[i] = subWithCount(row.Subscription, row.UnwatchedCount, row.InDeck)
+		}
+		_ = templates.SidebarList(subs, false, "", 0).Render(r.Context(), w)
 		return
 	}
 
-	rows, err := h.queries.ListSubscriptionsPaginated(r.Context(), db.ListSubscriptionsPaginatedParams{
-		Limit:  sidebarPageSize + 1,
-		Offset: offset,
-	})
+	if group != "" && group != "none" {
+		if _, err := strconv.ParseInt(group, 10, 64); err != nil {
+			http.Error(w, "invalid group", http.StatusBadRequest)
+			return
+		}
+	}
+
+	subs, hasMore, err := h.listSidebarPage(r.Context(), deckID, group, offset)
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	hasMore := len(rows) > sidebarPageSize
-	if hasMore {
-		rows = rows[:sidebarPageSize]
-	}
-
-	subs := h.rowsToSubs(rows)
 	nextOffset := offset + int64(len(subs))
-	_ = templates.SidebarList(subs, hasMore, nextOffset).Render(r.Context(), w)
+	_ = templates.SidebarList(subs, hasMore, group, nextOffset).Render(r.Context(), w)
 }
 
-func (h *Handlers) rowsToSubs(rows []db.ListSubscriptionsPaginatedRow) []templates.SubscriptionWithCount {
-	subs := make([]templates.SubscriptionWithCount, len(rows))
-	for i, row := range rows {
-		subs[i] = templates.SubscriptionWithCount{
-			Subscription: db.Subscription{
-				ID:           row.ID,
-				Name:         row.Name,
-				YoutubeID:    row.YoutubeID,
-				Type:         row.Type,
-				ThumbnailUrl: row.ThumbnailUrl,
-				LastChecked:  row.LastChecked,
-				CreatedAt:    row.CreatedAt,
-				Position:     row.Position,
-				Active:       row.Active,
-			},
-			UnwatchedCount: row.UnwatchedCount,
+// listSidebarPage returns the sidebar page at offset of every subscription,
+// of the members of folder group, or with group "none" of the subscriptions
+// in no folder.
+func (h *Handlers) listSidebarPage(ctx context.Context, deckID int64, group string, offset int64) ([]templates.SubscriptionWithCount, bool, error) {
+	var subs []templates.SubscriptionWithCount
+	switch group {
+	case "":
+		rows, err := h.queries.ListSubscriptionsPaginated(ctx, db.ListSubscriptionsPaginatedParams{
+			DeckID: deckID,
+			Limit:  sidebarPageSize + 1,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, row := range rows {
+			subs = append(subs, subWithCount(row.Subscription, row.UnwatchedCount, row.InDeck))
+		}
+	case "none":
+		rows, err := h.queries.ListUngroupedSubscriptionsPaginated(ctx, db.ListUngroupedSubscriptionsPaginatedParams{
+			DeckID: deckID,
+			Limit:  sidebarPageSize + 1,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, row := range rows {
+			subs = append(subs, subWithCount(row.Subscription, row.UnwatchedCount, row.InDeck))
+		}
+	default:
+		groupID, err := strconv.ParseInt(group, 10, 64)
+		if err != nil {
+			return nil, false, err
+		}
+		rows, err := h.queries.ListGroupSubscriptionsPaginated(ctx, db.ListGroupSubscriptionsPaginatedParams{
+			DeckID:  deckID,
+			GroupID: groupID,
+			Limit:   sidebarPageSize + 1,
+			Offset:  offset,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		for _, row := range rows {
+			subs = append(subs, subWithCount(row.Subscription, row.UnwatchedCount, row.InDeck))
 		}
 	}
-	return subs
-}
 
-func (h *Handlers) filterRowsToSubs(rows []db.FilterSubscriptionsRow) []templates.SubscriptionWithCount {
-	subs := make([]templates.SubscriptionWithCount, len(rows))
-	for i, row := range rows {
-		subs[i] = templates.SubscriptionWithCount{
-			Subscription: db.Subscription{
-				ID:           row.ID,
-				Name:         row.Name,
-				YoutubeID:    row.YoutubeID,
-				Type:         row.Type,
-				ThumbnailUrl: row.ThumbnailUrl,
-				LastChecked:  row.LastChecked,
-				CreatedAt:    row.CreatedAt,
-				Position:     row.Position,
-				Active:       row.Active,
-			},
-			UnwatchedCount: row.UnwatchedCount,
-		}
+	hasMore := len(subs) > sidebarPageSize
+	if hasMore {
+		subs = subs[:sidebarPageSize]
 	}
-	return subs
+	return subs, hasMore, nil
 }
 
+// HandleReorder stores the order of the sidebar, with context "columns" the
+// order of the current deck's columns, and with context "queue" the order of
+// the watch-later queue.
 func (h *Handlers) HandleReorder(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		IDs     []string `json:"ids"`
@@ -412,15 +517,36 @@ func (h *Handlers) HandleReorder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
 	for i, idStr := range req.IDs {
 		id, err := strconv.ParseInt(idStr, 10, 64)
 		if err != nil {
 			continue
 		}
-		if err := h.queries.UpdateSubscriptionPosition(r.Context(), db.UpdateSubscriptionPositionParams{
-			Position: sql.NullInt64{Int64: int64(i), Valid: true},
-			ID:       id,
-		}); err != nil {
+		switch req.Context {
+		case "columns":
+			err = h.queries.UpdateDeckSubscriptionPosition(r.Context(), db.UpdateDeckSubscriptionPositionParams{
+				Position:       int64(i),
+				DeckID:         deckID,
+				SubscriptionID: sql.NullInt64{Int64: id, Valid: true},
+			})
+		case "queue":
+			err = h.queries.UpdateQueuePosition(r.Context(), db.UpdateQueuePositionParams{
+				Position: int64(i),
+				VideoID:  id,
+			})
+		default:
+			err = h.queries.UpdateSubscriptionPosition(r.Context(), db.UpdateSubscriptionPositionParams{
+				Position: sql.NullInt64{Int64: int64(i), Valid: true},
+				ID:       id,
+			})
+		}
+		if err != nil {
 			log.Printf("update position error: %v", err)
 		}
 	}
@@ -454,35 +580,152 @@ func (h *Handlers) HandleToggleHideShorts(w http.ResponseWriter, r *http.Request
 		log.Printf("update hide shorts error: %v", err)
 	}
 
-	count, err := h.queries.CountUnwatchedBySubscriptionFiltered(r.Context(), db.CountUnwatchedBySubscriptionFilteredParams{
-		SubscriptionID: id,
-		Column2:        newValue,
-	})
+	sub.HideShorts = sql.NullInt64{Int64: newValue, Valid: true}
+	h.renderColumnWithVideos(w, r, sub)
+}
+
+// HandleSetUpcomingMode sets how a subscription's column shows upcoming
+// livestreams and premieres.
+func (h *Handlers) HandleSetUpcomingMode(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	mode := r.FormValue("mode")
+	if !upcomingModes[mode] {
+		http.Error(w, "invalid mode", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.queries.UpdateSubscriptionUpcomingMode(r.Context(), db.UpdateSubscriptionUpcomingModeParams{
+		UpcomingMode: mode,
+		ID:           id,
+	}); err != nil {
+		log.Printf("update upcoming mode error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	sub.UpcomingMode = mode
+	h.renderColumnWithVideos(w, r, sub)
+}
+
+// HandleUpcomingVideos renders the upcoming broadcasts of a column that
+// shows them in a section of their own.
+func (h *Handlers) HandleUpcomingVideos(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	videos, err := h.queries.ListUpcomingVideos(r.Context(), id)
+	if err != nil {
+		log.Printf("list upcoming videos error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.ColumnUpcoming(id, videos).Render(r.Context(), w)
+}
+
+// parseDurationBound reads a duration bound given in whole minutes. A blank
+// value clears the bound.
+func parseDurationBound(value string) (sql.NullInt64, error) {
+	if value == "" {
+		return sql.NullInt64{}, nil
+	}
+	minutes, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || minutes < 0 {
+		return sql.NullInt64{}, fmt.Errorf("invalid minutes %q", value)
+	}
+	return sql.NullInt64{Int64: minutes * 60, Valid: true}, nil
+}
+
+// HandleSetDurationFilter sets the shortest and longest videos shown in a
+// subscription's column, in minutes.
+func (h *Handlers) HandleSetDurationFilter(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	minSeconds, err := parseDurationBound(r.FormValue("min"))
+	if err != nil {
+		http.Error(w, "invalid minimum duration", http.StatusBadRequest)
+		return
+	}
+	maxSeconds, err := parseDurationBound(r.FormValue("max"))
+	if err != nil {
+		http.Error(w, "invalid maximum duration", http.StatusBadRequest)
+		return
+	}
+	if minSeconds.Valid && maxSeconds.Valid && minSeconds.Int64 > maxSeconds.Int64 {
+		http.Error(w, "minimum duration exceeds maximum", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	if err := h.queries.UpdateSubscriptionDurationFilter(r.Context(), db.UpdateSubscriptionDurationFilterParams{
+		MinDurationSeconds: minSeconds,
+		MaxDurationSeconds: maxSeconds,
+		ID:                 id,
+	}); err != nil {
+		log.Printf("update duration filter error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	sub.MinDurationSeconds = minSeconds
+	sub.MaxDurationSeconds = maxSeconds
+	h.renderColumnWithVideos(w, r, sub)
+}
+
+// renderColumnWithVideos re-renders sub's column after its filters changed.
+func (h *Handlers) renderColumnWithVideos(w http.ResponseWriter, r *http.Request, sub db.Subscription) {
+	count, err := h.countColumnVideos(r.Context(), sub)
 	if err != nil {
 		log.Printf("count unwatched error: %v", err)
 	}
 
 	// Fetch videos directly instead of relying on lazy load
-	videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
-		SubscriptionID: id,
-		Column2:        newValue,
-		Limit:          columnVideoPageSize + 1,
-		Offset:         0,
-	})
+	videos, hasMoreDB, err := h.listColumnVideos(r.Context(), sub, 0)
 	if err != nil {
 		log.Printf("list videos error: %v", err)
 	}
 
-	hasMoreDB := len(videos) > columnVideoPageSize
-	if hasMoreDB {
-		videos = videos[:columnVideoPageSize]
+	mutedCount, err := h.countMutedVideos(r.Context(), sub)
+	if err != nil {
+		log.Printf("count muted error: %v", err)
+	}
+
+	goneCount, err := h.queries.CountGoneVideos(r.Context(), sub.ID)
+	if err != nil {
+		log.Printf("count gone error: %v", err)
 	}
 
 	canFetchMore := !sub.PageToken.Valid || sub.PageToken.String != ""
 
-	sub.HideShorts = sql.NullInt64{Int64: newValue, Valid: true}
 	_ = templates.ColumnWithVideos(templates.SubscriptionWithCount{
 		Subscription:   sub,
 		UnwatchedCount: count,
+		MutedCount:     mutedCount,
+		GoneCount:      goneCount,
 	}, videos, hasMoreDB, canFetchMore, int64(len(videos))).Render(r.Context(), w)
 }
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"youtube-deck-go/internal/db"
+)
+
+// currentDeckKey is the setting holding the ID of the deck shown at /.
+const currentDeckKey = "current_deck_id"
+
+// currentDeck returns the deck shown at /. It falls back to the first deck
+// when the remembered one has been deleted, and creates one when there are
+// none left.
+func (h *Handlers) currentDeck(ctx context.Context) (db.Deck, error) {
+	if value, err := h.queries.GetSetting(ctx, currentDeckKey); err == nil {
+		if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+			if deck, err := h.queries.GetDeck(ctx, id); err == nil {
+				return deck, nil
+			}
+		}
+	}
+
+	decks, err := h.queries.ListDecks(ctx)
+	if err != nil {
+		return db.Deck{}, err
+	}
+	var deck db.Deck
+	if len(decks) > 0 {
+		deck = decks[0]
+	} else if deck, err = h.queries.CreateDeck(ctx, "Main"); err != nil {
+		return db.Deck{}, err
+	}
+	return deck, h.setCurrentDeck(ctx, deck.ID)
+}
+
+func (h *Handlers) setCurrentDeck(ctx context.Context, id int64) error {
+	return h.queries.SetSetting(ctx, db.SetSettingParams{
+		Key:   currentDeckKey,
+		Value: strconv.FormatInt(id, 10),
+	})
+}
+
+// currentDeckID is currentDeck for handlers that only need the ID.
+func (h *Handlers) currentDeckID(ctx context.Context) (int64, error) {
+	deck, err := h.currentDeck(ctx)
+	return deck.ID, err
+}
+
+// HandleShowDeck renders a deck. It leaves the current deck as it is.
+func (h *Handlers) HandleShowDeck(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deck, err := h.queries.GetDeck(r.Context(), id)
+	if err != nil {
+		http.Error(w, "deck not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderDeck(w, r, deck)
+}
+
+// HandleSelectDeck makes the deck the current one and sends the browser to /.
+func (h *Handlers) HandleSelectDeck(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deck, err := h.queries.GetDeck(r.Context(), id)
+	if err != nil {
+		http.Error(w, "deck not found", http.StatusNotFound)
+		return
+	}
+	if err := h.setCurrentDeck(r.Context(), deck.ID); err != nil {
+		log.Printf("set current deck error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) HandleCreateDeck(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+
+	deck, err := h.queries.CreateDeck(r.Context(), name)
+	if err != nil {
+		log.Printf("create deck error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.setCurrentDeck(r.Context(), deck.ID); err != nil {
+		log.Printf("set current deck error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleDeleteDeck deletes a deck and its columns. The last deck can't be
+// deleted.
+func (h *Handlers) HandleDeleteDeck(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.GetDeck(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "deck not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	decks, err := h.queries.ListDecks(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if len(decks) <= 1 {
+		http.Error(w, "cannot delete the last deck", http.StatusConflict)
+		return
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// Virtual columns only ever belong to one deck, so they go with it.
+	qtx := h.queries.WithTx(tx)
+	cols, err := qtx.ListVirtualColumns(r.Context(), id)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	for _, col := range cols {
+		if err := qtx.DeleteVirtualColumn(r.Context(), col.ID); err != nil {
+			log.Printf("delete virtual column error: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := qtx.DeleteDeck(r.Context(), id); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/")
+	w.WriteHeader(http.StatusOK)
+}
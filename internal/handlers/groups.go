@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+)
+
+// renderSidebarList renders the first page of the sidebar: folders when
+// there are any, the flat subscription list otherwise.
+func (h *Handlers) renderSidebarList(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.queries.ListGroups(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if len(groups) > 0 {
+		_ = templates.SidebarGroups(groups).Render(r.Context(), w)
+		return
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	subs, hasMore, err := h.listSidebarPage(r.Context(), deckID, "", 0)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.SidebarList(subs, hasMore, "", int64(len(subs))).Render(r.Context(), w)
+}
+
+func (h *Handlers) HandleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		http.Error(w, "name required", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.CreateGroup(r.Context(), name); err != nil {
+		if db.IsUniqueViolation(err) {
+			http.Error(w, "folder already exists", http.StatusConflict)
+			return
+		}
+		log.Printf("create group error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderSidebarList(w, r)
+}
+
+// HandleDeleteGroup deletes a folder. Its subscriptions stay; folder columns
+// showing it close.
+func (h *Handlers) HandleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	cols, err := h.queries.ListVirtualColumns(r.Context(), deckID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.queries.DeleteGroup(r.Context(), id); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderSidebarList(w, r)
+	for _, col := range cols {
+		if col.GroupID.Valid && col.GroupID.Int64 == id {
+			_ = templates.VirtualColumnRemoveOOB(col.ID).Render(r.Context(), w)
+		}
+	}
+}
+
+// HandleSubscriptionGroups renders the folder picker of a subscription.
+func (h *Handlers) HandleSubscriptionGroups(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	h.renderGroupPicker(w, r, id)
+}
+
+// HandleSetSubscriptionGroups replaces the folders of a subscription with
+// the checked group_id values.
+func (h *Handlers) HandleSetSubscriptionGroups(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	var groupIDs []int64
+	for _, v := range r.Form["group_id"] {
+		groupID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid group", http.StatusBadRequest)
+			return
+		}
+		groupIDs = append(groupIDs, groupID)
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+	if err := qtx.ClearSubscriptionGroups(r.Context(), id); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	for _, groupID := range groupIDs {
+		if err := qtx.AddSubscriptionGroup(r.Context(), db.AddSubscriptionGroupParams{
+			SubscriptionID: id,
+			GroupID:        groupID,
+		}); err != nil {
+			log.Printf("add subscription group error: %v", err)
+			http.Error(w, "invalid group", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderGroupPicker(w, r, id)
+}
+
+func (h *Handlers) renderGroupPicker(w http.ResponseWriter, r *http.Request, subscriptionID int64) {
+	groups, err := h.queries.ListGroups(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	memberOf, err := h.queries.ListSubscriptionGroupIDs(r.Context(), subscriptionID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	checked := make(map[int64]bool, len(memberOf))
+	for _, id := range memberOf {
+		checked[id] = true
+	}
+	_ = templates.GroupPicker(subscriptionID, groups, checked).Render(r.Context(), w)
+}
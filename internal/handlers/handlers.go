@@ -1,39 +1,73 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/a-h/templ"
 
 	"youtube-deck-go/internal/auth"
 	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/events"
+	"youtube-deck-go/internal/jobs"
+	"youtube-deck-go/internal/quota"
 	"youtube-deck-go/internal/youtube"
 )
 
 type Handlers struct {
 	queries *db.Queries
 	db      *sql.DB
-	yt      *youtube.Client
+	yt      youtube.VideoSource
+	feed    youtube.Fetcher
 	auth    *auth.Manager
+	// account is the signed-in YouTube account, if any.
+	account youtube.AccountSource
+	events  *events.Hub
+	jobs    *jobs.Runner
+	quota   *quota.Ledger
 	log     *slog.Logger
+	// region is the ISO 3166-1 alpha-2 code videos are watched from, or
+	// empty to ignore region restrictions.
+	region string
 }
 
-func New(database *sql.DB, yt *youtube.Client, authMgr *auth.Manager, log *slog.Logger) *Handlers {
+func New(database *sql.DB, yt youtube.VideoSource, feed youtube.Fetcher, authMgr *auth.Manager, hub *events.Hub, runner *jobs.Runner, ledger *quota.Ledger, log *slog.Logger) *Handlers {
 	return &Handlers{
 		queries: db.New(database),
 		db:      database,
 		yt:      yt,
+		feed:    feed,
 		auth:    authMgr,
+		events:  hub,
+		jobs:    runner,
+		quota:   ledger,
 		log:     log,
 	}
 }
 
+// SetRegion sets the country videos are watched from, so that videos blocked
+// there count as unavailable.
+func (h *Handlers) SetRegion(region string) {
+	h.region = strings.ToUpper(region)
+}
+
 func (h *Handlers) render(w http.ResponseWriter, ctx context.Context, c templ.Component) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := c.Render(ctx, w); err != nil {
 		h.log.Error("render error", "error", err)
 	}
 }
+
+// publish renders c and pushes it to every open deck over the event stream.
+func (h *Handlers) publish(ctx context.Context, c templ.Component) {
+	var buf bytes.Buffer
+	if err := c.Render(ctx, &buf); err != nil {
+		h.log.Error("render event error", "error", err)
+		return
+	}
+	h.events.Publish(buf.String())
+}
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/jobs"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+// maxImportSize caps uploaded import files.
+const maxImportSize = 10 << 20
+
+// jobSubscriptionImport is the kind of the jobs importing uploaded files.
+const jobSubscriptionImport = "subscription-import"
+
+// importEntry is a subscription read from an import file.
+type importEntry struct {
+	// Name is what the file calls the subscription, if anything.
+	Name string
+	// Link points at the channel or playlist. Its Kind is empty when the
+	// entry isn't one.
+	Link youtube.Link
+	// Groups files the subscription in the groups of those names, which
+	// are created when missing.
+	Groups []string
+	// Source is the entry as written in the file, to report it by.
+	Source string
+	// Verify looks the entry up even when its ID and name are known, for
+	// entries that may not be public.
+	Verify bool
+}
+
+// label names the entry in an import report.
+func (e importEntry) label() string {
+	switch {
+	case e.Name != "":
+		return e.Name
+	case e.Link.ID != "":
+		return e.Link.ID
+	}
+	return e.Source
+}
+
+// HandleImportModal opens the modal for importing and exporting
+// subscriptions.
+func (h *Handlers) HandleImportModal(w http.ResponseWriter, r *http.Request) {
+	_ = templates.ImportModal().Render(r.Context(), w)
+}
+
+// importBatch is what an import file, or a part of it, has to import:
+// either subscriptions or a watch history.
+type importBatch struct {
+	// File names the batch's report.
+	File    string
+	Entries []importEntry
+	// History makes the batch the watch history of Videos.
+	History bool
+	Videos  []watchedVideo
+}
+
+// steps is how many steps of an import's progress the batch counts for.
+func (b importBatch) steps() int64 {
+	if b.History {
+		return 1
+	}
+	return int64(len(b.Entries))
+}
+
+// startImport imports batches in a background job, whose result is the JSON
+// of the reports of files that couldn't be read followed by those of the
+// batches, and brings up its toast. Dry runs change nothing and look nothing
+// up, so they are done right away and their reports shown instead, as are
+// uploads with nothing to import.
+func (h *Handlers) startImport(w http.ResponseWriter, r *http.Request, reports []templates.ImportReport, batches []importBatch, dryRun bool) {
+	ctx := r.Context()
+	if dryRun || len(batches) == 0 {
+		reports, err := h.importBatches(ctx, reports, batches, dryRun, nil)
+		if err != nil {
+			log.Printf("preview import: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = templates.SearchError("Failed to preview the import").Render(ctx, w)
+			return
+		}
+		h.renderImportReports(w, r, reports...)
+		return
+	}
+
+	_, err := h.jobs.Start(ctx, jobSubscriptionImport, func(ctx context.Context, progress jobs.Progress) (string, error) {
+		reports, err := h.importBatches(ctx, reports, batches, false, progress)
+		if err != nil {
+			return "", err
+		}
+		result, err := json.Marshal(reports)
+		return string(result), err
+	})
+	if errors.Is(err, jobs.ErrRunning) {
+		w.WriteHeader(http.StatusConflict)
+		_ = templates.SearchError("Another import is running; try again once it's done").Render(ctx, w)
+		return
+	}
+	if err != nil {
+		log.Printf("start import: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = templates.SearchError("Failed to start importing").Render(ctx, w)
+		return
+	}
+	list, err := h.queries.ListUndismissedJobs(ctx)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, ctx, templates.ImportStarted(list))
+}
+
+// importBatches imports batches and returns their reports after the given
+// ones. progress, if not nil, follows the entries imported.
+func (h *Handlers) importBatches(ctx context.Context, reports []templates.ImportReport, batches []importBatch, dryRun bool, progress jobs.Progress) ([]templates.ImportReport, error) {
+	var done, total int64
+	for _, b := range batches {
+		total += b.steps()
+	}
+	var step jobs.Progress
+	if progress != nil {
+		progress(0, total)
+		step = func(n, _ int64) { progress(done+n, total) }
+	}
+
+	reports = slices.Clone(reports)
+	for _, b := range batches {
+		var report templates.ImportReport
+		var err error
+		if b.History {
+			report, err = h.importWatchHistory(ctx, b.Videos, dryRun)
+		} else {
+			report, err = h.importSubscriptions(ctx, b.Entries, dryRun, step)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("import %s: %w", cmp.Or(b.File, "subscriptions"), err)
+		}
+		report.File = b.File
+		reports = append(reports, report)
+		done += b.steps()
+		if progress != nil {
+			progress(done, total)
+		}
+	}
+	return reports, nil
+}
+
+// HandleImportReport shows the reports of a finished import job.
+func (h *Handlers) HandleImportReport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	job, err := h.queries.GetJob(r.Context(), id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || job.Kind != jobSubscriptionImport || job.Status != jobs.StatusDone {
+		http.Error(w, "report not found", http.StatusNotFound)
+		return
+	}
+	var reports []templates.ImportReport
+	if err := json.Unmarshal([]byte(job.Result), &reports); err != nil {
+		log.Printf("read import report %d: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.ImportReportModal(reports))
+}
+
+// importUpload opens the file uploaded as "file", of at most limit bytes.
+func importUpload(w http.ResponseWriter, r *http.Request, limit int64) (io.ReadCloser, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("missing or oversized file: %w", err)
+	}
+	return file, nil
+}
+
+// importSubscriptions subscribes to every entry not subscribed to yet.
+// Entries naming a channel or playlist by ID and name cost no quota. Channels
+// known only by ID are looked up fifty at a time, and handles, names, videos
+// and the remaining entries one by one. New subscriptions have their videos
+// fetched by the scheduler.
+//
+// A dry run reports what an import would do without changing anything or
+// looking anything up; entries that need a lookup are reported as imported.
+// progress, if not nil, follows the entries gone through.
+func (h *Handlers) importSubscriptions(ctx context.Context, entries []importEntry, dryRun bool, progress jobs.Progress) (templates.ImportReport, error) {
+	report := templates.ImportReport{DryRun: dryRun}
+	subs, err := h.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return report, err
+	}
+	existing := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		existing[sub.YoutubeID] = true
+	}
+	var channels map[string]youtube.SearchResult
+	if !dryRun {
+		channels, err = h.lookupImportChannels(ctx, entries, existing)
+		if err != nil {
+			return report, err
+		}
+	}
+	groups := make(map[string]int64)
+
+	for i, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		if progress != nil && i > 0 {
+			progress(int64(i), int64(len(entries)))
+		}
+		if e.Link.Kind == "" {
+			report.Failed = append(report.Failed, templates.ImportFailure{Entry: e.label(), Reason: "not a YouTube channel or playlist"})
+			continue
+		}
+		if dryRun {
+			h.planImportEntry(&report, e, existing)
+			continue
+		}
+		result, err := h.resolveImportEntry(ctx, e, channels)
+		if errors.Is(err, youtube.ErrNotFound) {
+			report.Failed = append(report.Failed, templates.ImportFailure{Entry: e.label(), Reason: "not found on YouTube"})
+			continue
+		}
+		if err != nil {
+			log.Printf("resolve import entry %q: %v", e.label(), err)
+			report.Failed = append(report.Failed, templates.ImportFailure{Entry: e.label(), Reason: "couldn't be looked up"})
+			continue
+		}
+		if existing[result.ID] {
+			report.Skipped = append(report.Skipped, result.Title)
+			continue
+		}
+
+		sub, err := h.queries.CreateSubscription(ctx, db.CreateSubscriptionParams{
+			Name:         result.Title,
+			YoutubeID:    result.ID,
+			Type:         result.Type,
+			ThumbnailUrl: sql.NullString{String: result.ThumbnailURL, Valid: result.ThumbnailURL != ""},
+			SortOrder:    defaultSortOrder(result.Type),
+		})
+		if err != nil {
+			log.Printf("import subscription %q: %v", result.ID, err)
+			report.Failed = append(report.Failed, templates.ImportFailure{Entry: e.label(), Reason: "couldn't be saved"})
+			continue
+		}
+		existing[result.ID] = true
+		report.Imported = append(report.Imported, result.Title)
+
+		for _, name := range e.Groups {
+			groupID, err := h.importGroup(ctx, groups, name)
+			if err == nil {
+				err = h.queries.AddSubscriptionGroup(ctx, db.AddSubscriptionGroupParams{SubscriptionID: sub.ID, GroupID: groupID})
+			}
+			if err != nil {
+				log.Printf("file imported subscription %d in group %q: %v", sub.ID, name, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// planImportEntry reports what importing e would do, going by its ID where
+// it has one.
+func (h *Handlers) planImportEntry(report *templates.ImportReport, e importEntry, existing map[string]bool) {
+	if e.Link.Kind != youtube.LinkChannel && e.Link.Kind != youtube.LinkPlaylist {
+		report.Imported = append(report.Imported, e.label()+" (looked up on import)")
+		return
+	}
+	if existing[e.Link.ID] {
+		report.Skipped = append(report.Skipped, e.label())
+		return
+	}
+	existing[e.Link.ID] = true
+	report.Imported = append(report.Imported, e.label())
+}
+
+// lookupImportChannels looks up the channels among entries that are known
+// by ID alone, or are to be verified, and not subscribed to yet. Channels
+// that weren't found map to an empty result.
+func (h *Handlers) lookupImportChannels(ctx context.Context, entries []importEntry, existing map[string]bool) (map[string]youtube.SearchResult, error) {
+	var ids []string
+	for _, e := range entries {
+		if e.Link.Kind == youtube.LinkChannel && (e.Name == "" || e.Verify) && !existing[e.Link.ID] && !slices.Contains(ids, e.Link.ID) {
+			ids = append(ids, e.Link.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	infos, err := h.yt.GetChannels(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	channels := make(map[string]youtube.SearchResult, len(ids))
+	for _, id := range ids {
+		channels[id] = youtube.SearchResult{}
+	}
+	for _, c := range infos {
+		channels[c.ID] = youtube.SearchResult{ID: c.ID, Title: c.Title, ThumbnailURL: c.ThumbnailURL, Type: "channel"}
+	}
+	return channels, nil
+}
+
+// resolveImportEntry finds the channel or playlist of an entry, looking it
+// up only when the entry lacks an ID or a name or asks to be verified.
+// channels holds the channels looked up beforehand.
+func (h *Handlers) resolveImportEntry(ctx context.Context, e importEntry, channels map[string]youtube.SearchResult) (youtube.SearchResult, error) {
+	if result, ok := channels[e.Link.ID]; ok && e.Link.Kind == youtube.LinkChannel {
+		if result.ID == "" {
+			return result, youtube.ErrNotFound
+		}
+		return result, nil
+	}
+	if (e.Link.Kind == youtube.LinkChannel || e.Link.Kind == youtube.LinkPlaylist) && e.Name != "" && !e.Verify {
+		return youtube.SearchResult{ID: e.Link.ID, Title: e.Name, Type: e.Link.Kind}, nil
+	}
+	return h.yt.Resolve(ctx, e.Link)
+}
+
+// importGroup returns the ID of the group called name, creating it if
+// needed. ids caches the groups seen so far.
+func (h *Handlers) importGroup(ctx context.Context, ids map[string]int64, name string) (int64, error) {
+	if id, ok := ids[name]; ok {
+		return id, nil
+	}
+	group, err := h.queries.GetGroupByName(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		group, err = h.queries.CreateGroup(ctx, name)
+	}
+	if err != nil {
+		return 0, err
+	}
+	ids[name] = group.ID
+	return group.ID, nil
+}
+
+// renderImportReports shows what an import did, with a toast summing it up.
+func (h *Handlers) renderImportReports(w http.ResponseWriter, r *http.Request, reports ...templates.ImportReport) {
+	w.Header().Set("HX-Trigger", `{"showToast": "`+templates.ImportSummary(reports)+`"}`)
+	_ = templates.ImportResults(reports).Render(r.Context(), w)
+}
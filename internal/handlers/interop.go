@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"youtube-deck-go/internal/interop"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+// maxAppImportSize caps uploaded app exports. NewPipe's database holds its
+// whole watch history and feed cache, and easily outgrows maxImportSize.
+const maxAppImportSize = 100 << 20
+
+// HandleImportApps imports the subscriptions and watch history exported by
+// NewPipe, FreeTube or Invidious in the background, telling the app from the
+// file. With dry_run=1 it only reports what the import would change.
+func (h *Handlers) HandleImportApps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	file, err := importUpload(w, r, maxAppImportSize)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Choose an export file to import").Render(ctx, w)
+		return
+	}
+	defer file.Close()
+	dryRun := r.FormValue("dry_run") == "1"
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Failed to read the file").Render(ctx, w)
+		return
+	}
+	export, err := interop.Read(data)
+	if err != nil {
+		if !errors.Is(err, interop.ErrUnknownFormat) {
+			log.Printf("read app export: %v", err)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Not a NewPipe, FreeTube or Invidious export").Render(ctx, w)
+		return
+	}
+
+	h.startImport(w, r, nil, appExportBatches(export), dryRun)
+}
+
+// appExportBatches lists the subscriptions of export, filed in groups named
+// after the app's, then its watch history.
+func appExportBatches(export *interop.Export) []importBatch {
+	var batches []importBatch
+	if len(export.Subscriptions) > 0 || len(export.History) == 0 {
+		entries := make([]importEntry, len(export.Subscriptions))
+		for i, s := range export.Subscriptions {
+			link, _ := youtube.ParseLink(s.URL)
+			if s.ID != "" {
+				link = youtube.Link{Kind: s.Kind, ID: s.ID}
+			}
+			entries[i] = importEntry{Name: s.Name, Link: link, Groups: s.Groups, Source: cmp.Or(s.URL, s.ID)}
+		}
+		batches = append(batches, importBatch{File: export.App + " subscriptions", Entries: entries})
+	}
+	if len(export.History) > 0 {
+		videos := make([]watchedVideo, 0, len(export.History))
+		for _, watch := range export.History {
+			videos = append(videos, watchedVideo{ID: watch.VideoID, At: watch.Time})
+		}
+		batches = append(batches, importBatch{File: export.App + " watch history", History: true, Videos: videos})
+	}
+	return batches
+}
+
+// HandleExportNewPipe serves the channel subscriptions for NewPipe.
+func (h *Handlers) HandleExportNewPipe(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.exportSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	serveExport(w, "newpipe_subscriptions.json", "application/json", func(w io.Writer) error {
+		return interop.WriteNewPipe(w, subs)
+	})
+}
+
+// HandleExportFreeTubeProfiles serves the channel subscriptions for
+// FreeTube, with a profile for each group.
+func (h *Handlers) HandleExportFreeTubeProfiles(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.exportSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	serveExport(w, "freetube-profiles.db", "application/octet-stream", func(w io.Writer) error {
+		return interop.WriteFreeTubeProfiles(w, subs)
+	})
+}
+
+// HandleExportFreeTubeHistory serves the watched videos for FreeTube.
+func (h *Handlers) HandleExportFreeTubeHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.exportHistory(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	serveExport(w, "freetube-history.db", "application/octet-stream", func(w io.Writer) error {
+		return interop.WriteFreeTubeHistory(w, history)
+	})
+}
+
+// HandleExportInvidious serves the channel subscriptions and watched videos
+// for Invidious.
+func (h *Handlers) HandleExportInvidious(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.exportSubscriptions(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	history, err := h.exportHistory(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	serveExport(w, "subscription_manager.json", "application/json", func(w io.Writer) error {
+		return interop.WriteInvidious(w, subs, history)
+	})
+}
+
+func serveExport(w http.ResponseWriter, filename, contentType string, write func(io.Writer) error) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if err := write(w); err != nil {
+		log.Printf("write %s: %v", filename, err)
+	}
+}
+
+// exportSubscriptions lists every subscription with its groups.
+func (h *Handlers) exportSubscriptions(ctx context.Context) ([]interop.Subscription, error) {
+	rows, err := h.queries.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := h.queries.ListSubscriptionGroupNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[int64][]string)
+	for _, m := range memberships {
+		groups[m.SubscriptionID] = append(groups[m.SubscriptionID], m.GroupName)
+	}
+	subs := make([]interop.Subscription, len(rows))
+	for i, sub := range rows {
+		subs[i] = interop.Subscription{Kind: sub.Type, ID: sub.YoutubeID, Name: sub.Name, Groups: groups[sub.ID]}
+	}
+	return subs, nil
+}
+
+// exportHistory lists the watched videos, most recently watched first as far
+// as that's known. Videos of playlists are credited to the playlist, whose
+// uploaders aren't stored.
+func (h *Handlers) exportHistory(ctx context.Context) ([]interop.Watch, error) {
+	rows, err := h.queries.ListWatchedVideosForExport(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]interop.Watch, len(rows))
+	for i, v := range rows {
+		history[i] = interop.Watch{
+			VideoID:       v.YoutubeID,
+			Time:          v.WatchedAt.Time,
+			Title:         v.Title,
+			Author:        v.SubscriptionName,
+			LengthSeconds: v.DurationSeconds.Int64,
+			Published:     v.PublishedAt.Time,
+		}
+		if v.SubscriptionType == "channel" {
+			history[i].AuthorID = v.SubscriptionYoutubeID
+		}
+	}
+	return history, nil
+}
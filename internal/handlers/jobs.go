@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"youtube-deck-go/internal/templates"
+)
+
+// HandleJobs lists the background jobs that are running or finished and not
+// dismissed yet, as progress toasts. Every page loads it, so the toasts
+// survive a reload.
+func (h *Handlers) HandleJobs(w http.ResponseWriter, r *http.Request) {
+	list, err := h.queries.ListUndismissedJobs(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.JobToasts(list))
+}
+
+// HandleJob renders the toast of one job, which polls it while it runs.
+func (h *Handlers) HandleJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	job, err := h.queries.GetJob(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Gone: let the toast disappear.
+		return
+	}
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.JobToast(job))
+}
+
+// HandleCancelJob asks a running job to stop. The toast shows it cancelled
+// once the job has stopped.
+func (h *Handlers) HandleCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	h.jobs.Cancel(id)
+	h.HandleJob(w, r)
+}
+
+// HandleDismissJob hides the toast of a finished job.
+func (h *Handlers) HandleDismissJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	if err := h.queries.DismissJob(r.Context(), id); err != nil {
+		log.Printf("dismiss job %d: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
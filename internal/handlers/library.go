@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+)
+
+// libraryPageSize is how many search results are shown at a time.
+const libraryPageSize = 30
+
+// HandleLibrarySearchModal opens the modal for searching stored videos.
+func (h *Handlers) HandleLibrarySearchModal(w http.ResponseWriter, r *http.Request) {
+	subs, err := h.queries.ListSubscriptions(r.Context())
+	if err != nil {
+		log.Printf("list subscriptions error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.LibrarySearchModal(subs).Render(r.Context(), w)
+}
+
+// HandleLibrarySearch searches the titles and descriptions of stored videos
+// without spending API quota. Besides the query q it takes the filters
+// channel (a subscription id), watched (yes or no), shorts (only or exclude)
+// and the dates from and to (inclusive, YYYY-MM-DD), and pages with offset.
+func (h *Handlers) HandleLibrarySearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	params, err := parseLibraryFilters(query.Get("channel"), query.Get("watched"), query.Get("shorts"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params.Query = db.LibraryQuery(text)
+	if params.Query == "" {
+		_ = templates.LibrarySearchPrompt().Render(r.Context(), w)
+		return
+	}
+	offset, _ := strconv.ParseInt(query.Get("offset"), 10, 64)
+	params.Offset = max(offset, 0)
+	params.Limit = libraryPageSize + 1
+
+	rows, err := h.queries.SearchLibrary(r.Context(), params)
+	if err != nil {
+		log.Printf("search library error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	nextURL := ""
+	if len(rows) > libraryPageSize {
+		rows = rows[:libraryPageSize]
+		query.Set("offset", strconv.FormatInt(params.Offset+libraryPageSize, 10))
+		nextURL = "/library/search?" + query.Encode()
+	}
+	_ = templates.LibrarySearchResults(rows, params.Offset == 0, nextURL).Render(r.Context(), w)
+}
+
+// parseLibraryFilters reads the filters of a library search. Empty values
+// leave a filter off.
+func parseLibraryFilters(channel, watched, shorts, from, to string) (db.SearchLibraryParams, error) {
+	var p db.SearchLibraryParams
+	if channel != "" {
+		id, err := strconv.ParseInt(channel, 10, 64)
+		if err != nil {
+			return p, fmt.Errorf("invalid channel %q", channel)
+		}
+		p.SubscriptionID = sql.NullInt64{Int64: id, Valid: true}
+	}
+	switch watched {
+	case "":
+	case "yes":
+		p.Watched = sql.NullInt64{Int64: 1, Valid: true}
+	case "no":
+		p.Watched = sql.NullInt64{Int64: 0, Valid: true}
+	default:
+		return p, fmt.Errorf("invalid watched %q", watched)
+	}
+	switch shorts {
+	case "":
+	case "only":
+		p.Shorts = sql.NullInt64{Int64: 1, Valid: true}
+	case "exclude":
+		p.Shorts = sql.NullInt64{Int64: 0, Valid: true}
+	default:
+		return p, fmt.Errorf("invalid shorts %q", shorts)
+	}
+	if from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			return p, fmt.Errorf("invalid from %q", from)
+		}
+		p.PublishedAfter = sql.NullTime{Time: t, Valid: true}
+	}
+	if to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			return p, fmt.Errorf("invalid to %q", to)
+		}
+		p.PublishedBefore = sql.NullTime{Time: t.AddDate(0, 0, 1), Valid: true}
+	}
+	return p, nil
+}
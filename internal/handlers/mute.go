@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+)
+
+// muteRuleActions are the accepted values of a mute rule's action.
+var muteRuleActions = map[string]bool{"exclude": true, "include": true}
+
+// HandleMuteRules lists the mute rules that apply to a subscription.
+func (h *Handlers) HandleMuteRules(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	rules, err := h.queries.ListMuteRules(r.Context(), sql.NullInt64{Int64: id, Valid: true})
+	if err != nil {
+		log.Printf("list mute rules error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.MuteRules(id, rules).Render(r.Context(), w)
+}
+
+// HandleCreateMuteRule adds a mute rule for a subscription, or for every
+// subscription when scope is "global".
+func (h *Handlers) HandleCreateMuteRule(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	pattern := strings.TrimSpace(r.FormValue("pattern"))
+	if pattern == "" {
+		http.Error(w, "pattern required", http.StatusBadRequest)
+		return
+	}
+	isRegex := r.FormValue("regex") != ""
+	if isRegex {
+		if _, err := db.CompileMutePattern(pattern); err != nil {
+			w.Header().Set("HX-Trigger", `{"showToast": {"value": "Invalid regular expression", "type": "error"}}`)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	action := r.FormValue("action")
+	if !muteRuleActions[action] {
+		http.Error(w, "invalid action", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	global := r.FormValue("scope") == "global"
+	var regex int64
+	if isRegex {
+		regex = 1
+	}
+	rule, err := h.changeMuteRules(r.Context(), func(q *db.Queries) (db.MuteRule, error) {
+		return q.CreateMuteRule(r.Context(), db.CreateMuteRuleParams{
+			SubscriptionID: sql.NullInt64{Int64: id, Valid: !global},
+			Pattern:        pattern,
+			IsRegex:        regex,
+			Action:         action,
+		})
+	})
+	if err != nil {
+		log.Printf("create mute rule error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.renderMuteRulesChanged(w, r, rule, sub)
+}
+
+// HandleDeleteMuteRule deletes a mute rule.
+func (h *Handlers) HandleDeleteMuteRule(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := h.changeMuteRules(r.Context(), func(q *db.Queries) (db.MuteRule, error) {
+		return q.DeleteMuteRule(r.Context(), id)
+	})
+	if err == sql.ErrNoRows {
+		http.Error(w, "mute rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("delete mute rule error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	db.ForgetMuteRule(rule.ID)
+
+	var sub db.Subscription
+	if rule.SubscriptionID.Valid {
+		if sub, err = h.queries.GetSubscription(r.Context(), rule.SubscriptionID.Int64); err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+	}
+	h.renderMuteRulesChanged(w, r, rule, sub)
+}
+
+// changeMuteRules creates or deletes a mute rule with change, then updates
+// which videos the rules hide, in one transaction: the videos of the rule's
+// subscription, or every video for a global rule.
+func (h *Handlers) changeMuteRules(ctx context.Context, change func(q *db.Queries) (db.MuteRule, error)) (db.MuteRule, error) {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return db.MuteRule{}, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+	rule, err := change(qtx)
+	if err != nil {
+		return rule, err
+	}
+	if err := qtx.UpdateMutedVideos(ctx, rule.SubscriptionID); err != nil {
+		return rule, err
+	}
+	return rule, tx.Commit()
+}
+
+// renderMuteRulesChanged re-renders what a changed rule affects. A rule for
+// one subscription only changes its column and the river counts; a global
+// rule changes every column, so the page reloads.
+func (h *Handlers) renderMuteRulesChanged(w http.ResponseWriter, r *http.Request, rule db.MuteRule, sub db.Subscription) {
+	if !rule.SubscriptionID.Valid {
+		w.Header().Set("HX-Refresh", "true")
+		return
+	}
+	h.renderColumnWithVideos(w, r, sub)
+	h.publishRiverCounts(r.Context(), w)
+}
+
+// HandleMutedVideos reveals the latest unwatched videos the mute rules hide
+// from a subscription's column.
+func (h *Handlers) HandleMutedVideos(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	count, err := h.countMutedVideos(r.Context(), sub)
+	if err != nil {
+		log.Printf("count muted error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
+		SubscriptionID: sub.ID,
+		HideShorts:     hideShortsParam(sub),
+		HideUpcoming:   hideUpcomingParam(sub),
+		MinSeconds:     sub.MinDurationSeconds,
+		MaxSeconds:     sub.MaxDurationSeconds,
+		Muted:          1,
+		Sort:           sub.SortOrder,
+		Limit:          templates.MutedRevealLimit,
+	})
+	if err != nil {
+		log.Printf("list muted videos error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	_ = templates.MutedVideos(id, videos, count).Render(r.Context(), w)
+}
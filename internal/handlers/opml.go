@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"youtube-deck-go/internal/opml"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+// HandleExportOPML serves every subscription as an OPML feed list. Each group
+// becomes a folder of its members, so a subscription in several groups is
+// listed in each; ungrouped subscriptions sit at the top level.
+func (h *Handlers) HandleExportOPML(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subs, err := h.queries.ListSubscriptions(ctx)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	memberships, err := h.queries.ListSubscriptionGroupNames(ctx)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	var folders []string
+	members := make(map[string][]int64)
+	grouped := make(map[int64]bool)
+	for _, m := range memberships {
+		if _, ok := members[m.GroupName]; !ok {
+			folders = append(folders, m.GroupName)
+		}
+		members[m.GroupName] = append(members[m.GroupName], m.SubscriptionID)
+		grouped[m.SubscriptionID] = true
+	}
+
+	outlines := make(map[int64]opml.Outline, len(subs))
+	doc := opml.Document{Title: "YouTube Deck subscriptions"}
+	for _, sub := range subs {
+		htmlURL := "https://www.youtube.com/channel/" + sub.YoutubeID
+		if sub.Type == "playlist" {
+			htmlURL = "https://www.youtube.com/playlist?list=" + sub.YoutubeID
+		}
+		outlines[sub.ID] = opml.Outline{
+			Text:    sub.Name,
+			Title:   sub.Name,
+			Type:    "rss",
+			XMLURL:  youtube.FeedURL(sub.Type, sub.YoutubeID),
+			HTMLURL: htmlURL,
+		}
+	}
+	for _, name := range folders {
+		folder := opml.Outline{Text: name, Title: name}
+		for _, id := range members[name] {
+			folder.Outlines = append(folder.Outlines, outlines[id])
+		}
+		doc.Outlines = append(doc.Outlines, folder)
+	}
+	for _, sub := range subs {
+		if !grouped[sub.ID] {
+			doc.Outlines = append(doc.Outlines, outlines[sub.ID])
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.opml"`)
+	if err := doc.Write(w); err != nil {
+		log.Printf("write opml: %v", err)
+	}
+}
+
+// HandleImportOPML subscribes to the YouTube feeds of an uploaded OPML file
+// in the background, filing them in groups named after their folders. Feeds
+// are recognized by their feed URL, or failing that by their page URL.
+func (h *Handlers) HandleImportOPML(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	file, err := importUpload(w, r, maxImportSize)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Choose an OPML file to import").Render(ctx, w)
+		return
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Not a valid OPML file").Render(ctx, w)
+		return
+	}
+
+	feeds := doc.Feeds()
+	entries := make([]importEntry, len(feeds))
+	for i, feed := range feeds {
+		link, ok := youtube.ParseFeedURL(feed.XMLURL)
+		if !ok {
+			link, _ = youtube.ParseLink(feed.HTMLURL)
+		}
+		entries[i] = importEntry{
+			Name:   strings.TrimSpace(feed.Name()),
+			Link:   link,
+			Source: feed.XMLURL,
+		}
+		if feed.Folder != "" {
+			entries[i].Groups = []string{feed.Folder}
+		}
+	}
+
+	h.startImport(w, r, nil, []importBatch{{Entries: entries}}, false)
+}
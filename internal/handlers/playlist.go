@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/quota"
+	"youtube-deck-go/internal/youtube"
+)
+
+// playlistPosition returns the position to store for v. Only playlist
+// subscriptions keep one; a channel's uploads playlist is just newest first.
+func playlistPosition(sub db.Subscription, v youtube.VideoInfo) sql.NullInt64 {
+	if sub.Type != "playlist" || v.PlaylistPosition == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *v.PlaylistPosition, Valid: true}
+}
+
+// SyncPlaylistPositions reads every playlist subscription in full and stores
+// the current position of its videos, so that reordering a playlist upstream
+// carries over to its column. Videos taken out of the playlist lose their
+// position and sort last. It stops once the API budget runs low, and fails
+// when it runs out.
+func (h *Handlers) SyncPlaylistPositions(ctx context.Context) error {
+	subs, err := h.queries.ListPlaylistSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	synced := 0
+	for _, sub := range subs {
+		if h.quota.Degraded(ctx) {
+			break
+		}
+		positions, err := h.yt.PlaylistPositions(ctx, sub.YoutubeID)
+		if errors.Is(err, quota.ErrExhausted) {
+			return err
+		}
+		if err != nil {
+			h.log.Warn("fetch playlist positions", "id", sub.ID, "error", err)
+			continue
+		}
+		if err := h.storePlaylistPositions(ctx, sub.ID, positions); err != nil {
+			return err
+		}
+		synced++
+	}
+	h.log.Info("synced playlist positions", "playlists", len(subs), "synced", synced)
+	return nil
+}
+
+// storePlaylistPositions replaces the positions of a subscription's videos
+// with positions, keyed by YouTube video ID.
+func (h *Handlers) storePlaylistPositions(ctx context.Context, subID int64, positions map[string]int64) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+	if err := qtx.ClearPlaylistPositions(ctx, subID); err != nil {
+		return err
+	}
+	for videoID, pos := range positions {
+		if err := qtx.UpdateVideoPlaylistPosition(ctx, db.UpdateVideoPlaylistPositionParams{
+			PlaylistPosition: sql.NullInt64{Int64: pos, Valid: true},
+			SubscriptionID:   subID,
+			YoutubeID:        videoID,
+		}); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
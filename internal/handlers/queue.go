@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"youtube-deck-go/internal/templates"
+)
+
+// HandleEnqueueVideo adds a video to the end of the watch-later queue.
+func (h *Handlers) HandleEnqueueVideo(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.GetVideo(r.Context(), id); err != nil {
+		http.Error(w, "video not found", http.StatusNotFound)
+		return
+	}
+
+	added, err := h.queries.EnqueueVideo(r.Context(), id)
+	if err != nil {
+		log.Printf("enqueue video error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if added == 0 {
+		w.Header().Set("HX-Trigger", `{"showToast": "Already in the queue"}`)
+		return
+	}
+	w.Header().Set("HX-Trigger", `{"showToast": "Added to the queue"}`)
+
+	items, err := h.queries.ListQueue(r.Context())
+	if err != nil {
+		log.Printf("list queue error: %v", err)
+		return
+	}
+	for _, item := range items {
+		if item.Video.ID == id {
+			_ = templates.QueueItemAddedOOB(item, items).Render(r.Context(), w)
+			h.publish(r.Context(), templates.QueueItemAddedOOB(item, items))
+			break
+		}
+	}
+	h.publishRiverCounts(r.Context(), w)
+}
+
+// HandleDequeueVideo removes a video from the queue without marking it as
+// watched.
+func (h *Handlers) HandleDequeueVideo(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.queries.DequeueVideo(r.Context(), id); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.renderDequeued(r.Context(), w, id)
+}
+
+// renderDequeued removes a video that just left the queue from every open
+// deck.
+func (h *Handlers) renderDequeued(ctx context.Context, w http.ResponseWriter, videoID int64) {
+	items, err := h.queries.ListQueue(ctx)
+	if err != nil {
+		log.Printf("list queue error: %v", err)
+		return
+	}
+	_ = templates.QueueItemRemovedOOB(videoID, items).Render(ctx, w)
+	h.publish(ctx, templates.QueueItemRemovedOOB(videoID, items))
+	h.publishRiverCounts(ctx, w)
+}
+
+// publishRiverCounts refreshes the unwatched counts of the virtual columns,
+// which include the length of the queue.
+func (h *Handlers) publishRiverCounts(ctx context.Context, w http.ResponseWriter) {
+	deckID, err := h.currentDeckID(ctx)
+	if err != nil {
+		log.Printf("current deck error: %v", err)
+		return
+	}
+	rivers, err := h.listRiverColumns(ctx, deckID)
+	if err != nil {
+		log.Printf("list virtual columns error: %v", err)
+		return
+	}
+	_ = templates.VirtualColumnCountsOOB(rivers).Render(ctx, w)
+	h.publish(ctx, templates.VirtualColumnCountsOOB(rivers))
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"youtube-deck-go/internal/templates"
+)
+
+func (h *Handlers) HandleQuota(w http.ResponseWriter, r *http.Request) {
+	status, err := h.quota.Status(r.Context())
+	if err != nil {
+		h.log.Error("quota status error", "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.QuotaGauge(status))
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+)
+
+// riverKindName returns the default name of a kind of virtual column.
+func riverKindName(kind string) (string, bool) {
+	for _, k := range templates.RiverKinds {
+		if k.Kind == kind {
+			return k.Label, true
+		}
+	}
+	return "", false
+}
+
+// startOfToday returns local midnight, which bounds the "Today" column.
+func startOfToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// riverSince returns the oldest publish time shown in a virtual column.
+// Times are compared as stored, so they must be in UTC like the videos.
+func riverSince(col db.VirtualColumn) sql.NullTime {
+	if col.Kind == "today" {
+		return sql.NullTime{Time: startOfToday().UTC(), Valid: true}
+	}
+	return sql.NullTime{Time: time.Unix(0, 0).UTC(), Valid: true}
+}
+
+// riverGroupID returns the folder a virtual column is limited to, or zero.
+func riverGroupID(col db.VirtualColumn) int64 {
+	if col.Kind == "folder" && col.GroupID.Valid {
+		return col.GroupID.Int64
+	}
+	return 0
+}
+
+// countRiverVideos counts the videos in a virtual column; for the queue
+// column that is the length of the queue.
+func (h *Handlers) countRiverVideos(ctx context.Context, col db.VirtualColumn) (int64, error) {
+	if col.Kind == "queue" {
+		return h.queries.CountQueue(ctx)
+	}
+	return h.queries.CountRiverVideos(ctx, db.CountRiverVideosParams{
+		Since:   riverSince(col),
+		GroupID: riverGroupID(col),
+	})
+}
+
+// listRiverColumns returns the virtual columns open on a deck with their
+// unwatched counts.
+func (h *Handlers) listRiverColumns(ctx context.Context, deckID int64) ([]templates.VirtualColumnWithCount, error) {
+	cols, err := h.queries.ListVirtualColumns(ctx, deckID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]templates.VirtualColumnWithCount, len(cols))
+	for i, col := range cols {
+		count, err := h.countRiverVideos(ctx, col)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = templates.VirtualColumnWithCount{VirtualColumn: col, UnwatchedCount: count}
+	}
+	return result, nil
+}
+
+// HandleAddVirtualColumn opens a virtual column on the current deck. Folder
+// columns take the folder in group_id.
+func (h *Handlers) HandleAddVirtualColumn(w http.ResponseWriter, r *http.Request) {
+	kind := r.FormValue("kind")
+	var groupID sql.NullInt64
+	name, ok := riverKindName(kind)
+	if kind == "folder" {
+		id, err := strconv.ParseInt(r.FormValue("group_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid group", http.StatusBadRequest)
+			return
+		}
+		group, err := h.queries.GetGroup(r.Context(), id)
+		if err != nil {
+			http.Error(w, "group not found", http.StatusNotFound)
+			return
+		}
+		name, ok = group.Name, true
+		groupID = sql.NullInt64{Int64: group.ID, Valid: true}
+	}
+	if !ok {
+		http.Error(w, "invalid column kind", http.StatusBadRequest)
+		return
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	open, err := h.queries.ListVirtualColumns(r.Context(), deckID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	for _, col := range open {
+		if col.Kind == kind && col.GroupID == groupID {
+			http.Error(w, "column already open", http.StatusConflict)
+			return
+		}
+	}
+
+	tx, err := h.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// A column that isn't on a deck is never shown, so it's only created
+	// along with its place on the deck.
+	qtx := h.queries.WithTx(tx)
+	col, err := qtx.CreateVirtualColumn(r.Context(), db.CreateVirtualColumnParams{
+		Kind:    kind,
+		Name:    name,
+		GroupID: groupID,
+	})
+	if err != nil {
+		log.Printf("create virtual column error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := qtx.AddDeckVirtualColumn(r.Context(), db.AddDeckVirtualColumnParams{
+		DeckID:          deckID,
+		VirtualColumnID: sql.NullInt64{Int64: col.ID, Valid: true},
+	}); err != nil {
+		log.Printf("add deck column error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("commit virtual column error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	cols, err := h.listRiverColumns(r.Context(), deckID)
+	if err != nil {
+		log.Printf("list virtual columns error: %v", err)
+	}
+	count, err := h.countRiverVideos(r.Context(), col)
+	if err != nil {
+		log.Printf("count river videos error: %v", err)
+	}
+
+	_ = templates.VirtualColumn(templates.VirtualColumnWithCount{VirtualColumn: col, UnwatchedCount: count}).Render(r.Context(), w)
+	_ = templates.RiverTogglesOOB(cols).Render(r.Context(), w)
+	_ = templates.DeckEmptyStateRemoveOOB().Render(r.Context(), w)
+}
+
+func (h *Handlers) HandleDeleteVirtualColumn(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.DeleteVirtualColumn(r.Context(), id); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	cols, err := h.listRiverColumns(r.Context(), deckID)
+	if err != nil {
+		log.Printf("list virtual columns error: %v", err)
+	}
+	_ = templates.RiverTogglesOOB(cols).Render(r.Context(), w)
+}
+
+// HandleVirtualColumn renders a whole virtual column; open decks use it to
+// catch up after losing the event stream.
+func (h *Handlers) HandleVirtualColumn(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	col, err := h.queries.GetVirtualColumn(r.Context(), id)
+	if err != nil {
+		http.Error(w, "column not found", http.StatusNotFound)
+		return
+	}
+	count, err := h.countRiverVideos(r.Context(), col)
+	if err != nil {
+		log.Printf("count river videos error: %v", err)
+	}
+
+	_ = templates.VirtualColumn(templates.VirtualColumnWithCount{VirtualColumn: col, UnwatchedCount: count}).Render(r.Context(), w)
+}
+
+// HandleVirtualColumnVideos renders one page of a virtual column. Pages are
+// keyed by the publish time and ID of the last video shown rather than an
+// offset, so videos marked as watched in the meantime don't shift them.
+func (h *Handlers) HandleVirtualColumnVideos(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	col, err := h.queries.GetVirtualColumn(r.Context(), id)
+	if err != nil {
+		http.Error(w, "column not found", http.StatusNotFound)
+		return
+	}
+
+	// The queue is short enough to show whole.
+	if col.Kind == "queue" {
+		items, err := h.queries.ListQueue(r.Context())
+		if err != nil {
+			log.Printf("list queue error: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		_ = templates.QueueVideos(items).Render(r.Context(), w)
+		return
+	}
+
+	// The first page starts past any possible video.
+	before := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	beforeID := int64(math.MaxInt64)
+	firstPage := true
+	if s := r.URL.Query().Get("before"); s != "" {
+		nanos, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		beforeID, err = strconv.ParseInt(r.URL.Query().Get("before_id"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		before = time.Unix(0, nanos).UTC()
+		firstPage = false
+	}
+
+	videos, err := h.queries.ListRiverVideos(r.Context(), db.ListRiverVideosParams{
+		Since:    riverSince(col),
+		GroupID:  riverGroupID(col),
+		Before:   sql.NullTime{Time: before, Valid: true},
+		BeforeID: beforeID,
+		Limit:    columnVideoPageSize + 1,
+	})
+	if err != nil {
+		log.Printf("list river videos error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	hasMore := len(videos) > columnVideoPageSize
+	if hasMore {
+		videos = videos[:columnVideoPageSize]
+	}
+
+	_ = templates.RiverVideos(id, videos, hasMore, firstPage).Render(r.Context(), w)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 
 	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
 )
 
 func (h *Handlers) HandleSearch(w http.ResponseWriter, r *http.Request) {
@@ -21,6 +24,20 @@ func (h *Handlers) HandleSearchResults(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A pasted link is looked up directly for a unit or two, so it keeps
+	// working when search is paused.
+	if link, ok := youtube.ParseLink(query); ok {
+		h.renderResolvedLink(w, r, link)
+		return
+	}
+
+	// Search costs 100 units per call, so it is the first thing to go once
+	// the daily budget runs low.
+	if h.quota.Degraded(r.Context()) {
+		_ = templates.SearchError("The daily YouTube API budget is nearly used up. Search is paused until it resets at midnight Pacific time.").Render(r.Context(), w)
+		return
+	}
+
 	searchType := r.URL.Query().Get("type")
 	if searchType == "" {
 		searchType = "channel"
@@ -45,6 +62,23 @@ func (h *Handlers) HandleSearchResults(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// renderResolvedLink shows the channel or playlist a pasted link leads to as
+// the only search result, to be added like any other.
+func (h *Handlers) renderResolvedLink(w http.ResponseWriter, r *http.Request, link youtube.Link) {
+	result, err := h.yt.Resolve(r.Context(), link)
+	if errors.Is(err, youtube.ErrNotFound) {
+		_ = templates.SearchResults(nil).Render(r.Context(), w)
+		return
+	}
+	if err != nil {
+		log.Printf("resolve %s %q: %v", link.Kind, link.ID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = templates.SearchError("Couldn't look up that link. Please try again.").Render(r.Context(), w)
+		return
+	}
+	_ = templates.SearchResults([]youtube.SearchResult{result}).Render(r.Context(), w)
+}
+
 func (h *Handlers) HandleSearchClose(w http.ResponseWriter, r *http.Request) {
 	_ = templates.SearchClose().Render(r.Context(), w)
 }
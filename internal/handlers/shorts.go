@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/jobs"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+const (
+	// shortsRetryBatch is how many unknown videos one retry pass probes.
+	shortsRetryBatch = 100
+	// shortsRetryBackoff is the wait after the first failed probe; it doubles
+	// with every further attempt.
+	shortsRetryBackoff = 15
+	// shortsMaxAttempts is how often a video is probed before it is left
+	// unknown for good, short of a reclassification.
+	shortsMaxAttempts = 8
+	// reclassifyBatch is how many videos a reclassification fetches and
+	// probes at a time, as many as one videos.list call takes.
+	reclassifyBatch = 50
+)
+
+// shortVerdict maps a classification to the is_short and short_source
+// columns: 1 or 0 when decided, NULL when unknown.
+func shortVerdict(v youtube.VideoInfo) (sql.NullInt64, sql.NullString) {
+	switch v.Short {
+	case youtube.ShortsYes:
+		return sql.NullInt64{Int64: 1, Valid: true}, sql.NullString{String: v.ShortSource, Valid: true}
+	case youtube.ShortsNo:
+		return sql.NullInt64{Int64: 0, Valid: true}, sql.NullString{String: v.ShortSource, Valid: true}
+	}
+	return sql.NullInt64{}, sql.NullString{}
+}
+
+// RetryUnknownShorts probes the videos whose Shorts verdict is still unknown
+// and due for another attempt. A video found to be a Short leaves the column
+// of a subscription that hides them.
+func (h *Handlers) RetryUnknownShorts(ctx context.Context) error {
+	videos, err := h.queries.ListUnknownShorts(ctx, db.ListUnknownShortsParams{
+		MaxAttempts:    shortsMaxAttempts,
+		BackoffMinutes: shortsRetryBackoff,
+		Limit:          shortsRetryBatch,
+	})
+	if err != nil || len(videos) == 0 {
+		return err
+	}
+
+	infos := make([]youtube.VideoInfo, len(videos))
+	for i, v := range videos {
+		infos[i] = youtube.VideoInfo{ID: v.YoutubeID, Duration: v.Duration.String}
+	}
+	infos = h.yt.CheckShortsParallel(ctx, infos)
+
+	decided := 0
+	for i, v := range videos {
+		isShort, source := shortVerdict(infos[i])
+		updated, err := h.queries.UpdateVideoShort(ctx, db.UpdateVideoShortParams{
+			IsShort:     isShort,
+			ShortSource: source,
+			ID:          v.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if !isShort.Valid {
+			continue
+		}
+		decided++
+		if isShort.Int64 == 1 && updated.Watched.Int64 == 0 {
+			h.publishShortHidden(ctx, updated)
+		}
+	}
+	h.log.Info("retried unknown shorts", "probed", len(videos), "decided", decided)
+	return nil
+}
+
+// publishShortHidden takes a video that turned out to be a Short off its
+// column when the subscription hides Shorts.
+func (h *Handlers) publishShortHidden(ctx context.Context, v db.Video) {
+	sub, err := h.queries.GetSubscription(ctx, v.SubscriptionID)
+	if err != nil {
+		log.Printf("load subscription %d: %v", v.SubscriptionID, err)
+		return
+	}
+	if hideShortsParam(sub) == 0 {
+		return
+	}
+	count, err := h.countColumnVideos(ctx, sub)
+	if err != nil {
+		log.Printf("count unwatched error: %v", err)
+		return
+	}
+	h.publish(ctx, templates.VideoWatchedOOB(v.ID, sub.ID, count))
+}
+
+// jobShortsReclassify is the kind of the jobs HandleReclassifyShorts starts.
+const jobShortsReclassify = "shorts-reclassify"
+
+// HandleReclassifyShorts starts a job that forgets the Shorts verdicts of a
+// subscription's videos and classifies them again from fresh metadata.
+func (h *Handlers) HandleReclassifyShorts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(ctx, id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = h.jobs.Start(ctx, jobShortsReclassify, func(ctx context.Context, progress jobs.Progress) (string, error) {
+		n, err := h.reclassifyShorts(ctx, sub, progress)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Reclassified %d videos of %s", n, sub.Name), nil
+	})
+	if errors.Is(err, jobs.ErrRunning) {
+		w.Header().Set("HX-Trigger", `{"showToast": {"value": "Another reclassification is running; try again once it's done", "type": "error"}}`)
+		http.Error(w, "reclassification running", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("start reclassifying shorts for subscription %d: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	list, err := h.queries.ListUndismissedJobs(ctx)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, ctx, templates.JobToastsOOB(list))
+}
+
+// reclassifyShorts classifies every stored video of sub again and returns
+// how many there are. Metadata is fetched anew, a batch at a time, so that
+// the player size is known; once the API budget runs low, the stored
+// duration and the probe have to do. Running out of quota altogether stops
+// the job. The old verdicts are replaced in one transaction at the end, so
+// a job that stops early leaves them as they were.
+func (h *Handlers) reclassifyShorts(ctx context.Context, sub db.Subscription, progress jobs.Progress) (int, error) {
+	rows, err := h.queries.ListSubscriptionVideoIDs(ctx, sub.ID)
+	if err != nil || len(rows) == 0 {
+		return 0, err
+	}
+
+	verdicts := make([]youtube.VideoInfo, 0, len(rows))
+	for start := 0; start < len(rows); start += reclassifyBatch {
+		chunk := rows[start:min(start+reclassifyBatch, len(rows))]
+
+		infos := make(map[string]youtube.VideoInfo, len(chunk))
+		if !h.quota.Degraded(ctx) {
+			ids := make([]string, len(chunk))
+			for i, row := range chunk {
+				ids[i] = row.YoutubeID
+			}
+			fetched, err := h.yt.GetVideos(ctx, ids)
+			if err != nil {
+				return 0, err
+			}
+			for _, info := range fetched {
+				infos[info.ID] = info
+			}
+		}
+
+		batch := make([]youtube.VideoInfo, len(chunk))
+		for i, row := range chunk {
+			info, ok := infos[row.YoutubeID]
+			if !ok {
+				info = youtube.VideoInfo{ID: row.YoutubeID, Duration: row.Duration.String}
+			}
+			batch[i] = info
+		}
+		verdicts = append(verdicts, h.yt.CheckShortsParallel(ctx, batch)...)
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		progress(int64(len(verdicts)), int64(len(rows)))
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	if err := qtx.ResetSubscriptionShorts(ctx, sub.ID); err != nil {
+		return 0, err
+	}
+	var hidden []db.Video
+	for i, row := range rows {
+		isShort, source := shortVerdict(verdicts[i])
+		updated, err := qtx.UpdateVideoShort(ctx, db.UpdateVideoShortParams{
+			IsShort:     isShort,
+			ShortSource: source,
+			ID:          row.ID,
+		})
+		if err != nil {
+			return 0, err
+		}
+		if isShort.Int64 == 1 && updated.Watched.Int64 == 0 {
+			hidden = append(hidden, updated)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	for _, v := range hidden {
+		h.publishShortHidden(ctx, v)
+	}
+	return len(rows), nil
+}
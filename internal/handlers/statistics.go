@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+const (
+	// statsRecentFor is how long after publication a video's statistics
+	// keep being refreshed; older videos keep their last counts.
+	statsRecentFor = 7 * 24 * time.Hour
+	// statsStaleMinutes is how old statistics get before they are refreshed.
+	statsStaleMinutes = 6 * 60
+	// statsRefreshBatch caps the videos refreshed in one pass.
+	statsRefreshBatch = 200
+)
+
+// sortOrders are the ways a column can order its videos.
+var sortOrders = map[string]bool{"newest": true, "oldest": true, "popular": true, "playlist": true}
+
+// validSortOrder reports whether sub's videos can be ordered by sort. Only
+// playlists have a playlist order.
+func validSortOrder(sub db.Subscription, sort string) bool {
+	return sortOrders[sort] && (sort != "playlist" || sub.Type == "playlist")
+}
+
+// videoStatistics returns the counters to store for v, all NULL when the
+// Data API wasn't asked.
+func videoStatistics(v youtube.VideoInfo) (views, likes, comments sql.NullInt64) {
+	if v.Statistics == nil {
+		return
+	}
+	return sql.NullInt64{Int64: v.Statistics.Views, Valid: true},
+		sql.NullInt64{Int64: v.Statistics.Likes, Valid: true},
+		sql.NullInt64{Int64: v.Statistics.Comments, Valid: true}
+}
+
+// RefreshVideoStatistics fetches fresh statistics and descriptions for
+// recently published videos whose counts are getting old, and updates their
+// cards on every open deck. It does nothing once the API budget runs low.
+func (h *Handlers) RefreshVideoStatistics(ctx context.Context) error {
+	if h.quota.Degraded(ctx) {
+		return nil
+	}
+	videos, err := h.queries.ListVideosForStatsRefresh(ctx, db.ListVideosForStatsRefreshParams{
+		PublishedSince: sql.NullTime{Time: time.Now().UTC().Add(-statsRecentFor), Valid: true},
+		StaleMinutes:   statsStaleMinutes,
+		Limit:          statsRefreshBatch,
+	})
+	if err != nil || len(videos) == 0 {
+		return err
+	}
+
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.YoutubeID
+	}
+	infos, err := h.yt.GetVideos(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]youtube.VideoInfo, len(infos))
+	for _, info := range infos {
+		byID[info.ID] = info
+	}
+
+	var updated []db.Video
+	for _, v := range videos {
+		info, ok := byID[v.YoutubeID]
+		if !ok || info.Statistics == nil {
+			continue
+		}
+		views, likes, comments := videoStatistics(info)
+		video, err := h.queries.UpdateVideoStatistics(ctx, db.UpdateVideoStatisticsParams{
+			Description:  sql.NullString{String: info.Description, Valid: true},
+			ViewCount:    views,
+			LikeCount:    likes,
+			CommentCount: comments,
+			ID:           v.ID,
+		})
+		if err != nil {
+			return err
+		}
+		updated = append(updated, video)
+	}
+	if len(updated) > 0 {
+		h.publish(ctx, templates.VideoStatsOOB(updated))
+	}
+	h.log.Info("refreshed video statistics", "videos", len(updated))
+	return nil
+}
+
+// HandleSetSortOrder switches the order of a column's videos: newest,
+// oldest or most viewed first, or the order of the playlist.
+func (h *Handlers) HandleSetSortOrder(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	sort := r.FormValue("sort")
+	if !validSortOrder(sub, sort) {
+		http.Error(w, "invalid sort", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.UpdateSubscriptionSortOrder(r.Context(), db.UpdateSubscriptionSortOrderParams{
+		SortOrder: sort,
+		ID:        id,
+	}); err != nil {
+		log.Printf("update sort order error: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	sub.SortOrder = sort
+	h.renderColumnWithVideos(w, r, sub)
+}
@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
 	"strconv"
+	"time"
 
 	"youtube-deck-go/internal/db"
 	"youtube-deck-go/internal/templates"
@@ -29,7 +34,7 @@ func (h *Handlers) HandleAddSubscription(w http.ResponseWriter, r *http.Request)
 		Name:         req.Name,
 		Type:         req.Type,
 		ThumbnailUrl: sql.NullString{String: req.ThumbnailURL, Valid: req.ThumbnailURL != ""},
-		Active:       sql.NullInt64{Int64: 0, Valid: true},
+		SortOrder:    defaultSortOrder(req.Type),
 	})
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
@@ -37,22 +42,12 @@ func (h *Handlers) HandleAddSubscription(w http.ResponseWriter, r *http.Request)
 	}
 
 	var unwatchedCount int64
-	if req.Type == "channel" {
-		vids, err := h.yt.FetchChannelVideos(r.Context(), req.YoutubeID, 20)
-		if err == nil {
-			if err := h.saveVideos(r, sub.ID, vids); err != nil {
-				log.Printf("save videos error: %v", err)
-			}
-			unwatchedCount = int64(len(vids))
-		}
-	} else {
-		vids, err := h.yt.FetchPlaylistVideos(r.Context(), req.YoutubeID, 20)
-		if err == nil {
-			if err := h.saveVideos(r, sub.ID, vids); err != nil {
-				log.Printf("save videos error: %v", err)
-			}
-			unwatchedCount = int64(len(vids))
+	result, err := h.fetchVideos(r.Context(), &sub, "")
+	if err == nil {
+		if err := h.saveVideos(r.Context(), sub, result.Videos); err != nil {
+			log.Printf("save videos error: %v", err)
 		}
+		unwatchedCount = int64(len(result.Videos))
 	}
 	if err := h.queries.UpdateSubscriptionChecked(r.Context(), sub.ID); err != nil {
 		log.Printf("update subscription checked error: %v", err)
@@ -64,6 +59,13 @@ func (h *Handlers) HandleAddSubscription(w http.ResponseWriter, r *http.Request)
 	}).Render(r.Context(), w)
 }
 
+// defaultSortOrder is the sort order new subscriptions of type kind start
+// with. Playlists are often courses or series, meant to be watched in order;
+// everything else falls back to the column default.
+func defaultSortOrder(kind string) sql.NullString {
+	return sql.NullString{String: "playlist", Valid: kind == "playlist"}
+}
+
 func (h *Handlers) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -94,64 +96,245 @@ func (h *Handlers) HandleRefreshSubscription(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if err := h.RefreshSubscription(r.Context(), sub); err != nil {
+		log.Printf("refresh subscription %d error: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderSubscription(w, r, sub)
+}
+
+// HandleReloadColumn re-renders a subscription from the database without
+// calling the YouTube API. Fetching new videos is left to the scheduler.
+func (h *Handlers) HandleReloadColumn(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.queries.GetSubscription(r.Context(), id)
+	if err != nil {
+		http.Error(w, "subscription not found", http.StatusNotFound)
+		return
+	}
+
+	h.renderSubscription(w, r, sub)
+}
+
+// RefreshSubscription fetches the latest videos of sub from YouTube, stores
+// the new ones, re-checks pending broadcasts and bumps last_checked. It backs
+// both the refresh endpoint and the background scheduler.
+func (h *Handlers) RefreshSubscription(ctx context.Context, sub db.Subscription) error {
+	videos, err := h.fetchLatest(ctx, &sub)
+	if err != nil {
+		return err
+	}
+	if err := h.saveVideos(ctx, sub, videos); err != nil {
+		return err
+	}
+	if err := h.recheckBroadcasts(ctx, sub); err != nil {
+		h.log.Warn("recheck broadcasts", "id", sub.ID, "error", err)
+	}
+	return h.queries.UpdateSubscriptionChecked(ctx, sub.ID)
+}
+
+// fetchLatest reads sub's newest uploads from its Atom feed, which costs no
+// quota, and only asks the Data API for the details of videos not stored yet.
+// If the feed can't be read it falls back to the Data API altogether.
+func (h *Handlers) fetchLatest(ctx context.Context, sub *db.Subscription) ([]youtube.VideoInfo, error) {
+	var videos []youtube.VideoInfo
+	var err error
 	if sub.Type == "channel" {
-		vids, err := h.yt.FetchChannelVideos(r.Context(), sub.YoutubeID, 20)
+		videos, err = h.feed.FetchChannelVideos(ctx, sub.YoutubeID, 20)
+	} else {
+		videos, err = h.feed.FetchPlaylistVideos(ctx, sub.YoutubeID, 20)
+	}
+	if err != nil {
+		h.log.Warn("feed fetch failed, falling back to the Data API", "id", sub.ID, "error", err)
+		result, err := h.fetchVideos(ctx, sub, "")
 		if err != nil {
-			http.Error(w, "internal error", http.StatusInternalServerError)
-			return
-		}
-		if err = h.saveVideos(r, sub.ID, vids); err != nil {
-			http.Error(w, "internal error", http.StatusInternalServerError)
-			return
+			return nil, err
 		}
-	} else {
-		vids, err := h.yt.FetchPlaylistVideos(r.Context(), sub.YoutubeID, 20)
+		return result.Videos, nil
+	}
+
+	var unseen []youtube.VideoInfo
+	for _, v := range videos {
+		exists, err := h.queries.VideoExistsByYoutubeID(ctx, v.ID)
 		if err != nil {
-			http.Error(w, "internal error", http.StatusInternalServerError)
-			return
+			return nil, err
 		}
-		if err = h.saveVideos(r, sub.ID, vids); err != nil {
-			http.Error(w, "internal error", http.StatusInternalServerError)
-			return
+		if exists == 0 {
+			unseen = append(unseen, v)
 		}
 	}
+	if len(unseen) == 0 {
+		return nil, nil
+	}
 
-	if err := h.queries.UpdateSubscriptionChecked(r.Context(), id); err != nil {
-		log.Printf("update subscription checked error: %v", err)
+	enriched, err := h.yt.EnrichVideos(ctx, unseen)
+	if err != nil {
+		// Save what the feed gave us rather than losing the new uploads.
+		h.log.Warn("enrich videos failed", "id", sub.ID, "error", err)
+		return unseen, nil
 	}
+	return enriched, nil
+}
 
-	hideShorts := int64(0)
-	if sub.HideShorts.Valid {
-		hideShorts = sub.HideShorts.Int64
+// fetchVideos fetches a page of sub's videos from the Data API. Channels are
+// read through their uploads playlist, which is looked up and stored on the
+// subscription the first time it is needed.
+func (h *Handlers) fetchVideos(ctx context.Context, sub *db.Subscription, pageToken string) (*youtube.FetchResult, error) {
+	playlistID := sub.YoutubeID
+	if sub.Type == "channel" {
+		if !sub.UploadsPlaylistID.Valid || sub.UploadsPlaylistID.String == "" {
+			channels, err := h.yt.GetChannels(ctx, []string{sub.YoutubeID})
+			if err != nil {
+				return nil, err
+			}
+			if len(channels) == 0 {
+				return nil, fmt.Errorf("channel not found: %s", sub.YoutubeID)
+			}
+			if err := h.storeChannelInfo(ctx, sub, channels[0]); err != nil {
+				log.Printf("store channel info error: %v", err)
+			}
+		}
+		playlistID = sub.UploadsPlaylistID.String
+		if playlistID == "" {
+			return nil, fmt.Errorf("channel has no uploads playlist: %s", sub.YoutubeID)
+		}
 	}
+	return h.yt.FetchPlaylistVideosWithToken(ctx, playlistID, pageToken, 20)
+}
 
-	unwatchedCount, err := h.queries.CountUnwatchedBySubscriptionFiltered(r.Context(), db.CountUnwatchedBySubscriptionFilteredParams{
-		SubscriptionID: id,
-		Column2:        hideShorts,
+// storeChannelInfo saves channel metadata on sub, both in the database and
+// in the passed struct.
+func (h *Handlers) storeChannelInfo(ctx context.Context, sub *db.Subscription, info youtube.ChannelInfo) error {
+	sub.UploadsPlaylistID = sql.NullString{String: info.UploadsPlaylistID, Valid: info.UploadsPlaylistID != ""}
+	sub.CustomUrl = sql.NullString{String: info.CustomURL, Valid: info.CustomURL != ""}
+	sub.SubscriberCount = sql.NullInt64{Int64: info.SubscriberCount, Valid: info.SubscriberCount > 0}
+	return h.queries.UpdateSubscriptionChannelInfo(ctx, db.UpdateSubscriptionChannelInfoParams{
+		UploadsPlaylistID: sub.UploadsPlaylistID,
+		CustomUrl:         sub.CustomUrl,
+		SubscriberCount:   sub.SubscriberCount,
+		ID:                sub.ID,
 	})
+}
+
+// BackfillChannelInfo stores the uploads playlist and channel metadata of
+// channel subscriptions created before they were cached, 50 channels per
+// API call.
+func (h *Handlers) BackfillChannelInfo(ctx context.Context) error {
+	subs, err := h.queries.ListChannelsMissingUploads(ctx)
 	if err != nil {
-		log.Printf("count unwatched error: %v", err)
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
 	}
 
-	swc := templates.SubscriptionWithCount{
-		Subscription:   sub,
-		UnwatchedCount: unwatchedCount,
+	ids := make([]string, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.YoutubeID
+	}
+	channels, err := h.yt.GetChannels(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]youtube.ChannelInfo, len(channels))
+	for _, c := range channels {
+		byID[c.ID] = c
 	}
+	stored := 0
+	for i := range subs {
+		info, ok := byID[subs[i].YoutubeID]
+		if !ok {
+			continue
+		}
+		if err := h.storeChannelInfo(ctx, &subs[i], info); err != nil {
+			return err
+		}
+		stored++
+	}
+	h.log.Info("backfilled channel info", "channels", stored, "missing", len(subs)-stored)
+	return nil
+}
+
+// durationBackfillBatch is how many videos BackfillDurations updates per
+// query.
+const durationBackfillBatch = 500
 
-	if sub.Active.Valid && sub.Active.Int64 == 1 {
-		videos, err := h.queries.ListUnwatchedVideosPaginatedFiltered(r.Context(), db.ListUnwatchedVideosPaginatedFilteredParams{
-			SubscriptionID: id,
-			Column2:        hideShorts,
-			Limit:          11,
-			Offset:         0,
+// BackfillDurations stores duration_seconds for videos saved before it was
+// parsed on insert. Durations that don't parse are logged and left NULL.
+func (h *Handlers) BackfillDurations(ctx context.Context) error {
+	var afterID int64
+	stored := 0
+	for {
+		rows, err := h.queries.ListVideosMissingDurationSeconds(ctx, db.ListVideosMissingDurationSecondsParams{
+			AfterID: afterID,
+			Limit:   durationBackfillBatch,
 		})
 		if err != nil {
-			log.Printf("list videos error: %v", err)
+			return err
+		}
+		for _, row := range rows {
+			afterID = row.ID
+			d, err := youtube.ParseDuration(row.Duration.String)
+			if err != nil {
+				h.log.Warn("skip video duration", "video", row.ID, "error", err)
+				continue
+			}
+			if err := h.queries.UpdateVideoDurationSeconds(ctx, db.UpdateVideoDurationSecondsParams{
+				DurationSeconds: sql.NullInt64{Int64: int64(d / time.Second), Valid: true},
+				ID:              row.ID,
+			}); err != nil {
+				return err
+			}
+			stored++
+		}
+		if len(rows) < durationBackfillBatch {
+			break
 		}
+	}
+	if stored > 0 {
+		h.log.Info("backfilled video durations", "videos", stored)
+	}
+	return nil
+}
 
-		hasMoreDB := len(videos) > 10
-		if hasMoreDB {
-			videos = videos[:10]
+// renderSubscription renders sub as a deck column when it is on the current
+// deck and as a subscription card otherwise.
+func (h *Handlers) renderSubscription(w http.ResponseWriter, r *http.Request, sub db.Subscription) {
+	unwatchedCount, err := h.countColumnVideos(r.Context(), sub)
+	if err != nil {
+		log.Printf("count unwatched error: %v", err)
+	}
+
+	var inDeck int64
+	if deckID, err := h.currentDeckID(r.Context()); err != nil {
+		log.Printf("current deck error: %v", err)
+	} else if inDeck, err = h.queries.IsSubscriptionInDeck(r.Context(), db.IsSubscriptionInDeckParams{
+		DeckID:         deckID,
+		SubscriptionID: sql.NullInt64{Int64: sub.ID, Valid: true},
+	}); err != nil {
+		log.Printf("deck membership error: %v", err)
+	}
+	swc := subWithCount(sub, unwatchedCount, inDeck)
+
+	if swc.InDeck {
+		if swc.MutedCount, err = h.countMutedVideos(r.Context(), sub); err != nil {
+			log.Printf("count muted error: %v", err)
+		}
+		if swc.GoneCount, err = h.queries.CountGoneVideos(r.Context(), sub.ID); err != nil {
+			log.Printf("count gone error: %v", err)
+		}
+		videos, hasMoreDB, err := h.listColumnVideos(r.Context(), sub, 0)
+		if err != nil {
+			log.Printf("list videos error: %v", err)
 		}
 
 		canFetchMore := !sub.PageToken.Valid || sub.PageToken.String != ""
@@ -161,30 +344,209 @@ func (h *Handlers) HandleRefreshSubscription(w http.ResponseWriter, r *http.Requ
 	}
 }
 
-func (h *Handlers) saveVideos(r *http.Request, subID int64, vids []youtube.VideoInfo) error {
+// refreshIntervals are the per-subscription refresh intervals offered in the
+// column settings, in seconds. Zero falls back to the scheduler default.
+var refreshIntervals = map[int64]bool{0: true, 300: true, 900: true, 1800: true, 3600: true, 21600: true, 86400: true}
+
+func (h *Handlers) HandleSetRefreshInterval(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	seconds, err := strconv.ParseInt(r.FormValue("interval"), 10, 64)
+	if err != nil || !refreshIntervals[seconds] {
+		http.Error(w, "invalid interval", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.queries.UpdateSubscriptionRefreshInterval(r.Context(), db.UpdateSubscriptionRefreshIntervalParams{
+		RefreshInterval: sql.NullInt64{Int64: seconds, Valid: seconds > 0},
+		ID:              id,
+	}); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// videoDuration returns the duration of v as stored: the ISO-8601 string and
+// its length in seconds, each NULL when unknown.
+func videoDuration(v youtube.VideoInfo) (sql.NullString, sql.NullInt64) {
+	var seconds sql.NullInt64
+	if d, err := youtube.ParseDuration(v.Duration); err == nil {
+		seconds = sql.NullInt64{Int64: int64(d / time.Second), Valid: true}
+	}
+	return sql.NullString{String: v.Duration, Valid: v.Duration != ""}, seconds
+}
+
+// broadcastState returns the state to store for v. Videos read from a feed
+// carry none, and are taken to be regular uploads.
+func broadcastState(v youtube.VideoInfo) string {
+	if v.BroadcastState == "" {
+		return youtube.BroadcastNone
+	}
+	return v.BroadcastState
+}
+
+// recheckBroadcasts asks the Data API about the stored upcoming and live
+// broadcasts of sub, so that a premiere turns into a regular video once it
+// ends. Changed cards are updated on every open deck.
+func (h *Handlers) recheckBroadcasts(ctx context.Context, sub db.Subscription) error {
+	pending, err := h.queries.ListPendingBroadcasts(ctx, sub.ID)
+	if err != nil || len(pending) == 0 {
+		return err
+	}
+
+	ids := make([]string, len(pending))
+	for i, v := range pending {
+		ids[i] = v.YoutubeID
+	}
+	infos, err := h.yt.GetVideos(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]youtube.VideoInfo, len(infos))
+	for _, info := range infos {
+		byID[info.ID] = info
+	}
+
+	for _, v := range pending {
+		info, ok := byID[v.YoutubeID]
+		if !ok || broadcastState(info) == v.BroadcastState {
+			continue
+		}
+		duration, seconds := videoDuration(info)
+		updated, err := h.queries.UpdateVideoBroadcast(ctx, db.UpdateVideoBroadcastParams{
+			BroadcastState:   broadcastState(info),
+			ScheduledStartAt: sql.NullTime{Time: info.ScheduledStartAt, Valid: !info.ScheduledStartAt.IsZero()},
+			Duration:         duration,
+			DurationSeconds:  seconds,
+			PublishedAt:      sql.NullTime{Time: info.PublishedAt, Valid: !info.PublishedAt.IsZero()},
+			ID:               v.ID,
+		})
+		if err != nil {
+			return err
+		}
+		h.publish(ctx, templates.VideoBroadcastOOB(updated))
+	}
+	return nil
+}
+
+// saveVideos stores vids for sub, skipping videos that are already known, and
+// pushes the newly stored uploads to every open deck.
+func (h *Handlers) saveVideos(ctx context.Context, sub db.Subscription, vids []youtube.VideoInfo) error {
 	if len(vids) == 0 {
 		return nil
 	}
 
-	vids = h.yt.CheckShortsParallel(r.Context(), vids)
+	// Only videos newer than everything stored so far belong at the top of
+	// the column; older ones (fetch-more) show up through pagination.
+	latest, err := h.queries.GetLatestVideoPublishedAt(ctx, sub.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	rules, err := h.queries.ListMuteRules(ctx, sql.NullInt64{Int64: sub.ID, Valid: true})
+	if err != nil {
+		return err
+	}
+
+	vids = h.yt.CheckShortsParallel(ctx, vids)
 
+	var fresh []db.Video
+	var inserted []int64
 	for _, v := range vids {
-		isShort := int64(0)
-		if v.IsShort {
-			isShort = 1
-		}
-		_, err := h.queries.CreateVideo(r.Context(), db.CreateVideoParams{
-			SubscriptionID: subID,
-			YoutubeID:      v.ID,
-			Title:          v.Title,
-			ThumbnailUrl:   sql.NullString{String: v.ThumbnailURL, Valid: v.ThumbnailURL != ""},
-			Duration:       sql.NullString{String: v.Duration, Valid: v.Duration != ""},
-			PublishedAt:    sql.NullTime{Time: v.PublishedAt, Valid: !v.PublishedAt.IsZero()},
-			IsShort:        sql.NullInt64{Int64: isShort, Valid: true},
+		isShort, shortSource := shortVerdict(v)
+		duration, seconds := videoDuration(v)
+		views, likes, comments := videoStatistics(v)
+		var statsUpdatedAt sql.NullTime
+		if v.Statistics != nil {
+			statsUpdatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+		}
+		var muted int64
+		if db.MuteRules(rules).Mute(v.Title) {
+			muted = 1
+		}
+		video, err := h.queries.CreateVideo(ctx, db.CreateVideoParams{
+			SubscriptionID:   sub.ID,
+			YoutubeID:        v.ID,
+			Title:            v.Title,
+			ThumbnailUrl:     sql.NullString{String: v.ThumbnailURL, Valid: v.ThumbnailURL != ""},
+			Duration:         duration,
+			DurationSeconds:  seconds,
+			PublishedAt:      sql.NullTime{Time: v.PublishedAt, Valid: !v.PublishedAt.IsZero()},
+			IsShort:          isShort,
+			ShortSource:      shortSource,
+			BroadcastState:   broadcastState(v),
+			ScheduledStartAt: sql.NullTime{Time: v.ScheduledStartAt, Valid: !v.ScheduledStartAt.IsZero()},
+			Description:      sql.NullString{String: v.Description, Valid: v.Statistics != nil},
+			ViewCount:        views,
+			LikeCount:        likes,
+			CommentCount:     comments,
+			StatsUpdatedAt:   statsUpdatedAt,
+			PlaylistPosition: playlistPosition(sub, v),
+			Muted:            muted,
 		})
-		if err != nil && err != sql.ErrNoRows {
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
 			return err
 		}
+		inserted = append(inserted, video.ID)
+		// A column sorted by popularity has no top for new uploads to go to.
+		if sub.SortOrder == "newest" && video.PublishedAt.Valid && (!latest.Valid || video.PublishedAt.Time.After(latest.Time)) &&
+			columnShows(sub, video) && video.Muted == 0 {
+			fresh = append(fresh, video)
+		}
+	}
+
+	if len(inserted) == 0 {
+		return nil
+	}
+	// Videos in an imported watch history arrive watched.
+	watched, err := h.queries.MarkNewVideosWatchedFromHistory(ctx, inserted)
+	if err != nil {
+		return err
 	}
+	fresh = slices.DeleteFunc(fresh, func(v db.Video) bool {
+		return slices.Contains(watched, v.ID)
+	})
+	h.publishVideosSaved(ctx, sub, fresh)
 	return nil
 }
+
+func (h *Handlers) publishVideosSaved(ctx context.Context, sub db.Subscription, fresh []db.Video) {
+	count, err := h.countColumnVideos(ctx, sub)
+	if err != nil {
+		log.Printf("count unwatched error: %v", err)
+		return
+	}
+	sort.Slice(fresh, func(i, j int) bool {
+		return fresh[i].PublishedAt.Time.After(fresh[j].PublishedAt.Time)
+	})
+	h.publish(ctx, templates.VideosSavedOOB(sub.ID, fresh, count))
+
+	deckID, err := h.currentDeckID(ctx)
+	if err != nil {
+		log.Printf("current deck error: %v", err)
+		return
+	}
+	rivers, err := h.listRiverColumns(ctx, deckID)
+	if err != nil {
+		log.Printf("list virtual columns error: %v", err)
+		return
+	}
+	if len(rivers) == 0 {
+		return
+	}
+	groupIDs, err := h.queries.ListSubscriptionGroupIDs(ctx, sub.ID)
+	if err != nil {
+		log.Printf("list subscription groups error: %v", err)
+	}
+	h.publish(ctx, templates.RiverVideosSavedOOB(sub, fresh, startOfToday(), groupIDs, rivers))
+}
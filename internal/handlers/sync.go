@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"youtube-deck-go/internal/auth"
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/jobs"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+// SetAccount sets the YouTube account subscriptions are synced from.
+// Without one, syncing asks the user to sign in.
+func (h *Handlers) SetAccount(account youtube.AccountSource) {
+	h.account = account
+}
+
+// jobSubscriptionSync is the kind of the sync jobs.
+const jobSubscriptionSync = "subscription-sync"
+
+// syncRejection identifies a rejected change. value is the rejected name of
+// a rename and the rejected avatar of an avatar change.
+type syncRejection struct {
+	kind, youtubeID, value string
+}
+
+// SyncAccountSubscriptions starts the sync job HandleSync starts, which
+// compares the subscriptions of the YouTube account with the stored ones and
+// records the differences for review. It does nothing while nobody is signed
+// in or while a sync is running already.
+func (h *Handlers) SyncAccountSubscriptions(ctx context.Context) error {
+	if h.account == nil {
+		return nil
+	}
+	_, err := h.jobs.Start(ctx, jobSubscriptionSync, h.syncJob)
+	if errors.Is(err, jobs.ErrRunning) {
+		return nil
+	}
+	return err
+}
+
+// syncAccount replaces the pending changes with the differences between the
+// account and the stored subscriptions, and returns how many there are.
+// progress, if not nil, follows the listing of the account's subscriptions.
+//
+// A channel subscribed to on YouTube but not stored is to be added, and a
+// stored one whose name or avatar changed is to be updated. A stored channel
+// is only to be removed once it's gone from an account it was in at the
+// previous sync, so subscriptions added in the deck alone are left alone.
+// Changes rejected before aren't proposed again.
+func (h *Handlers) syncAccount(ctx context.Context, progress jobs.Progress) (int, error) {
+	if h.account == nil {
+		return 0, auth.ErrNotSignedIn
+	}
+	upstream, err := h.account.MySubscriptions(ctx, progress)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	subs, err := qtx.ListSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	stored := make(map[string]db.Subscription, len(subs))
+	for _, s := range subs {
+		stored[s.YoutubeID] = s
+	}
+	previous, err := qtx.ListAccountSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pending, err := qtx.ListSyncChanges(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rejectionRows, err := qtx.ListSyncRejections(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rejected := make(map[syncRejection]bool, len(rejectionRows))
+	for _, r := range rejectionRows {
+		rejected[syncRejection{r.Kind, r.YoutubeID, r.Value}] = true
+	}
+
+	var changes []db.AddSyncChangeParams
+	propose := func(kind string, channel youtube.SearchResult, value string) {
+		if rejected[syncRejection{kind, channel.ID, value}] {
+			return
+		}
+		changes = append(changes, db.AddSyncChangeParams{
+			Kind:         kind,
+			YoutubeID:    channel.ID,
+			Name:         channel.Title,
+			ThumbnailUrl: sql.NullString{String: channel.ThumbnailURL, Valid: channel.ThumbnailURL != ""},
+		})
+	}
+	subscribed := make(map[string]bool, len(upstream))
+	for _, channel := range upstream {
+		if channel.ID == "" || subscribed[channel.ID] {
+			continue
+		}
+		subscribed[channel.ID] = true
+		sub, ok := stored[channel.ID]
+		if !ok {
+			propose("add", channel, "")
+			continue
+		}
+		if channel.Title != "" && channel.Title != sub.Name {
+			propose("rename", channel, channel.Title)
+		}
+		if avatar := avatarKey(channel.ThumbnailURL); avatar != "" && avatar != avatarKey(sub.ThumbnailUrl.String) {
+			propose("avatar", channel, avatar)
+		}
+	}
+	// Removals still pending are kept, since the previous sync's account
+	// no longer lists their channels.
+	gone := previous
+	for _, c := range pending {
+		if c.Kind == "remove" {
+			gone = append(gone, c.YoutubeID)
+		}
+	}
+	removed := make(map[string]bool)
+	for _, id := range gone {
+		sub, ok := stored[id]
+		if subscribed[id] || removed[id] || !ok || sub.Type != "channel" {
+			continue
+		}
+		removed[id] = true
+		propose("remove", youtube.SearchResult{ID: id, Title: sub.Name, ThumbnailURL: sub.ThumbnailUrl.String}, "")
+	}
+
+	if err := qtx.ClearSyncChanges(ctx); err != nil {
+		return 0, err
+	}
+	for _, c := range changes {
+		if err := qtx.AddSyncChange(ctx, c); err != nil {
+			return 0, err
+		}
+	}
+	if err := qtx.ClearAccountSubscriptions(ctx); err != nil {
+		return 0, err
+	}
+	for _, channel := range upstream {
+		if channel.ID == "" {
+			continue
+		}
+		if err := qtx.AddAccountSubscription(ctx, channel.ID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(changes), nil
+}
+
+// avatarKey drops the size and crop options YouTube appends to avatar URLs
+// after "=", which change without the picture changing.
+func avatarKey(url string) string {
+	key, _, _ := strings.Cut(url, "=")
+	return key
+}
+
+// HandleSyncModal shows the changes found by the last sync.
+func (h *Handlers) HandleSyncModal(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.syncChanges(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.SyncModal(changes))
+}
+
+// HandleSync starts syncing with the account in the background, replacing
+// the list with a note to come back once the job's toast says it's done.
+// A sync already running is shown instead of starting another.
+func (h *Handlers) HandleSync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if h.account == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError(templates.SignInToSync).Render(ctx, w)
+		return
+	}
+	_, err := h.jobs.Start(ctx, jobSubscriptionSync, h.syncJob)
+	if err != nil && !errors.Is(err, jobs.ErrRunning) {
+		log.Printf("start subscription sync: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = templates.SearchError("Failed to start syncing").Render(ctx, w)
+		return
+	}
+	list, err := h.queries.ListUndismissedJobs(ctx)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, ctx, templates.SyncStarted(list))
+}
+
+// syncJob is the job HandleSync starts. Its result is shown in the job's
+// toast as it is. Not being signed in is reported as auth.ErrNotSignedIn
+// itself, however deep it was wrapped, for the toast to ask the user to sign
+// in.
+func (h *Handlers) syncJob(ctx context.Context, progress jobs.Progress) (string, error) {
+	n, err := h.syncAccount(ctx, progress)
+	if errors.Is(err, auth.ErrNotSignedIn) {
+		return "", auth.ErrNotSignedIn
+	}
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "Subscriptions are in sync", nil
+	}
+	return fmt.Sprintf("Found %d changes to review", n), nil
+}
+
+// HandleAcceptSyncChange applies a pending change.
+func (h *Handlers) HandleAcceptSyncChange(w http.ResponseWriter, r *http.Request) {
+	h.resolveSyncChange(w, r, h.acceptSyncChange)
+}
+
+// HandleRejectSyncChange drops a pending change and remembers not to
+// propose it again.
+func (h *Handlers) HandleRejectSyncChange(w http.ResponseWriter, r *http.Request) {
+	h.resolveSyncChange(w, r, rejectSyncChange)
+}
+
+// HandleAcceptSyncChanges applies every pending change.
+func (h *Handlers) HandleAcceptSyncChanges(w http.ResponseWriter, r *http.Request) {
+	h.resolveSyncChanges(w, r, h.acceptSyncChange)
+}
+
+// HandleRejectSyncChanges rejects every pending change.
+func (h *Handlers) HandleRejectSyncChanges(w http.ResponseWriter, r *http.Request) {
+	h.resolveSyncChanges(w, r, rejectSyncChange)
+}
+
+func (h *Handlers) resolveSyncChange(w http.ResponseWriter, r *http.Request, resolve func(context.Context, *db.Queries, db.SyncChange) error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	change, err := qtx.GetSyncChange(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "change not found", http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		err = resolve(ctx, qtx, change)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("resolve sync change %d: %v", id, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.renderSyncChanges(w, r)
+}
+
+func (h *Handlers) resolveSyncChanges(w http.ResponseWriter, r *http.Request, resolve func(context.Context, *db.Queries, db.SyncChange) error) {
+	ctx := r.Context()
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := h.queries.WithTx(tx)
+
+	changes, err := qtx.ListSyncChanges(ctx)
+	for i := 0; err == nil && i < len(changes); i++ {
+		c := changes[i]
+		err = resolve(ctx, qtx, db.SyncChange{ID: c.ID, Kind: c.Kind, YoutubeID: c.YoutubeID, Name: c.Name, ThumbnailUrl: c.ThumbnailUrl})
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		log.Printf("resolve sync changes: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.renderSyncChanges(w, r)
+}
+
+// acceptSyncChange applies c to the stored subscriptions. Changes to
+// subscriptions deleted meanwhile are dropped.
+func (h *Handlers) acceptSyncChange(ctx context.Context, q *db.Queries, c db.SyncChange) error {
+	sub, err := q.GetSubscriptionByYoutubeID(ctx, c.YoutubeID)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	switch {
+	case c.Kind == "add" && !found:
+		_, err = q.CreateSubscription(ctx, db.CreateSubscriptionParams{
+			Name:         c.Name,
+			YoutubeID:    c.YoutubeID,
+			Type:         "channel",
+			ThumbnailUrl: c.ThumbnailUrl,
+			SortOrder:    defaultSortOrder("channel"),
+		})
+	case c.Kind == "remove" && found:
+		err = q.DeleteSubscription(ctx, sub.ID)
+	case c.Kind == "rename" && found:
+		err = q.UpdateSubscriptionName(ctx, db.UpdateSubscriptionNameParams{Name: c.Name, ID: sub.ID})
+	case c.Kind == "avatar" && found:
+		err = q.UpdateSubscriptionThumbnail(ctx, db.UpdateSubscriptionThumbnailParams{ThumbnailUrl: c.ThumbnailUrl, ID: sub.ID})
+	}
+	if err != nil {
+		return err
+	}
+	return q.DeleteSyncChange(ctx, c.ID)
+}
+
+func rejectSyncChange(ctx context.Context, q *db.Queries, c db.SyncChange) error {
+	rejection := db.AddSyncRejectionParams{Kind: c.Kind, YoutubeID: c.YoutubeID}
+	switch c.Kind {
+	case "rename":
+		rejection.Value = c.Name
+	case "avatar":
+		rejection.Value = avatarKey(c.ThumbnailUrl.String)
+	}
+	if err := q.AddSyncRejection(ctx, rejection); err != nil {
+		return err
+	}
+	return q.DeleteSyncChange(ctx, c.ID)
+}
+
+func (h *Handlers) renderSyncChanges(w http.ResponseWriter, r *http.Request) {
+	changes, err := h.syncChanges(r.Context())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, r.Context(), templates.SyncChanges(changes))
+}
+
+func (h *Handlers) syncChanges(ctx context.Context) ([]templates.SyncChange, error) {
+	rows, err := h.queries.ListSyncChanges(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]templates.SyncChange, len(rows))
+	for i, c := range rows {
+		changes[i] = templates.SyncChange{
+			ID:           c.ID,
+			Kind:         c.Kind,
+			YoutubeID:    c.YoutubeID,
+			Name:         c.Name,
+			ThumbnailURL: c.ThumbnailUrl.String,
+			CurrentName:  c.CurrentName.String,
+		}
+	}
+	return changes, nil
+}
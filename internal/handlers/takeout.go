@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/takeout"
+	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
+)
+
+// maxTakeoutSize caps uploaded Takeout archives, which are large when the
+// export includes the user's own videos.
+const maxTakeoutSize = 512 << 20
+
+// takeoutFile is a file of a Takeout export, uploaded by itself or found in
+// the archive.
+type takeoutFile struct {
+	Name string
+	Kind string
+	Open func() (io.ReadCloser, error)
+}
+
+// takeoutKinds orders the files of an import: subscriptions before
+// playlists, and the watch history last.
+var takeoutKinds = []string{takeout.Subscriptions, takeout.Playlists, takeout.WatchHistory}
+
+// HandleImportTakeout imports a Google Takeout export in the background,
+// either the archive or some of its files: subscriptions.csv becomes channel
+// subscriptions, the playlist files playlist subscriptions, and
+// watch-history.json marks the videos in it watched. Files of the archive it
+// can't use are ignored; ones uploaded by themselves are reported.
+func (h *Handlers) HandleImportTakeout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	r.Body = http.MaxBytesReader(w, r.Body, maxTakeoutSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil || len(r.MultipartForm.File["file"]) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("Choose a Takeout archive or its files to import").Render(ctx, w)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	var files []takeoutFile
+	var reports []templates.ImportReport
+	for _, fh := range r.MultipartForm.File["file"] {
+		upload, err := fh.Open()
+		if err != nil {
+			log.Printf("open takeout upload %s: %v", fh.Filename, err)
+			continue
+		}
+		defer upload.Close()
+		found, report := takeoutUpload(fh, upload)
+		files = append(files, found...)
+		if report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	slices.SortStableFunc(files, func(a, b takeoutFile) int {
+		return cmp.Compare(slices.Index(takeoutKinds, a.Kind), slices.Index(takeoutKinds, b.Kind))
+	})
+
+	var batches []importBatch
+	for _, f := range files {
+		batch, report := readTakeoutFile(f)
+		if batch != nil {
+			batches = append(batches, *batch)
+		}
+		if report != nil {
+			reports = append(reports, *report)
+		}
+	}
+	if len(reports) == 0 && len(batches) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = templates.SearchError("No YouTube subscriptions, playlists or watch history found").Render(ctx, w)
+		return
+	}
+	h.startImport(w, r, reports, batches, false)
+}
+
+// takeoutUpload lists the Takeout files of an upload, which is an archive or
+// a single file. It reports uploads that hold nothing it can import.
+func takeoutUpload(fh *multipart.FileHeader, upload multipart.File) ([]takeoutFile, *templates.ImportReport) {
+	failed := func(reason string) *templates.ImportReport {
+		return &templates.ImportReport{
+			File:   fh.Filename,
+			Failed: []templates.ImportFailure{{Entry: fh.Filename, Reason: reason}},
+		}
+	}
+
+	if !strings.EqualFold(path.Ext(fh.Filename), ".zip") {
+		kind, err := takeout.Classify(fh.Filename)
+		if err != nil {
+			return nil, failed(err.Error())
+		}
+		if kind == "" {
+			return nil, failed("not a Takeout file this can import")
+		}
+		open := func() (io.ReadCloser, error) {
+			_, err := upload.Seek(0, io.SeekStart)
+			return io.NopCloser(upload), err
+		}
+		return []takeoutFile{{Name: fh.Filename, Kind: kind, Open: open}}, nil
+	}
+
+	archive, err := zip.NewReader(upload, fh.Size)
+	if err != nil {
+		return nil, failed("not a valid zip archive")
+	}
+	var files []takeoutFile
+	var report *templates.ImportReport
+	for _, zf := range archive.File {
+		kind, err := takeout.Classify(zf.Name)
+		if errors.Is(err, takeout.ErrHTMLHistory) {
+			report = failed(err.Error())
+		}
+		if kind != "" {
+			files = append(files, takeoutFile{Name: path.Base(zf.Name), Kind: kind, Open: zf.Open})
+		}
+	}
+	if len(files) == 0 && report == nil {
+		report = failed("no YouTube subscriptions, playlists or watch history in the archive")
+	}
+	return files, report
+}
+
+// readTakeoutFile reads what a single Takeout file has to import. Files
+// that turn out not to be what their name says are reported as failed;
+// playlist files listing no playlist give nothing at all.
+func readTakeoutFile(f takeoutFile) (*importBatch, *templates.ImportReport) {
+	unreadable := func(err error) (*importBatch, *templates.ImportReport) {
+		log.Printf("read takeout %s: %v", f.Name, err)
+		return nil, &templates.ImportReport{
+			File:   f.Name,
+			Failed: []templates.ImportFailure{{Entry: f.Name, Reason: "couldn't be read as " + f.Kind}},
+		}
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return unreadable(err)
+	}
+	defer rc.Close()
+
+	batch := &importBatch{File: f.Name}
+	switch f.Kind {
+	case takeout.Subscriptions:
+		channels, err := takeout.ReadSubscriptions(rc)
+		if err != nil {
+			return unreadable(err)
+		}
+		for _, c := range channels {
+			link, ok := youtube.ParseLink(c.ID)
+			if !ok {
+				link, _ = youtube.ParseLink(c.URL)
+			}
+			batch.Entries = append(batch.Entries, importEntry{Name: c.Title, Link: link, Source: cmp.Or(c.URL, c.ID)})
+		}
+	case takeout.Playlists:
+		playlists, err := takeout.ReadPlaylists(rc)
+		if err != nil {
+			return unreadable(err)
+		}
+		if len(playlists) == 0 {
+			// The video lists of current exports, whose playlists
+			// are in playlists.csv.
+			return nil, nil
+		}
+		for _, p := range playlists {
+			batch.Entries = append(batch.Entries, importEntry{
+				Name:   p.Title,
+				Link:   youtube.Link{Kind: youtube.LinkPlaylist, ID: p.ID},
+				Source: p.ID,
+				// Own playlists are often private, which the API
+				// can't read.
+				Verify: true,
+			})
+		}
+	case takeout.WatchHistory:
+		watches, err := takeout.ReadWatchHistory(rc)
+		if err != nil {
+			return unreadable(err)
+		}
+		batch.History = true
+		for _, watch := range watches {
+			if link, ok := youtube.ParseLink(watch.URL); ok && link.Kind == youtube.LinkVideo {
+				batch.Videos = append(batch.Videos, watchedVideo{ID: link.ID, At: watch.Time})
+			}
+		}
+	}
+	return batch, nil
+}
+
+// watchedVideo is a video in an imported watch history.
+type watchedVideo struct {
+	ID string
+	At time.Time
+}
+
+// importWatchHistory remembers the videos of a watch history and marks the
+// stored ones watched. Videos stored later are marked watched by saveVideos.
+// A dry run counts the videos that would be marked and changes nothing.
+func (h *Handlers) importWatchHistory(ctx context.Context, videos []watchedVideo, dryRun bool) (templates.ImportReport, error) {
+	report := templates.ImportReport{DryRun: dryRun}
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback()
+
+	qtx := h.queries.WithTx(tx)
+	seen := make(map[string]bool)
+	for _, v := range videos {
+		if !seen[v.ID] {
+			seen[v.ID] = true
+			report.History++
+		}
+		if err := qtx.AddWatchHistory(ctx, db.AddWatchHistoryParams{
+			YoutubeID: v.ID,
+			WatchedAt: sql.NullTime{Time: v.At, Valid: !v.At.IsZero()},
+		}); err != nil {
+			return report, err
+		}
+	}
+	marked, err := qtx.MarkWatchHistoryWatched(ctx)
+	if err != nil {
+		return report, err
+	}
+	report.MarkedWatched = marked
+	if dryRun {
+		return report, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return report, fmt.Errorf("commit watch history: %w", err)
+	}
+	return report, nil
+}
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"strconv"
 
+	"youtube-deck-go/internal/db"
 	"youtube-deck-go/internal/templates"
+	"youtube-deck-go/internal/youtube"
 )
 
 func (h *Handlers) HandleVideos(w http.ResponseWriter, r *http.Request) {
@@ -22,13 +24,18 @@ func (h *Handlers) HandleVideos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	videos, err := h.queries.ListVideos(r.Context(), id)
+	sort := r.URL.Query().Get("sort")
+	if !validSortOrder(sub, sort) {
+		sort = sub.SortOrder
+	}
+
+	videos, err := h.queries.ListVideos(r.Context(), db.ListVideosParams{SubscriptionID: id, Sort: sort})
 	if err != nil {
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 
-	_ = templates.Videos(sub, videos).Render(r.Context(), w)
+	_ = templates.Videos(sub, videos, sort).Render(r.Context(), w)
 }
 
 func (h *Handlers) HandleToggleWatched(w http.ResponseWriter, r *http.Request) {
@@ -51,11 +58,61 @@ func (h *Handlers) HandleToggleWatched(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	count, err := h.queries.CountUnwatchedBySubscription(r.Context(), video.SubscriptionID)
+	sub, err := h.queries.GetSubscription(r.Context(), video.SubscriptionID)
+	if err != nil {
+		log.Printf("failed to load subscription %d: %v", video.SubscriptionID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	count, err := h.countColumnVideos(r.Context(), sub)
 	if err != nil {
 		log.Printf("failed to count unwatched videos for subscription %d: %v", video.SubscriptionID, err)
 		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
 	_ = templates.UnwatchedCountsOOB(video.SubscriptionID, count).Render(r.Context(), w)
+	h.publish(r.Context(), templates.VideoWatchedOOB(video.ID, video.SubscriptionID, count))
+
+	if muted, err := h.countMutedVideos(r.Context(), sub); err != nil {
+		log.Printf("count muted error: %v", err)
+	} else {
+		_ = templates.MutedCountOOB(sub.ID, muted).Render(r.Context(), w)
+		h.publish(r.Context(), templates.MutedCountOOB(sub.ID, muted))
+	}
+
+	if video.Availability != youtube.AvailabilityAvailable {
+		if gone, err := h.queries.CountGoneVideos(r.Context(), sub.ID); err != nil {
+			log.Printf("count gone error: %v", err)
+		} else {
+			_ = templates.GoneCountOOB(sub.ID, gone).Render(r.Context(), w)
+			h.publish(r.Context(), templates.GoneCountOOB(sub.ID, gone))
+		}
+	}
+
+	// Watching a queued video takes it off the queue.
+	if dequeued, err := h.queries.DequeueVideo(r.Context(), id); err != nil {
+		log.Printf("dequeue video %d: %v", id, err)
+	} else if dequeued > 0 {
+		items, err := h.queries.ListQueue(r.Context())
+		if err != nil {
+			log.Printf("list queue error: %v", err)
+		} else {
+			_ = templates.QueueItemRemovedOOB(id, items).Render(r.Context(), w)
+			h.publish(r.Context(), templates.QueueItemRemovedOOB(id, items))
+		}
+	}
+
+	deckID, err := h.currentDeckID(r.Context())
+	if err != nil {
+		log.Printf("current deck error: %v", err)
+		return
+	}
+	rivers, err := h.listRiverColumns(r.Context(), deckID)
+	if err != nil {
+		log.Printf("list virtual columns error: %v", err)
+		return
+	}
+	_ = templates.VirtualColumnCountsOOB(rivers).Render(r.Context(), w)
+	h.publish(r.Context(), templates.VirtualColumnCountsOOB(rivers))
 }
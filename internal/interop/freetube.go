@@ -0,0 +1,213 @@
+package interop
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// freeTubeAllChannels is the _id of the FreeTube profile every subscription
+// belongs to.
+const freeTubeAllChannels = "allChannels"
+
+// freeTubeDoc is a line of a FreeTube database. Profiles, the subscriptions
+// of older versions and history entries share no fields but the _id, so the
+// line says what it is by which fields it has.
+type freeTubeDoc struct {
+	ID      string `json:"_id"`
+	Deleted bool   `json:"$$deleted"`
+
+	// A profile.
+	Name          *string           `json:"name"`
+	Subscriptions []freeTubeChannel `json:"subscriptions"`
+
+	// A subscription of subscriptions.db, before profiles.
+	ChannelID   string `json:"channelId"`
+	ChannelName string `json:"channelName"`
+
+	// A history entry.
+	VideoID       string `json:"videoId"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	AuthorID      string `json:"authorId"`
+	Published     int64  `json:"published"`
+	LengthSeconds int64  `json:"lengthSeconds"`
+	TimeWatched   int64  `json:"timeWatched"`
+}
+
+type freeTubeChannel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Thumbnail string `json:"thumbnail"`
+}
+
+// readFreeTube reads a FreeTube database: profiles.db, subscriptions.db or
+// history.db. Each line is a document; like the database does, a later line
+// with the same _id replaces an earlier one.
+func readFreeTube(data []byte) (*Export, error) {
+	var ids []string
+	docs := make(map[string]freeTubeDoc)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var doc freeTubeDoc
+		if err := json.Unmarshal(line, &doc); err != nil {
+			return nil, ErrUnknownFormat
+		}
+		if _, ok := docs[doc.ID]; !ok {
+			ids = append(ids, doc.ID)
+		}
+		docs[doc.ID] = doc
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("interop: freetube: %w", err)
+	}
+
+	export := &Export{App: FreeTube}
+	subs := make(map[string]int)
+	subscribe := func(id, name, group string) {
+		i, ok := subs[id]
+		if !ok {
+			i = len(export.Subscriptions)
+			subs[id] = i
+			export.Subscriptions = append(export.Subscriptions, Subscription{Kind: Channel, ID: id, Name: name})
+		}
+		s := &export.Subscriptions[i]
+		if group != "" && !slices.Contains(s.Groups, group) {
+			s.Groups = append(s.Groups, group)
+		}
+	}
+	for _, id := range ids {
+		doc := docs[id]
+		switch {
+		case doc.Deleted:
+		case doc.VideoID != "":
+			var watched, published time.Time
+			if doc.TimeWatched > 0 {
+				watched = time.UnixMilli(doc.TimeWatched).UTC()
+			}
+			if doc.Published > 0 {
+				published = time.UnixMilli(doc.Published).UTC()
+			}
+			export.History = append(export.History, Watch{
+				VideoID:       doc.VideoID,
+				Time:          watched,
+				Title:         doc.Title,
+				Author:        doc.Author,
+				AuthorID:      doc.AuthorID,
+				LengthSeconds: doc.LengthSeconds,
+				Published:     published,
+			})
+		case doc.Name != nil:
+			group := *doc.Name
+			if doc.ID == freeTubeAllChannels {
+				group = ""
+			}
+			for _, c := range doc.Subscriptions {
+				subscribe(c.ID, c.Name, group)
+			}
+		case doc.ChannelID != "":
+			subscribe(doc.ChannelID, doc.ChannelName, "")
+		default:
+			return nil, ErrUnknownFormat
+		}
+	}
+	return export, nil
+}
+
+// WriteFreeTubeProfiles writes channel subscriptions as a FreeTube profile
+// database, for its "Import Subscriptions". Every channel is in the profile
+// of all channels, and each group becomes a profile of its own. Playlists
+// have no place in it and are left out.
+func WriteFreeTubeProfiles(w io.Writer, subs []Subscription) error {
+	profiles := []freeTubeProfile{newFreeTubeProfile(freeTubeAllChannels, "All Channels")}
+	byName := make(map[string]int)
+	for _, s := range subs {
+		if s.Kind != Channel {
+			continue
+		}
+		c := freeTubeChannel{ID: s.ID, Name: s.Name}
+		profiles[0].Subscriptions = append(profiles[0].Subscriptions, c)
+		for _, group := range s.Groups {
+			i, ok := byName[group]
+			if !ok {
+				i = len(profiles)
+				byName[group] = i
+				profiles = append(profiles, newFreeTubeProfile(fmt.Sprintf("profile%d", i), group))
+			}
+			profiles[i].Subscriptions = append(profiles[i].Subscriptions, c)
+		}
+	}
+	enc := json.NewEncoder(w)
+	for _, p := range profiles {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// freeTubeProfile is how a profile is written, without the fields of the
+// other documents.
+type freeTubeProfile struct {
+	ID            string            `json:"_id"`
+	Name          string            `json:"name"`
+	BgColor       string            `json:"bgColor"`
+	TextColor     string            `json:"textColor"`
+	Subscriptions []freeTubeChannel `json:"subscriptions"`
+}
+
+func newFreeTubeProfile(id, name string) freeTubeProfile {
+	return freeTubeProfile{ID: id, Name: name, BgColor: "#000000", TextColor: "#FFFFFF", Subscriptions: []freeTubeChannel{}}
+}
+
+// freeTubeHistory is how a history entry is written.
+type freeTubeHistory struct {
+	ID            string `json:"_id"`
+	VideoID       string `json:"videoId"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	AuthorID      string `json:"authorId"`
+	Published     int64  `json:"published"`
+	LengthSeconds int64  `json:"lengthSeconds"`
+	WatchProgress int64  `json:"watchProgress"`
+	TimeWatched   int64  `json:"timeWatched"`
+	IsLive        bool   `json:"isLive"`
+	Type          string `json:"type"`
+}
+
+// WriteFreeTubeHistory writes watched videos as a FreeTube history database,
+// for its "Import History". Videos count as watched to the end.
+func WriteFreeTubeHistory(w io.Writer, history []Watch) error {
+	enc := json.NewEncoder(w)
+	for _, h := range history {
+		entry := freeTubeHistory{
+			ID:            h.VideoID,
+			VideoID:       h.VideoID,
+			Title:         h.Title,
+			Author:        h.Author,
+			AuthorID:      h.AuthorID,
+			LengthSeconds: h.LengthSeconds,
+			WatchProgress: h.LengthSeconds,
+			Type:          "video",
+		}
+		if !h.Published.IsZero() {
+			entry.Published = h.Published.UnixMilli()
+		}
+		if !h.Time.IsZero() {
+			entry.TimeWatched = h.Time.UnixMilli()
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
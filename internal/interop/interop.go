@@ -0,0 +1,109 @@
+// Package interop reads and writes the subscription and history exports of
+// other YouTube clients: NewPipe, FreeTube and Invidious.
+package interop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Apps, as reported in Export.App.
+const (
+	NewPipe   = "NewPipe"
+	FreeTube  = "FreeTube"
+	Invidious = "Invidious"
+)
+
+// Kinds of Subscription.
+const (
+	Channel  = "channel"
+	Playlist = "playlist"
+)
+
+// ErrUnknownFormat is returned by Read for files that aren't an export of
+// any of the apps.
+var ErrUnknownFormat = errors.New("interop: not a NewPipe, FreeTube or Invidious export")
+
+// Export is what an app exported: subscriptions, watch history or both.
+type Export struct {
+	App           string
+	Subscriptions []Subscription
+	History       []Watch
+}
+
+// Subscription is a channel or playlist followed in an app.
+type Subscription struct {
+	Kind string
+	// ID is the channel or playlist ID. Some apps only give a URL.
+	ID  string
+	URL string
+	// Name is empty when the app exports IDs only.
+	Name string
+	// Groups are the app's groups holding the subscription: NewPipe's
+	// channel groups and FreeTube's profiles.
+	Groups []string
+}
+
+// Watch is a video in a watch history.
+type Watch struct {
+	VideoID string
+	// Time is when the video was last watched, if known.
+	Time time.Time
+
+	// The video's details, which FreeTube shows its history with.
+	Title         string
+	Author        string
+	AuthorID      string
+	LengthSeconds int64
+	Published     time.Time
+}
+
+var (
+	zipMagic    = []byte("PK\x03\x04")
+	sqliteMagic = []byte("SQLite format 3\x00")
+)
+
+// Read reads an export of any of the apps, telling them apart by content:
+//
+//   - NewPipe: the subscriptions JSON, or the data zip holding newpipe.db,
+//     or newpipe.db itself.
+//   - FreeTube: profiles.db, the older subscriptions.db, or history.db.
+//   - Invidious: the subscription_manager JSON.
+func Read(data []byte) (*Export, error) {
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return readNewPipeZip(data)
+	case bytes.HasPrefix(data, sqliteMagic):
+		return readNewPipeDB(data)
+	}
+
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	if !bytes.HasPrefix(data, []byte("{")) {
+		return nil, ErrUnknownFormat
+	}
+	// FreeTube's databases hold one JSON document per line, each with an
+	// _id; the other exports are a single document without one.
+	var doc struct {
+		ID         string          `json:"_id"`
+		AppVersion *string         `json:"app_version"`
+		Subs       json.RawMessage `json:"subscriptions"`
+		History    json.RawMessage `json:"watch_history"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil || doc.ID != "" {
+		return readFreeTube(data)
+	}
+	switch {
+	case doc.AppVersion != nil:
+		return readNewPipeJSON(data)
+	case doc.Subs != nil || doc.History != nil:
+		return readInvidious(data)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// channelURL is the page of a channel, which NewPipe identifies channels by.
+func channelURL(id string) string {
+	return "https://www.youtube.com/channel/" + id
+}
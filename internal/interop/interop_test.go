@@ -0,0 +1,187 @@
+package interop
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const (
+	veritasium = "UCHnyfMqiRRG1u-2MsSQLbXA"
+	kurzgesagt = "UCsXVk37bltHxD1rDPwtNM8Q"
+)
+
+func TestReadNewPipeJSON(t *testing.T) {
+	const file = `{"app_version":"0.26.1","app_version_int":996,"subscriptions":[
+		{"service_id":0,"url":"https://www.youtube.com/channel/UCHnyfMqiRRG1u-2MsSQLbXA","name":"Veritasium"},
+		{"service_id":1,"url":"https://soundcloud.com/someone","name":"Someone"}
+	]}`
+	export, err := Read([]byte(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.App != NewPipe || len(export.Subscriptions) != 1 || export.Subscriptions[0].Name != "Veritasium" {
+		t.Errorf("Read = %+v", export)
+	}
+}
+
+func TestReadFreeTube(t *testing.T) {
+	profiles := `{"name":"All Channels","bgColor":"#000000","textColor":"#FFFFFF","subscriptions":[{"id":"` + veritasium + `","name":"Veritasium","thumbnail":""}],"_id":"allChannels"}
+{"name":"Science","bgColor":"#112233","textColor":"#FFFFFF","subscriptions":[],"_id":"p1"}
+{"name":"All Channels","bgColor":"#000000","textColor":"#FFFFFF","subscriptions":[{"id":"` + veritasium + `","name":"Veritasium","thumbnail":""},{"id":"` + kurzgesagt + `","name":"Kurzgesagt","thumbnail":""}],"_id":"allChannels"}
+{"name":"Science","bgColor":"#112233","textColor":"#FFFFFF","subscriptions":[{"id":"` + kurzgesagt + `","name":"Kurzgesagt","thumbnail":""}],"_id":"p1"}
+{"name":"Gone","subscriptions":[{"id":"` + veritasium + `","name":"Veritasium"}],"_id":"p2"}
+{"$$deleted":true,"_id":"p2"}
+`
+	export, err := Read([]byte(profiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.App != FreeTube || len(export.Subscriptions) != 2 {
+		t.Fatalf("Read(profiles) = %+v", export)
+	}
+	if s := export.Subscriptions[0]; s.ID != veritasium || len(s.Groups) != 0 {
+		t.Errorf("first subscription = %+v", s)
+	}
+	if s := export.Subscriptions[1]; s.ID != kurzgesagt || !slices.Equal(s.Groups, []string{"Science"}) {
+		t.Errorf("second subscription = %+v", s)
+	}
+
+	history := `{"videoId":"dQw4w9WgXcQ","title":"Never Gonna Give You Up","author":"Rick Astley","authorId":"UCuAXFkgsw1L7xaCfnd5JJOw","published":1256453280000,"description":"","viewCount":1,"lengthSeconds":212,"watchProgress":100,"timeWatched":1700000000000,"isLive":false,"type":"video","_id":"dQw4w9WgXcQ"}
+`
+	export, err = Read([]byte(history))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.History) != 1 || export.History[0].VideoID != "dQw4w9WgXcQ" || !export.History[0].Time.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("Read(history) = %+v", export)
+	}
+
+	legacy := `{"channelId":"` + veritasium + `","channelName":"Veritasium","channelThumbnail":"","_id":"x"}`
+	export, err = Read([]byte(legacy))
+	if err != nil || len(export.Subscriptions) != 1 || export.Subscriptions[0].Name != "Veritasium" {
+		t.Errorf("Read(subscriptions.db) = %+v, %v", export, err)
+	}
+}
+
+func TestReadInvidious(t *testing.T) {
+	const file = `{"subscriptions":["` + veritasium + `"],"watch_history":["dQw4w9WgXcQ"],"preferences":{"locale":"en-US"},"playlists":[]}`
+	export, err := Read([]byte(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.App != Invidious || len(export.Subscriptions) != 1 || export.Subscriptions[0].ID != veritasium ||
+		len(export.History) != 1 || export.History[0].VideoID != "dQw4w9WgXcQ" {
+		t.Errorf("Read = %+v", export)
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	for _, file := range []string{"", "<opml/>", `{"hello":"world"}`, "[1,2,3]"} {
+		if _, err := Read([]byte(file)); err == nil {
+			t.Errorf("Read(%q) succeeded", file)
+		}
+	}
+}
+
+func TestReadNewPipeDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "newpipe.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE subscriptions (uid INTEGER PRIMARY KEY, service_id INTEGER, url TEXT, name TEXT)`,
+		`CREATE TABLE remote_playlists (uid INTEGER PRIMARY KEY, service_id INTEGER, name TEXT, url TEXT)`,
+		`CREATE TABLE feed_group (uid INTEGER PRIMARY KEY, name TEXT, sort_order INTEGER)`,
+		`CREATE TABLE feed_group_subscription_join (group_id INTEGER, subscription_id INTEGER)`,
+		`CREATE TABLE streams (uid INTEGER PRIMARY KEY, service_id INTEGER, url TEXT)`,
+		`CREATE TABLE stream_history (stream_id INTEGER, access_date INTEGER, repeat_count INTEGER)`,
+		`INSERT INTO subscriptions VALUES (1, 0, 'https://www.youtube.com/channel/` + veritasium + `', 'Veritasium'), (2, 1, 'https://soundcloud.com/x', 'X')`,
+		`INSERT INTO remote_playlists VALUES (1, 0, 'Lectures', 'https://www.youtube.com/playlist?list=PLUl4u3cNGP61Oq3tWYp6V_F-5jb5L2iHb')`,
+		`INSERT INTO feed_group VALUES (1, 'Science', 0)`,
+		`INSERT INTO feed_group_subscription_join VALUES (1, 1)`,
+		`INSERT INTO streams VALUES (1, 0, 'https://www.youtube.com/watch?v=dQw4w9WgXcQ')`,
+		`INSERT INTO stream_history VALUES (1, 1600000000000, 1), (1, 1700000000000, 1)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	db.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	f, _ := zw.Create("newpipe.db")
+	f.Write(data)
+	zw.Close()
+
+	for name, file := range map[string][]byte{"database": data, "zip": archive.Bytes()} {
+		export, err := Read(file)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(export.Subscriptions) != 2 {
+			t.Fatalf("%s: subscriptions = %+v", name, export.Subscriptions)
+		}
+		if s := export.Subscriptions[0]; s.Kind != Channel || s.Name != "Veritasium" || !slices.Equal(s.Groups, []string{"Science"}) {
+			t.Errorf("%s: channel = %+v", name, s)
+		}
+		if s := export.Subscriptions[1]; s.Kind != Playlist || s.Name != "Lectures" {
+			t.Errorf("%s: playlist = %+v", name, s)
+		}
+		if len(export.History) != 1 || export.History[0].VideoID != "dQw4w9WgXcQ" || !export.History[0].Time.Equal(time.UnixMilli(1700000000000)) {
+			t.Errorf("%s: history = %+v", name, export.History)
+		}
+	}
+}
+
+func TestWritersRoundTrip(t *testing.T) {
+	subs := []Subscription{
+		{Kind: Channel, ID: veritasium, Name: "Veritasium", Groups: []string{"Science"}},
+		{Kind: Channel, ID: kurzgesagt, Name: "Kurzgesagt"},
+		{Kind: Playlist, ID: "PLUl4u3cNGP61Oq3tWYp6V_F-5jb5L2iHb", Name: "Lectures"},
+	}
+	history := []Watch{{VideoID: "dQw4w9WgXcQ", Title: "Never Gonna Give You Up", Time: time.UnixMilli(1700000000000).UTC()}}
+
+	var newPipe, freeTube, freeTubeHistory, invidious bytes.Buffer
+	if err := WriteNewPipe(&newPipe, subs); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFreeTubeProfiles(&freeTube, subs); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFreeTubeHistory(&freeTubeHistory, history); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteInvidious(&invidious, subs, history); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, buf := range map[string]*bytes.Buffer{"NewPipe": &newPipe, "FreeTube": &freeTube, "Invidious": &invidious} {
+		export, err := Read(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if export.App != name || len(export.Subscriptions) != 2 {
+			t.Errorf("%s: read back %+v", name, export)
+		}
+	}
+	export, err := Read(freeTube.Bytes())
+	if err != nil || !slices.Equal(export.Subscriptions[0].Groups, []string{"Science"}) {
+		t.Errorf("FreeTube profiles lost the group: %+v, %v", export, err)
+	}
+	export, err = Read(freeTubeHistory.Bytes())
+	if err != nil || len(export.History) != 1 || export.History[0] != history[0] {
+		t.Errorf("FreeTube history read back as %+v, %v", export, err)
+	}
+}
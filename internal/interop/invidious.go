@@ -0,0 +1,50 @@
+package interop
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// invidiousJSON is the part of Invidious's subscription_manager export this
+// package deals in. Its playlists live on the Invidious instance rather than
+// on YouTube, and its preferences mean nothing here, so both are ignored.
+type invidiousJSON struct {
+	Subscriptions []string `json:"subscriptions"`
+	WatchHistory  []string `json:"watch_history"`
+}
+
+// readInvidious reads the export, which lists channels and videos by ID
+// alone; the history is newest first, without times.
+func readInvidious(data []byte) (*Export, error) {
+	var doc invidiousJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("interop: invidious: %w", err)
+	}
+	export := &Export{App: Invidious}
+	for _, id := range doc.Subscriptions {
+		export.Subscriptions = append(export.Subscriptions, Subscription{Kind: Channel, ID: id})
+	}
+	for _, id := range doc.WatchHistory {
+		export.History = append(export.History, Watch{VideoID: id})
+	}
+	return export, nil
+}
+
+// WriteInvidious writes channel subscriptions and watched videos in the
+// format of Invidious's "Import Invidious JSON data". Playlists have no place
+// in it and are left out.
+func WriteInvidious(w io.Writer, subs []Subscription, history []Watch) error {
+	doc := invidiousJSON{Subscriptions: []string{}, WatchHistory: []string{}}
+	for _, s := range subs {
+		if s.Kind == Channel {
+			doc.Subscriptions = append(doc.Subscriptions, s.ID)
+		}
+	}
+	for _, h := range history {
+		doc.WatchHistory = append(doc.WatchHistory, h.VideoID)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
@@ -0,0 +1,223 @@
+package interop
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// newPipeYouTube is NewPipe's service ID for YouTube; the others are
+// SoundCloud, PeerTube and the like.
+const newPipeYouTube = 0
+
+// newPipeJSON is the subscriptions export of NewPipe, from "Export to file"
+// in its subscriptions tab.
+type newPipeJSON struct {
+	AppVersion    string                `json:"app_version"`
+	AppVersionInt int                   `json:"app_version_int"`
+	Subscriptions []newPipeSubscription `json:"subscriptions"`
+}
+
+type newPipeSubscription struct {
+	ServiceID int    `json:"service_id"`
+	URL       string `json:"url"`
+	Name      string `json:"name"`
+}
+
+func readNewPipeJSON(data []byte) (*Export, error) {
+	var doc newPipeJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("interop: newpipe: %w", err)
+	}
+	export := &Export{App: NewPipe}
+	for _, s := range doc.Subscriptions {
+		if s.ServiceID == newPipeYouTube {
+			export.Subscriptions = append(export.Subscriptions, Subscription{Kind: Channel, URL: s.URL, Name: s.Name})
+		}
+	}
+	return export, nil
+}
+
+// WriteNewPipe writes channel subscriptions in the format NewPipe imports
+// "from a previous export". Playlists have no place in it and are left out.
+func WriteNewPipe(w io.Writer, subs []Subscription) error {
+	doc := newPipeJSON{AppVersion: "0.27.0", AppVersionInt: 1000, Subscriptions: []newPipeSubscription{}}
+	for _, s := range subs {
+		if s.Kind == Channel {
+			doc.Subscriptions = append(doc.Subscriptions, newPipeSubscription{newPipeYouTube, channelURL(s.ID), s.Name})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// readNewPipeZip reads the database out of the zip NewPipe's "Export
+// database" writes.
+func readNewPipeZip(data []byte) (*Export, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("interop: newpipe: %w", err)
+	}
+	for _, f := range archive.File {
+		if path.Base(f.Name) != "newpipe.db" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("interop: newpipe: %w", err)
+		}
+		defer rc.Close()
+		db, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("interop: newpipe: %w", err)
+		}
+		return readNewPipeDB(db)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// readNewPipeDB reads subscriptions, bookmarked playlists, channel groups
+// and the watch history from NewPipe's database. SQLite can only open it
+// from a file, so it's copied to a temporary one first.
+func readNewPipeDB(data []byte) (*Export, error) {
+	f, err := os.CreateTemp("", "newpipe-*.db")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+f.Name()+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	export := &Export{App: NewPipe}
+	groups, err := newPipeGroups(db)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT uid, url, name FROM subscriptions WHERE service_id = ? ORDER BY name`, newPipeYouTube)
+	if err != nil {
+		return nil, fmt.Errorf("interop: newpipe subscriptions: %w", err)
+	}
+	for rows.Next() {
+		var uid int64
+		s := Subscription{Kind: Channel}
+		if err := rows.Scan(&uid, &s.URL, &s.Name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		s.Groups = groups[uid]
+		export.Subscriptions = append(export.Subscriptions, s)
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT url, name FROM remote_playlists WHERE service_id = ? ORDER BY name`, newPipeYouTube)
+	if err != nil && !missingTable(err) {
+		return nil, fmt.Errorf("interop: newpipe playlists: %w", err)
+	}
+	if err == nil {
+		for rows.Next() {
+			s := Subscription{Kind: Playlist}
+			if err := rows.Scan(&s.URL, &s.Name); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			export.Subscriptions = append(export.Subscriptions, s)
+		}
+		if err := closeRows(rows); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err = db.Query(`
+		SELECT s.url, MAX(h.access_date)
+		FROM stream_history h JOIN streams s ON s.uid = h.stream_id
+		WHERE s.service_id = ?
+		GROUP BY s.url`, newPipeYouTube)
+	if err != nil {
+		return nil, fmt.Errorf("interop: newpipe history: %w", err)
+	}
+	for rows.Next() {
+		var watchURL string
+		var accessed int64
+		if err := rows.Scan(&watchURL, &accessed); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if id := videoIDFromURL(watchURL); id != "" {
+			export.History = append(export.History, Watch{VideoID: id, Time: time.UnixMilli(accessed).UTC()})
+		}
+	}
+	if err := closeRows(rows); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// newPipeGroups maps subscription uids to the names of their channel
+// groups. Databases from before groups existed have none.
+func newPipeGroups(db *sql.DB) (map[int64][]string, error) {
+	groups := make(map[int64][]string)
+	rows, err := db.Query(`
+		SELECT j.subscription_id, g.name
+		FROM feed_group_subscription_join j JOIN feed_group g ON g.uid = j.group_id
+		ORDER BY g.sort_order, g.name`)
+	if missingTable(err) {
+		return groups, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("interop: newpipe groups: %w", err)
+	}
+	for rows.Next() {
+		var uid int64
+		var name string
+		if err := rows.Scan(&uid, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		groups[uid] = append(groups[uid], name)
+	}
+	return groups, closeRows(rows)
+}
+
+func closeRows(rows *sql.Rows) error {
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+func missingTable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "no such table")
+}
+
+// videoIDFromURL returns the v parameter of a watch page URL.
+func videoIDFromURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("v")
+}
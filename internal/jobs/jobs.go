@@ -0,0 +1,168 @@
+// Package jobs runs long tasks, such as imports, in the background.
+//
+// Each job is a row in the jobs table holding its status, progress and
+// outcome, so a job outlives the request that started it and any page can
+// show how it's doing.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"youtube-deck-go/internal/db"
+)
+
+// Job statuses, as stored in the jobs table.
+const (
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// dismissedRetentionDays is how long dismissed jobs are kept.
+const dismissedRetentionDays = 7
+
+// ErrRunning is returned by Start while a job of the same kind is running.
+var ErrRunning = errors.New("jobs: a job of this kind is running")
+
+// Progress reports that done of total steps are complete. total is 0 while
+// unknown. Progress writes to the database, so it must not be called while
+// the job holds a transaction open.
+type Progress func(done, total int64)
+
+// Func does the work of a job and returns a summary of what it did. It
+// should return soon after ctx is cancelled, with an error wrapping
+// context.Canceled: only then is the job recorded as cancelled.
+type Func func(ctx context.Context, progress Progress) (string, error)
+
+// Runner runs jobs in goroutines of its own. It is safe for concurrent use.
+type Runner struct {
+	queries *db.Queries
+	log     *slog.Logger
+
+	// ctx is the parent of every job's context. Close cancels it.
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// running maps the ID of each running job to its cancel function, and
+	// kinds each running kind to its job's ID.
+	running map[int64]context.CancelFunc
+	kinds   map[string]int64
+}
+
+func NewRunner(queries *db.Queries, log *slog.Logger) *Runner {
+	ctx, stop := context.WithCancel(context.Background())
+	return &Runner{
+		queries: queries,
+		log:     log,
+		ctx:     ctx,
+		stop:    stop,
+		running: make(map[int64]context.CancelFunc),
+		kinds:   make(map[string]int64),
+	}
+}
+
+// Recover fails the jobs a previous run of the server left running, and
+// forgets jobs dismissed long ago. Call it before starting any job.
+func (r *Runner) Recover(ctx context.Context) (int64, error) {
+	n, err := r.queries.FailRunningJobs(ctx, "Interrupted by a server restart")
+	if err != nil {
+		return 0, err
+	}
+	if err := r.queries.DeleteDismissedJobs(ctx, dismissedRetentionDays); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// Start records a job of the given kind and runs fn in the background. It
+// returns ErrRunning, along with the running job, if one of the same kind
+// hasn't finished yet.
+func (r *Runner) Start(ctx context.Context, kind string, fn Func) (db.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.kinds[kind]; ok {
+		job, err := r.queries.GetJob(ctx, id)
+		if err != nil {
+			return job, err
+		}
+		return job, ErrRunning
+	}
+	if r.ctx.Err() != nil {
+		return db.Job{}, errors.New("jobs: runner closed")
+	}
+
+	job, err := r.queries.CreateJob(ctx, kind)
+	if err != nil {
+		return job, err
+	}
+	jobCtx, cancel := context.WithCancel(r.ctx)
+	r.running[job.ID] = cancel
+	r.kinds[kind] = job.ID
+	r.wg.Add(1)
+	go r.run(jobCtx, job, fn)
+	return job, nil
+}
+
+func (r *Runner) run(ctx context.Context, job db.Job, fn Func) {
+	defer r.wg.Done()
+	log := r.log.With("job", job.ID, "kind", job.Kind)
+	progress := func(done, total int64) {
+		if err := r.queries.UpdateJobProgress(ctx, db.UpdateJobProgressParams{Progress: done, Total: total, ID: job.ID}); err != nil && ctx.Err() == nil {
+			log.Warn("jobs: record progress", "error", err)
+		}
+	}
+
+	result, err := fn(ctx, progress)
+	finish := db.FinishJobParams{Status: StatusDone, Result: result, ID: job.ID}
+	// A cancel that comes too late to stop fn doesn't undo what it did: the
+	// job is only cancelled if fn says so.
+	switch {
+	case err == nil:
+	case errors.Is(err, context.Canceled):
+		finish.Status = StatusCancelled
+	default:
+		log.Warn("jobs: failed", "error", err)
+		finish.Status = StatusFailed
+		finish.Error = err.Error()
+	}
+
+	// The job's context may be cancelled by now, and the outcome must be
+	// recorded regardless.
+	if err := r.queries.FinishJob(context.Background(), finish); err != nil {
+		log.Error("jobs: record outcome", "error", err)
+	}
+
+	r.mu.Lock()
+	r.running[job.ID]()
+	delete(r.running, job.ID)
+	delete(r.kinds, job.Kind)
+	r.mu.Unlock()
+}
+
+// Cancel asks a running job to stop. Jobs that aren't running are left as
+// they are.
+func (r *Runner) Cancel(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cancel, ok := r.running[id]; ok {
+		cancel()
+	}
+}
+
+// Wait blocks until no job is running.
+func (r *Runner) Wait() {
+	r.wg.Wait()
+}
+
+// Close cancels the running jobs, waits for them to stop and refuses new
+// ones.
+func (r *Runner) Close() {
+	r.stop()
+	r.wg.Wait()
+}
@@ -0,0 +1,155 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestRunner(t *testing.T) (*Runner, *db.Queries) {
+	t.Helper()
+	database, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+	if _, err := migrations.Up(context.Background(), database); err != nil {
+		t.Fatal(err)
+	}
+	queries := db.New(database)
+	runner := NewRunner(queries, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(runner.Close)
+	return runner, queries
+}
+
+func getJob(t *testing.T, queries *db.Queries, id int64) db.Job {
+	t.Helper()
+	job, err := queries.GetJob(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestRunnerRecordsOutcome(t *testing.T) {
+	ctx := context.Background()
+	runner, queries := newTestRunner(t)
+
+	done, err := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) {
+		progress(50, 120)
+		progress(120, 120)
+		return "Imported 120 subscriptions", nil
+	})
+	if err != nil || done.Status != StatusRunning {
+		t.Fatalf("Start = %+v, %v", done, err)
+	}
+	runner.Wait()
+	if job := getJob(t, queries, done.ID); job.Status != StatusDone || job.Progress != 120 || job.Total != 120 || job.Result != "Imported 120 subscriptions" || !job.FinishedAt.Valid {
+		t.Errorf("finished job = %+v", job)
+	}
+
+	failed, err := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) {
+		return "", errors.New("quota exhausted")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runner.Wait()
+	if job := getJob(t, queries, failed.ID); job.Status != StatusFailed || job.Error != "quota exhausted" {
+		t.Errorf("failed job = %+v", job)
+	}
+}
+
+func TestRunnerCancels(t *testing.T) {
+	ctx := context.Background()
+	runner, queries := newTestRunner(t)
+
+	started := make(chan struct{})
+	job, err := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	// One job of a kind runs at a time.
+	again, err := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) {
+		t.Error("second job of the same kind ran")
+		return "", nil
+	})
+	if !errors.Is(err, ErrRunning) || again.ID != job.ID {
+		t.Errorf("second Start = %+v, %v; want the running job and ErrRunning", again, err)
+	}
+
+	runner.Cancel(job.ID)
+	runner.Wait()
+	if got := getJob(t, queries, job.ID); got.Status != StatusCancelled || got.Error != "" {
+		t.Errorf("cancelled job = %+v", got)
+	}
+	if _, err := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) { return "", nil }); err != nil {
+		t.Errorf("Start after cancelling: %v", err)
+	}
+}
+
+func TestRunnerKeepsOutcomeOfLateCancel(t *testing.T) {
+	ctx := context.Background()
+	runner, queries := newTestRunner(t)
+
+	// Each job is cancelled after it's done its work, before it returns.
+	late := func(err error) db.Job {
+		t.Helper()
+		started := make(chan struct{})
+		job, startErr := runner.Start(ctx, "import", func(ctx context.Context, progress Progress) (string, error) {
+			close(started)
+			<-ctx.Done()
+			if err != nil {
+				return "", err
+			}
+			return "Imported 3 subscriptions", nil
+		})
+		if startErr != nil {
+			t.Fatal(startErr)
+		}
+		<-started
+		runner.Cancel(job.ID)
+		runner.Wait()
+		return getJob(t, queries, job.ID)
+	}
+
+	if job := late(nil); job.Status != StatusDone || job.Result != "Imported 3 subscriptions" {
+		t.Errorf("job that finished its work = %+v", job)
+	}
+	if job := late(errors.New("quota exhausted")); job.Status != StatusFailed || job.Error != "quota exhausted" {
+		t.Errorf("job that failed = %+v", job)
+	}
+}
+
+func TestRecoverFailsInterruptedJobs(t *testing.T) {
+	ctx := context.Background()
+	runner, queries := newTestRunner(t)
+	// A job the previous run of the server didn't finish.
+	orphan, err := queries.CreateJob(ctx, "import")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := runner.Recover(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Recover = %d, %v", n, err)
+	}
+	if job := getJob(t, queries, orphan.ID); job.Status != StatusFailed || job.Error == "" {
+		t.Errorf("interrupted job = %+v", job)
+	}
+}
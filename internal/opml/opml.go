@@ -0,0 +1,90 @@
+// Package opml reads and writes OPML, the outline format feed readers
+// exchange their subscription lists in.
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document is an OPML file. Feeds are outlines with an XMLURL; outlines
+// without one are folders.
+type Document struct {
+	XMLName  xml.Name  `xml:"opml"`
+	Version  string    `xml:"version,attr"`
+	Title    string    `xml:"head>title"`
+	Outlines []Outline `xml:"body>outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Name is what the outline is called. Readers fill in text, title or both.
+func (o Outline) Name() string {
+	if o.Text != "" {
+		return o.Text
+	}
+	return o.Title
+}
+
+// Feed is a feed outline along with the folder it's filed in, if any.
+type Feed struct {
+	Outline
+	// Folder is the name of the innermost folder holding the feed.
+	Folder string
+}
+
+// Parse reads an OPML document.
+func Parse(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("opml: %w", err)
+	}
+	return &doc, nil
+}
+
+// Feeds lists the feeds of doc in document order, however deeply they are
+// nested in folders.
+func (doc *Document) Feeds() []Feed {
+	var feeds []Feed
+	var walk func(outlines []Outline, folder string)
+	walk = func(outlines []Outline, folder string) {
+		for _, o := range outlines {
+			if o.XMLURL != "" {
+				feeds = append(feeds, Feed{Outline: o, Folder: folder})
+			}
+			if len(o.Outlines) > 0 {
+				inner := folder
+				if o.XMLURL == "" {
+					inner = strings.TrimSpace(o.Name())
+				}
+				walk(o.Outlines, inner)
+			}
+		}
+	}
+	walk(doc.Outlines, "")
+	return feeds
+}
+
+// Write writes doc as OPML 2.0.
+func (doc *Document) Write(w io.Writer) error {
+	doc.Version = "2.0"
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("opml: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
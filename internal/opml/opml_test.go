@@ -0,0 +1,78 @@
+package opml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const feedly = `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="1.0">
+  <head><title>Feedly subscriptions</title></head>
+  <body>
+    <outline text="Science" title="Science">
+      <outline type="rss" text="Veritasium" xmlUrl="https://www.youtube.com/feeds/videos.xml?channel_id=UCHnyfMqiRRG1u-2MsSQLbXA"/>
+      <outline text="Nested">
+        <outline type="rss" title="Deep" xmlUrl="https://example.com/deep.xml"/>
+      </outline>
+    </outline>
+    <outline type="rss" text="Top level" xmlUrl="https://example.com/top.xml" htmlUrl="https://example.com"/>
+    <outline text="Empty folder"/>
+  </body>
+</opml>`
+
+func TestFeeds(t *testing.T) {
+	doc, err := Parse(strings.NewReader(feedly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Feedly subscriptions" {
+		t.Errorf("Title = %q", doc.Title)
+	}
+	feeds := doc.Feeds()
+	want := []struct{ name, folder, url string }{
+		{"Veritasium", "Science", "https://www.youtube.com/feeds/videos.xml?channel_id=UCHnyfMqiRRG1u-2MsSQLbXA"},
+		{"Deep", "Nested", "https://example.com/deep.xml"},
+		{"Top level", "", "https://example.com/top.xml"},
+	}
+	if len(feeds) != len(want) {
+		t.Fatalf("Feeds() = %+v, want %d feeds", feeds, len(want))
+	}
+	for i, w := range want {
+		if feeds[i].Name() != w.name || feeds[i].Folder != w.folder || feeds[i].XMLURL != w.url {
+			t.Errorf("feed %d = %q in %q at %q, want %q in %q at %q",
+				i, feeds[i].Name(), feeds[i].Folder, feeds[i].XMLURL, w.name, w.folder, w.url)
+		}
+	}
+}
+
+func TestParseRejectsGarbage(t *testing.T) {
+	if _, err := Parse(strings.NewReader("not xml at all")); err == nil {
+		t.Error("Parse accepted a file that isn't OPML")
+	}
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	doc := &Document{
+		Title: "Deck & friends",
+		Outlines: []Outline{
+			{Text: "Music", Outlines: []Outline{{Text: "Band", Type: "rss", XMLURL: "https://example.com/a?x=1&y=2"}}},
+			{Text: "Loose", Type: "rss", XMLURL: "https://example.com/b"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") || !strings.Contains(buf.String(), `version="2.0"`) {
+		t.Errorf("written document lacks the XML header or version:\n%s", buf.String())
+	}
+	back, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeds := back.Feeds()
+	if back.Title != doc.Title || len(feeds) != 2 || feeds[0].Folder != "Music" || feeds[0].XMLURL != "https://example.com/a?x=1&y=2" {
+		t.Errorf("round trip = %+v", back)
+	}
+}
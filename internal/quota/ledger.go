@@ -0,0 +1,144 @@
+// Package quota keeps track of the YouTube Data API units spent per day.
+//
+// YouTube resets the daily quota at midnight Pacific time, so usage is
+// bucketed by the Pacific calendar day.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	_ "time/tzdata" // the ledger must work on hosts without a zoneinfo database
+
+	"youtube-deck-go/internal/db"
+)
+
+const (
+	DefaultDailyLimit = 10000
+	DefaultSoftLimit  = 8000
+)
+
+// ErrExhausted is returned when a call would exceed the daily limit.
+var ErrExhausted = errors.New("quota: daily YouTube API quota exhausted")
+
+var pacific = mustLoadLocation("America/Los_Angeles")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Ledger records API usage in SQLite. It is safe for concurrent use.
+type Ledger struct {
+	queries *db.Queries
+	limit   int64
+	soft    int64
+	now     func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewLedger returns a ledger enforcing limit units per day. Non-essential
+// calls should be refused once softLimit units have been spent.
+func NewLedger(queries *db.Queries, limit, softLimit int64) *Ledger {
+	if limit <= 0 {
+		limit = DefaultDailyLimit
+	}
+	if softLimit <= 0 || softLimit > limit {
+		softLimit = limit
+	}
+	return &Ledger{queries: queries, limit: limit, soft: softLimit, now: time.Now}
+}
+
+// Spend records units for call. It fails with ErrExhausted, without recording
+// anything, if the day's limit would be exceeded.
+func (l *Ledger) Spend(ctx context.Context, call string, units int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	day := Day(l.now())
+	used, err := l.queries.GetQuotaUsed(ctx, day)
+	if err != nil {
+		return fmt.Errorf("quota: read usage: %w", err)
+	}
+	if used+units > l.limit {
+		return ErrExhausted
+	}
+	if err := l.queries.AddQuotaUsage(ctx, db.AddQuotaUsageParams{
+		Day:   day,
+		Call:  call,
+		Units: units,
+	}); err != nil {
+		return fmt.Errorf("quota: record usage: %w", err)
+	}
+	return nil
+}
+
+// Status is a snapshot of the current day's usage.
+type Status struct {
+	Used      int64
+	Limit     int64
+	SoftLimit int64
+	ResetAt   time.Time
+	Calls     []db.QuotaUsage
+}
+
+// Degraded reports whether non-essential calls such as search and fetching
+// older videos should be refused.
+func (s Status) Degraded() bool {
+	return s.Used >= s.SoftLimit
+}
+
+func (s Status) Exhausted() bool {
+	return s.Used >= s.Limit
+}
+
+// Percent returns the share of the daily limit used, capped at 100.
+func (s Status) Percent() int64 {
+	if s.Limit <= 0 {
+		return 0
+	}
+	return min(s.Used*100/s.Limit, 100)
+}
+
+func (l *Ledger) Status(ctx context.Context) (Status, error) {
+	now := l.now()
+	day := Day(now)
+	calls, err := l.queries.ListQuotaUsage(ctx, day)
+	if err != nil {
+		return Status{}, fmt.Errorf("quota: list usage: %w", err)
+	}
+	var used int64
+	for _, c := range calls {
+		used += c.Units
+	}
+	return Status{
+		Used:      used,
+		Limit:     l.limit,
+		SoftLimit: l.soft,
+		ResetAt:   nextReset(now),
+		Calls:     calls,
+	}, nil
+}
+
+// Degraded is a shortcut for Status(ctx).Degraded(). A ledger that can't be
+// read is treated as healthy so that a database hiccup doesn't disable search.
+func (l *Ledger) Degraded(ctx context.Context) bool {
+	s, err := l.Status(ctx)
+	return err == nil && s.Degraded()
+}
+
+// Day returns the quota day t falls on, as YYYY-MM-DD in Pacific time.
+func Day(t time.Time) string {
+	return t.In(pacific).Format(time.DateOnly)
+}
+
+func nextReset(t time.Time) time.Time {
+	p := t.In(pacific)
+	return time.Date(p.Year(), p.Month(), p.Day()+1, 0, 0, 0, 0, pacific)
+}
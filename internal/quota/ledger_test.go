@@ -0,0 +1,129 @@
+package quota
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+// newTestLedger returns a ledger on an in-memory database whose clock reads
+// *now.
+func newTestLedger(t *testing.T, limit, soft int64, now *time.Time) *Ledger {
+	t.Helper()
+	database, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each connection to :memory: is a database of its own.
+	database.SetMaxOpenConns(1)
+	t.Cleanup(func() { database.Close() })
+	if _, err := migrations.Up(context.Background(), database); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLedger(db.New(database), limit, soft)
+	l.now = func() time.Time { return *now }
+	return l
+}
+
+func TestSpendEnforcesTheDailyLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, pacific)
+	l := newTestLedger(t, 100, 80, &now)
+
+	if err := l.Spend(ctx, "search.list", 100); err != nil {
+		t.Fatalf("spending the whole limit: %v", err)
+	}
+	if err := l.Spend(ctx, "videos.list", 1); !errors.Is(err, ErrExhausted) {
+		t.Fatalf("Spend past the limit = %v, want ErrExhausted", err)
+	}
+	s, err := l.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Used != 100 || !s.Exhausted() || s.Percent() != 100 {
+		t.Errorf("Status = %+v, want 100 used and exhausted", s)
+	}
+	if len(s.Calls) != 1 || s.Calls[0].Call != "search.list" {
+		t.Errorf("refused call was recorded: %+v", s.Calls)
+	}
+}
+
+func TestUsageResetsAtPacificMidnight(t *testing.T) {
+	ctx := context.Background()
+	// 23:30 in Los Angeles is already the next day in UTC.
+	now := time.Date(2024, 3, 10, 23, 30, 0, 0, pacific)
+	l := newTestLedger(t, 100, 80, &now)
+	if err := l.Spend(ctx, "search.list", 100); err != nil {
+		t.Fatal(err)
+	}
+	s, err := l.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 11, 0, 0, 0, 0, pacific); !s.ResetAt.Equal(want) {
+		t.Errorf("ResetAt = %s, want %s", s.ResetAt, want)
+	}
+
+	// UTC midnight has passed, Pacific midnight hasn't: still exhausted.
+	now = time.Date(2024, 3, 11, 6, 45, 0, 0, time.UTC)
+	if err := l.Spend(ctx, "videos.list", 1); !errors.Is(err, ErrExhausted) {
+		t.Errorf("Spend after UTC midnight = %v, want ErrExhausted", err)
+	}
+
+	now = time.Date(2024, 3, 11, 0, 0, 0, 0, pacific)
+	if err := l.Spend(ctx, "videos.list", 1); err != nil {
+		t.Errorf("Spend after Pacific midnight: %v", err)
+	}
+	if s, _ := l.Status(ctx); s.Used != 1 {
+		t.Errorf("used after the reset = %d, want 1", s.Used)
+	}
+}
+
+func TestDegradedAtTheSoftLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, pacific)
+	l := newTestLedger(t, 100, 80, &now)
+
+	if err := l.Spend(ctx, "search.list", 79); err != nil {
+		t.Fatal(err)
+	}
+	if l.Degraded(ctx) {
+		t.Error("degraded below the soft limit")
+	}
+	if err := l.Spend(ctx, "videos.list", 1); err != nil {
+		t.Fatal(err)
+	}
+	if !l.Degraded(ctx) {
+		t.Error("not degraded at the soft limit")
+	}
+	if s, _ := l.Status(ctx); s.Exhausted() {
+		t.Error("exhausted at the soft limit")
+	}
+}
+
+func TestNewLedgerDefaults(t *testing.T) {
+	l := NewLedger(nil, 0, 0)
+	if l.limit != DefaultDailyLimit || l.soft != DefaultDailyLimit {
+		t.Errorf("limit %d, soft %d; want both %d", l.limit, l.soft, DefaultDailyLimit)
+	}
+	if l := NewLedger(nil, 100, 500); l.soft != 100 {
+		t.Errorf("soft limit above the limit became %d, want 100", l.soft)
+	}
+}
+
+func TestDegradedTreatsUnreadableLedgerAsHealthy(t *testing.T) {
+	now := time.Now()
+	l := newTestLedger(t, 100, 0, &now)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if l.Degraded(ctx) {
+		t.Error("unreadable ledger reported degraded")
+	}
+}
@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Every runs task every interval until ctx is cancelled, logging failures
+// under name. The first run happens one interval after the start. An
+// interval of zero or less disables the task, and Every returns at once.
+func Every(ctx context.Context, interval time.Duration, name string, log *slog.Logger, task func(context.Context) error) {
+	if interval <= 0 {
+		log.Info("scheduler: "+name+" disabled", "interval", interval)
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task(ctx); err != nil && ctx.Err() == nil {
+				log.Warn("scheduler: "+name, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryDisabled(t *testing.T) {
+	for _, interval := range []time.Duration{0, -5 * time.Minute} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			Every(context.Background(), interval, "test", discard, func(context.Context) error {
+				t.Error("disabled task ran")
+				return nil
+			})
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Every(%s) did not return", interval)
+		}
+	}
+}
+
+func TestEveryRunsUntilCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	ran := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Every(ctx, 5*time.Millisecond, "test", discard, func(context.Context) error {
+			runs.Add(1)
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			// Failures are logged and don't stop the task.
+			return errors.New("failed")
+		})
+	}()
+	for range 3 {
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatalf("task ran %d times, want 3", runs.Load())
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Every did not return once cancelled")
+	}
+}
@@ -0,0 +1,192 @@
+// Package scheduler refreshes subscriptions in the background so that open
+// deck columns no longer poll the YouTube API themselves.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"youtube-deck-go/internal/db"
+)
+
+// Refresher fetches and stores the latest videos of a subscription.
+type Refresher interface {
+	RefreshSubscription(ctx context.Context, sub db.Subscription) error
+}
+
+type Config struct {
+	// Interval is the time between refreshes of a subscription that has no
+	// refresh_interval of its own.
+	Interval time.Duration
+	// Jitter is the upper bound of the random delay added to each interval so
+	// that subscriptions added together don't stay in lockstep.
+	Jitter time.Duration
+	// Concurrency caps the number of refreshes running at the same time.
+	Concurrency int
+	// Tick is how often the scheduler looks for subscriptions that are due.
+	Tick time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Interval:    15 * time.Minute,
+		Jitter:      2 * time.Minute,
+		Concurrency: 4,
+		Tick:        30 * time.Second,
+	}
+}
+
+type Scheduler struct {
+	queries   *db.Queries
+	refresher Refresher
+	cfg       Config
+	log       *slog.Logger
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	mu        sync.Mutex
+	inFlight  map[int64]bool
+	jitter    map[int64]time.Duration
+	notBefore map[int64]time.Time
+}
+
+func New(queries *db.Queries, refresher Refresher, cfg Config, log *slog.Logger) *Scheduler {
+	def := DefaultConfig()
+	if cfg.Interval <= 0 {
+		cfg.Interval = def.Interval
+	}
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = def.Concurrency
+	}
+	if cfg.Tick <= 0 {
+		cfg.Tick = def.Tick
+	}
+	return &Scheduler{
+		queries:   queries,
+		refresher: refresher,
+		cfg:       cfg,
+		log:       log,
+		sem:       make(chan struct{}, cfg.Concurrency),
+		inFlight:  make(map[int64]bool),
+		jitter:    make(map[int64]time.Duration),
+		notBefore: make(map[int64]time.Time),
+	}
+}
+
+// Run checks for due subscriptions every Tick until ctx is cancelled, then
+// waits for in-flight refreshes to finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Tick)
+	defer ticker.Stop()
+
+	s.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return
+		case <-ticker.C:
+			s.tick(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	subs, err := s.queries.ListSubscriptionsForRefresh(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.log.Error("scheduler: list subscriptions", "error", err)
+		}
+		return
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		if !s.due(sub, now) {
+			continue
+		}
+		select {
+		case s.sem <- struct{}{}:
+		default:
+			// Concurrency cap reached; the remaining subscriptions are
+			// still due on the next tick.
+			return
+		}
+		if !s.claim(sub.ID) {
+			<-s.sem
+			continue
+		}
+
+		s.wg.Add(1)
+		go func(sub db.Subscription) {
+			defer s.wg.Done()
+			defer func() { <-s.sem }()
+
+			err := s.refresher.RefreshSubscription(ctx, sub)
+			if err != nil && ctx.Err() == nil {
+				s.log.Warn("scheduler: refresh subscription", "id", sub.ID, "name", sub.Name, "error", err)
+			}
+			s.release(sub, err)
+		}(sub)
+	}
+}
+
+func (s *Scheduler) due(sub db.Subscription, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Before(s.notBefore[sub.ID]) {
+		return false
+	}
+	if !sub.LastChecked.Valid {
+		return true
+	}
+
+	jitter, ok := s.jitter[sub.ID]
+	if !ok {
+		if s.cfg.Jitter > 0 {
+			jitter = rand.N(s.cfg.Jitter)
+		}
+		s.jitter[sub.ID] = jitter
+	}
+	return !now.Before(sub.LastChecked.Time.Add(s.interval(sub) + jitter))
+}
+
+func (s *Scheduler) interval(sub db.Subscription) time.Duration {
+	if sub.RefreshInterval.Valid && sub.RefreshInterval.Int64 > 0 {
+		return time.Duration(sub.RefreshInterval.Int64) * time.Second
+	}
+	return s.cfg.Interval
+}
+
+func (s *Scheduler) claim(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight[id] {
+		return false
+	}
+	s.inFlight[id] = true
+	return true
+}
+
+// release marks a refresh as finished. A fresh jitter is drawn for the next
+// cycle, and failed refreshes back off for the subscription's interval
+// instead of being retried on every tick.
+func (s *Scheduler) release(sub db.Subscription, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, sub.ID)
+	delete(s.jitter, sub.ID)
+	if err != nil {
+		s.notBefore[sub.ID] = time.Now().Add(s.interval(sub))
+	} else {
+		delete(s.notBefore, sub.ID)
+	}
+}
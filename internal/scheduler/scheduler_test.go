@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"youtube-deck-go/internal/db"
+	"youtube-deck-go/internal/db/migrations"
+
+	_ "modernc.org/sqlite"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func checkedAt(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func TestDue(t *testing.T) {
+	s := New(nil, nil, Config{Interval: 15 * time.Minute, Jitter: 2 * time.Minute}, discard)
+	now := time.Now()
+
+	if !s.due(db.Subscription{ID: 1}, now) {
+		t.Error("never checked subscription not due")
+	}
+	if s.due(db.Subscription{ID: 2, LastChecked: checkedAt(now.Add(-14 * time.Minute))}, now) {
+		t.Error("subscription due before its interval")
+	}
+	// Past interval and jitter together, whatever jitter was drawn.
+	if !s.due(db.Subscription{ID: 3, LastChecked: checkedAt(now.Add(-17 * time.Minute))}, now) {
+		t.Error("subscription not due past interval plus jitter")
+	}
+
+	own := db.Subscription{ID: 4, LastChecked: checkedAt(now.Add(-6 * time.Minute)), RefreshInterval: sql.NullInt64{Int64: 300, Valid: true}}
+	s.cfg.Jitter = 0
+	if !s.due(own, now) {
+		t.Error("subscription not due after its own refresh interval")
+	}
+}
+
+func TestJitterIsKeptUntilRelease(t *testing.T) {
+	s := New(nil, nil, Config{Interval: time.Minute, Jitter: time.Hour}, discard)
+	sub := db.Subscription{ID: 1, LastChecked: checkedAt(time.Now())}
+	s.due(sub, time.Now())
+	first := s.jitter[sub.ID]
+	if first < 0 || first >= time.Hour {
+		t.Fatalf("jitter %s out of [0, 1h)", first)
+	}
+	s.due(sub, time.Now())
+	if s.jitter[sub.ID] != first {
+		t.Error("jitter redrawn before the refresh")
+	}
+	s.release(sub, nil)
+	if _, ok := s.jitter[sub.ID]; ok {
+		t.Error("jitter kept after the refresh")
+	}
+}
+
+func TestFailedRefreshBacksOffForOwnInterval(t *testing.T) {
+	s := New(nil, nil, Config{Interval: time.Hour}, discard)
+	sub := db.Subscription{ID: 1, RefreshInterval: sql.NullInt64{Int64: 300, Valid: true}}
+
+	s.release(sub, errors.New("quota exhausted"))
+	backoff := time.Until(s.notBefore[sub.ID])
+	if backoff <= 4*time.Minute || backoff > 5*time.Minute {
+		t.Errorf("backoff = %s, want the subscription's 5m", backoff)
+	}
+	if s.due(sub, time.Now()) {
+		t.Error("failed subscription due again right away")
+	}
+	if !s.due(sub, time.Now().Add(6*time.Minute)) {
+		t.Error("failed subscription not due after backing off")
+	}
+
+	s.release(sub, nil)
+	if _, ok := s.notBefore[sub.ID]; ok {
+		t.Error("backoff kept after a successful refresh")
+	}
+}
+
+// blockingRefresher holds every refresh until release is closed.
+type blockingRefresher struct {
+	mu      sync.Mutex
+	running int
+	max     int
+	calls   int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingRefresher) RefreshSubscription(ctx context.Context, sub db.Subscription) error {
+	r.mu.Lock()
+	r.calls++
+	r.running++
+	r.max = max(r.max, r.running)
+	r.mu.Unlock()
+	r.started <- struct{}{}
+	<-r.release
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return nil
+}
+
+func TestTickCapsConcurrency(t *testing.T) {
+	database, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.SetMaxOpenConns(1)
+	defer database.Close()
+	ctx := context.Background()
+	if _, err := migrations.Up(ctx, database); err != nil {
+		t.Fatal(err)
+	}
+	queries := db.New(database)
+	for _, id := range []string{"UCsub000000000000000000a", "UCsub000000000000000000b", "UCsub000000000000000000c"} {
+		sub, err := queries.CreateSubscription(ctx, db.CreateSubscriptionParams{Name: id, YoutubeID: id, Type: "channel"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := queries.AddDeckSubscription(ctx, db.AddDeckSubscriptionParams{DeckID: 1, SubscriptionID: sql.NullInt64{Int64: sub.ID, Valid: true}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	refresher := &blockingRefresher{started: make(chan struct{}, 10), release: make(chan struct{})}
+	s := New(queries, refresher, Config{Concurrency: 2}, discard)
+	s.tick(ctx)
+	<-refresher.started
+	<-refresher.started
+	// In-flight subscriptions aren't claimed twice, and the third waits for
+	// a free slot.
+	s.tick(ctx)
+	close(refresher.release)
+	s.wg.Wait()
+	if refresher.max != 2 || refresher.calls != 2 {
+		t.Errorf("ran %d refreshes, at most %d at once; want 2 and 2", refresher.calls, refresher.max)
+	}
+
+	// Never checked, the third is still due on the next tick.
+	s.tick(ctx)
+	s.wg.Wait()
+	if refresher.calls < 3 {
+		t.Errorf("ran %d refreshes after a free tick, want the third too", refresher.calls)
+	}
+}
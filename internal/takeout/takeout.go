@@ -0,0 +1,196 @@
+// Package takeout reads the YouTube files of a Google Takeout export: the
+// channel subscriptions, the user's own playlists and the watch history.
+//
+// Takeout names its files in the account's language; only the English names
+// are recognized.
+package takeout
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Kinds of file, as told by Classify.
+const (
+	Subscriptions = "subscriptions"
+	Playlists     = "playlists"
+	WatchHistory  = "watch history"
+)
+
+// ErrHTMLHistory is returned by Classify for a watch history exported as
+// HTML, which carries the same data as the JSON one but isn't worth parsing.
+var ErrHTMLHistory = errors.New("takeout: watch history exported as HTML; export it as JSON instead")
+
+// Classify tells which kind of file name is from its base name. It returns ""
+// for files this package can't read. Every CSV but subscriptions.csv is
+// assumed to describe playlists, as Takeout puts one file per playlist in the
+// playlists folder.
+func Classify(name string) (string, error) {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(name, `\`, "/")))
+	switch {
+	case base == "subscriptions.csv":
+		return Subscriptions, nil
+	case base == "watch-history.json":
+		return WatchHistory, nil
+	case base == "watch-history.html":
+		return "", ErrHTMLHistory
+	case strings.HasSuffix(base, ".csv"):
+		return Playlists, nil
+	}
+	return "", nil
+}
+
+// Channel is a channel subscribed to.
+type Channel struct {
+	ID    string
+	URL   string
+	Title string
+}
+
+// ReadSubscriptions reads subscriptions.csv, whose columns are the channel
+// ID, URL and title.
+func ReadSubscriptions(r io.Reader) ([]Channel, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := columns(rows[0])
+	id, ok := header["channel id"]
+	if !ok {
+		return nil, errors.New("takeout: subscriptions.csv has no Channel Id column")
+	}
+	urlCol, hasURL := header["channel url"]
+	title, hasTitle := header["channel title"]
+
+	var channels []Channel
+	for _, row := range rows[1:] {
+		c := Channel{ID: field(row, id)}
+		if hasURL {
+			c.URL = field(row, urlCol)
+		}
+		if hasTitle {
+			c.Title = field(row, title)
+		}
+		if c.ID != "" || c.URL != "" {
+			channels = append(channels, c)
+		}
+	}
+	return channels, nil
+}
+
+// Playlist is one of the user's own playlists.
+type Playlist struct {
+	ID    string
+	Title string
+}
+
+// ReadPlaylists reads the playlists listed in a CSV file. It understands
+// both the playlists.csv of current exports, one row per playlist, and the
+// per-playlist files of older ones, which start with a row describing the
+// playlist before listing its videos. Files that describe no playlist, such
+// as the video lists of current exports, yield none.
+func ReadPlaylists(r io.Reader) ([]Playlist, error) {
+	rows, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	header := columns(rows[0])
+	id, ok := header["playlist id"]
+	if !ok {
+		return nil, nil
+	}
+	title := -1
+	for _, name := range []string{"playlist title (original)", "playlist title", "title"} {
+		if i, ok := header[name]; ok {
+			title = i
+			break
+		}
+	}
+
+	var playlists []Playlist
+	for _, row := range rows[1:] {
+		// Older exports follow the playlist row with a blank line,
+		// which the CSV reader skips, and a table of the videos.
+		if strings.EqualFold(field(row, 0), "video id") {
+			break
+		}
+		p := Playlist{ID: field(row, id)}
+		if title >= 0 {
+			p.Title = field(row, title)
+		}
+		if p.ID != "" {
+			playlists = append(playlists, p)
+		}
+	}
+	return playlists, nil
+}
+
+// Watch is a video in the watch history.
+type Watch struct {
+	// URL is the watch page of the video.
+	URL  string
+	Time time.Time
+}
+
+// ReadWatchHistory reads watch-history.json. Videos that have since been
+// removed are listed without a URL and are left out.
+func ReadWatchHistory(r io.Reader) ([]Watch, error) {
+	var entries []struct {
+		TitleURL string    `json:"titleUrl"`
+		Time     time.Time `json:"time"`
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("takeout: watch history: %w", err)
+	}
+	var watches []Watch
+	for _, e := range entries {
+		if e.TitleURL != "" {
+			watches = append(watches, Watch{URL: e.TitleURL, Time: e.Time})
+		}
+	}
+	return watches, nil
+}
+
+// readCSV reads every record of a CSV file, allowing them to differ in
+// length and dropping the byte order mark Takeout starts files with.
+func readCSV(r io.Reader) ([][]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("takeout: %w", err)
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
+
+// columns maps the lowercased names in a header row to their index.
+func columns(header []string) map[string]int {
+	m := make(map[string]int, len(header))
+	for i, name := range header {
+		m[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return m
+}
+
+func field(row []string, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
@@ -0,0 +1,111 @@
+package takeout
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	for name, want := range map[string]string{
+		"Takeout/YouTube and YouTube Music/subscriptions/subscriptions.csv": Subscriptions,
+		"Takeout/YouTube and YouTube Music/history/watch-history.json":      WatchHistory,
+		"Takeout/YouTube and YouTube Music/playlists/playlists.csv":         Playlists,
+		`Takeout\YouTube and YouTube Music\playlists\Cooking.csv`:           Playlists,
+		"Takeout/YouTube and YouTube Music/history/search-history.json":     "",
+		"Takeout/archive_browser.html":                                      "",
+	} {
+		if got, err := Classify(name); got != want || err != nil {
+			t.Errorf("Classify(%q) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := Classify("history/watch-history.html"); err != ErrHTMLHistory {
+		t.Errorf("Classify(watch-history.html) error = %v", err)
+	}
+}
+
+func TestReadSubscriptions(t *testing.T) {
+	const file = "\ufeffChannel Id,Channel Url,Channel Title\n" +
+		"UCHnyfMqiRRG1u-2MsSQLbXA,http://www.youtube.com/channel/UCHnyfMqiRRG1u-2MsSQLbXA,Veritasium\n" +
+		"UCsXVk37bltHxD1rDPwtNM8Q,http://www.youtube.com/channel/UCsXVk37bltHxD1rDPwtNM8Q,\"Kurzgesagt – In a Nutshell\"\n" +
+		"\n"
+	channels, err := ReadSubscriptions(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(channels) != 2 || channels[0].ID != "UCHnyfMqiRRG1u-2MsSQLbXA" || channels[1].Title != "Kurzgesagt – In a Nutshell" {
+		t.Errorf("ReadSubscriptions = %+v", channels)
+	}
+	if _, err := ReadSubscriptions(strings.NewReader("Video ID,Time\nabc,def\n")); err == nil {
+		t.Error("ReadSubscriptions accepted a file without channels")
+	}
+}
+
+func TestReadPlaylists(t *testing.T) {
+	for _, tc := range []struct {
+		name, file string
+		want       []Playlist
+	}{
+		{
+			"current playlists.csv",
+			"Playlist ID,Add new videos to top,Playlist title (original),Playlist title (original) language,Playlist create timestamp\n" +
+				"PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx,False,Cooking,en,2020-01-01T00:00:00+00:00\n" +
+				"PLbpi6ZahtOH6Blw3RGYpWkSByi_T7Rygb,True,Talks,en,2021-01-01T00:00:00+00:00\n",
+			[]Playlist{{"PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx", "Cooking"}, {"PLbpi6ZahtOH6Blw3RGYpWkSByi_T7Rygb", "Talks"}},
+		},
+		{
+			"older per-playlist file",
+			"Playlist Id,Channel Id,Time Created,Time Updated,Title,Description,Visibility\n" +
+				"PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx,UCHnyfMqiRRG1u-2MsSQLbXA,2020-01-01 00:00:00 UTC,2020-01-02 00:00:00 UTC,Cooking,,Public\n" +
+				"\n" +
+				"Video Id,Time Added\n" +
+				"dQw4w9WgXcQ,2020-01-01 00:00:00 UTC\n",
+			[]Playlist{{"PLxA687tYuMWhkqYjvAGtW_heiEL4Hk_Lx", "Cooking"}},
+		},
+		{
+			"current video list",
+			"Video ID,Playlist Video Creation Timestamp\ndQw4w9WgXcQ,2020-01-01T00:00:00+00:00\n",
+			nil,
+		},
+	} {
+		got, err := ReadPlaylists(strings.NewReader(tc.file))
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: ReadPlaylists = %+v, want %+v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: playlist %d = %+v, want %+v", tc.name, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
+
+func TestReadWatchHistory(t *testing.T) {
+	const file = `[{
+  "header": "YouTube",
+  "title": "Watched Never Gonna Give You Up",
+  "titleUrl": "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+  "time": "2024-03-01T20:15:00.123Z",
+  "products": ["YouTube"]
+},{
+  "header": "YouTube",
+  "title": "Watched a video that has been removed",
+  "time": "2024-02-01T10:00:00Z"
+}]`
+	watches, err := ReadWatchHistory(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2024, 3, 1, 20, 15, 0, 123e6, time.UTC)
+	if len(watches) != 1 || watches[0].URL != "https://www.youtube.com/watch?v=dQw4w9WgXcQ" || !watches[0].Time.Equal(want) {
+		t.Errorf("ReadWatchHistory = %+v", watches)
+	}
+	if _, err := ReadWatchHistory(strings.NewReader("<html>")); err == nil {
+		t.Error("ReadWatchHistory accepted HTML")
+	}
+}
@@ -0,0 +1,82 @@
+package youtube
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+// AccountSource reads the YouTube account the user signed in with. *Account
+// implements it; youtubetest.Fake is an in-memory implementation for tests.
+type AccountSource interface {
+	// MySubscriptions lists the channels the account subscribes to. If
+	// progress isn't nil, it is called after each page with how many
+	// channels were listed so far out of how many there are.
+	MySubscriptions(ctx context.Context, progress func(done, total int64)) ([]SearchResult, error)
+}
+
+var _ AccountSource = (*Account)(nil)
+
+// Account makes Data API calls on behalf of the signed-in user, charging
+// them to the same quota as the Client's.
+type Account struct {
+	// client returns an HTTP client authorized with the user's token, or
+	// an error when nobody is signed in.
+	client func(ctx context.Context) (*http.Client, error)
+	quota  Quota
+}
+
+// NewAccount creates an Account whose calls are made with the HTTP clients
+// returned by client. quota may be nil to disable accounting.
+func NewAccount(client func(ctx context.Context) (*http.Client, error), quota Quota) *Account {
+	return &Account{client: client, quota: quota}
+}
+
+func (a *Account) MySubscriptions(ctx context.Context, progress func(done, total int64)) ([]SearchResult, error) {
+	httpClient, err := a.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	service, err := youtube.NewService(ctx, option.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("youtube: create account service: %w", err)
+	}
+
+	var results []SearchResult
+	pageToken := ""
+	for {
+		if a.quota != nil {
+			if err := a.quota.Spend(ctx, "subscriptions.list", costSubscriptionsList); err != nil {
+				return nil, fmt.Errorf("youtube: subscriptions.list: %w", err)
+			}
+		}
+		call := service.Subscriptions.List([]string{"snippet"}).Mine(true).MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("youtube: list my subscriptions: %w", err)
+		}
+		for _, item := range resp.Items {
+			if item.Snippet == nil || item.Snippet.ResourceId == nil {
+				continue
+			}
+			results = append(results, SearchResult{
+				ID:           item.Snippet.ResourceId.ChannelId,
+				Title:        item.Snippet.Title,
+				ThumbnailURL: getBestThumbnail(item.Snippet.Thumbnails),
+				Type:         "channel",
+			})
+		}
+		if progress != nil && resp.PageInfo != nil {
+			progress(int64(len(results)), resp.PageInfo.TotalResults)
+		}
+		if pageToken = resp.NextPageToken; pageToken == "" {
+			return results, nil
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package youtube
+
+import "slices"
+
+// Availability of a stored video. AvailabilityMissing covers videos the Data
+// API no longer returns, which are deleted or private; it can't tell which.
+const (
+	AvailabilityAvailable = "available"
+	AvailabilityMissing   = "missing"
+	AvailabilityRemoved   = "removed"
+	AvailabilityBlocked   = "blocked"
+)
+
+// Availability reports whether v, as returned by the Data API, can be watched
+// from region. YouTube still lists some videos it took down; those are
+// removed. An empty region ignores region restrictions.
+func Availability(v VideoInfo, region string) string {
+	switch v.UploadStatus {
+	case "rejected", "deleted", "failed":
+		return AvailabilityRemoved
+	}
+	if region == "" {
+		return AvailabilityAvailable
+	}
+	if slices.Contains(v.RegionBlocked, region) ||
+		(len(v.RegionAllowed) > 0 && !slices.Contains(v.RegionAllowed, region)) {
+		return AvailabilityBlocked
+	}
+	return AvailabilityAvailable
+}
@@ -0,0 +1,28 @@
+package youtube
+
+import "testing"
+
+func TestAvailability(t *testing.T) {
+	tests := []struct {
+		name   string
+		v      VideoInfo
+		region string
+		want   string
+	}{
+		{"processed", VideoInfo{UploadStatus: "processed"}, "DE", AvailabilityAvailable},
+		{"rejected", VideoInfo{UploadStatus: "rejected"}, "DE", AvailabilityRemoved},
+		{"deleted", VideoInfo{UploadStatus: "deleted"}, "", AvailabilityRemoved},
+		{"blocked here", VideoInfo{RegionBlocked: []string{"DE", "AT"}}, "DE", AvailabilityBlocked},
+		{"blocked elsewhere", VideoInfo{RegionBlocked: []string{"AT"}}, "DE", AvailabilityAvailable},
+		{"allowed here", VideoInfo{RegionAllowed: []string{"DE"}}, "DE", AvailabilityAvailable},
+		{"allowed elsewhere", VideoInfo{RegionAllowed: []string{"US"}}, "DE", AvailabilityBlocked},
+		{"no region", VideoInfo{RegionBlocked: []string{"DE"}}, "", AvailabilityAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Availability(tt.v, tt.region); got != tt.want {
+				t.Errorf("Availability() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
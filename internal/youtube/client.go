@@ -2,6 +2,7 @@ package youtube
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -14,8 +15,39 @@ import (
 type Client struct {
 	service    *youtube.Service
 	httpClient *http.Client
+	quota      Quota
+	// shortsURL is the prefix ProbeShort appends video IDs to.
+	shortsURL string
 }
 
+// DefaultShortsURL is where YouTube serves Shorts.
+const DefaultShortsURL = "https://www.youtube.com/shorts/"
+
+// VideoStatistics are the public counters of a video at the time it was
+// fetched. Counts hidden by the uploader are zero.
+type VideoStatistics struct {
+	Views    int64
+	Likes    int64
+	Comments int64
+}
+
+// Quota is charged before every Data API call. Spend returns an error when
+// the call must not be made.
+type Quota interface {
+	Spend(ctx context.Context, call string, units int64) error
+}
+
+// Unit costs of the Data API calls the client makes, see
+// https://developers.google.com/youtube/v3/determine_quota_cost
+const (
+	costSearchList        = 100
+	costChannelsList      = 1
+	costPlaylistItemsList = 1
+	costPlaylistsList     = 1
+	costSubscriptionsList = 1
+	costVideosList        = 1
+)
+
 type SearchResult struct {
 	ID           string
 	Title        string
@@ -23,13 +55,58 @@ type SearchResult struct {
 	Type         string
 }
 
+// Broadcast states of a video. Videos that never were a livestream or
+// premiere are BroadcastNone; ones that were are BroadcastCompleted once they
+// end.
+const (
+	BroadcastNone      = "none"
+	BroadcastUpcoming  = "upcoming"
+	BroadcastLive      = "live"
+	BroadcastCompleted = "completed"
+)
+
 type VideoInfo struct {
 	ID           string
 	Title        string
 	ThumbnailURL string
 	Duration     string
 	PublishedAt  time.Time
-	IsShort      bool
+	Description  string
+	// Statistics is nil when the Data API wasn't asked.
+	Statistics *VideoStatistics
+	// Short and ShortSource classify the video, see ClassifyShort.
+	Short       ShortsVerdict
+	ShortSource string
+	// EmbedWidth and EmbedHeight are the size of the player, which follows
+	// the aspect ratio of the video. Zero when unknown.
+	EmbedWidth  int64
+	EmbedHeight int64
+	// UploadStatus is the Data API's status.uploadStatus, "rejected" or
+	// "deleted" for videos YouTube took down but still lists.
+	UploadStatus string
+	// RegionAllowed and RegionBlocked are the region restrictions of the
+	// video, as ISO 3166-1 alpha-2 codes. Both are empty when it has none.
+	RegionAllowed []string
+	RegionBlocked []string
+	// BroadcastState is one of the Broadcast constants, or empty when the
+	// Data API wasn't asked, as for videos read from a feed.
+	BroadcastState string
+	// ScheduledStartAt is when an upcoming livestream or premiere starts.
+	ScheduledStartAt time.Time
+	// PlaylistPosition is the zero-based position of the video in the
+	// playlist it was read from, or nil when it wasn't read from one.
+	PlaylistPosition *int64
+}
+
+// ChannelInfo is the channel metadata that is stored on a subscription. The
+// uploads playlist never changes, so it only has to be looked up once.
+type ChannelInfo struct {
+	ID                string
+	Title             string
+	ThumbnailURL      string
+	UploadsPlaylistID string
+	CustomURL         string
+	SubscriberCount   int64
 }
 
 type FetchResult struct {
@@ -37,7 +114,9 @@ type FetchResult struct {
 	NextPageToken string
 }
 
-func New(apiKey string) (*Client, error) {
+// New creates a client authenticated with apiKey. Every API call is charged to
+// quota, which may be nil to disable accounting.
+func New(apiKey string, quota Quota) (*Client, error) {
 	ctx := context.Background()
 	service, err := youtube.NewService(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
@@ -49,10 +128,23 @@ func New(apiKey string) (*Client, error) {
 			return http.ErrUseLastResponse // Don't follow redirects
 		},
 	}
-	return &Client{service: service, httpClient: httpClient}, nil
+	return &Client{service: service, httpClient: httpClient, quota: quota, shortsURL: DefaultShortsURL}, nil
+}
+
+func (c *Client) charge(ctx context.Context, call string, units int64) error {
+	if c.quota == nil {
+		return nil
+	}
+	if err := c.quota.Spend(ctx, call, units); err != nil {
+		return fmt.Errorf("youtube: %s: %w", call, err)
+	}
+	return nil
 }
 
 func (c *Client) SearchChannels(ctx context.Context, query string, maxResults int64) ([]SearchResult, error) {
+	if err := c.charge(ctx, "search.list", costSearchList); err != nil {
+		return nil, err
+	}
 	call := c.service.Search.List([]string{"snippet"}).
 		Q(query).
 		Type("channel").
@@ -76,6 +168,9 @@ func (c *Client) SearchChannels(ctx context.Context, query string, maxResults in
 }
 
 func (c *Client) SearchPlaylists(ctx context.Context, query string, maxResults int64) ([]SearchResult, error) {
+	if err := c.charge(ctx, "search.list", costSearchList); err != nil {
+		return nil, err
+	}
 	call := c.service.Search.List([]string{"snippet"}).
 		Q(query).
 		Type("playlist").
@@ -106,20 +201,69 @@ func (c *Client) FetchChannelVideos(ctx context.Context, channelID string, maxRe
 	return result.Videos, nil
 }
 
+// FetchChannelVideosWithToken resolves the uploads playlist of channelID and
+// fetches it. Callers that have the uploads playlist ID stored should call
+// FetchPlaylistVideosWithToken directly and save the Channels.List call.
 func (c *Client) FetchChannelVideosWithToken(ctx context.Context, channelID string, pageToken string, maxResults int64) (*FetchResult, error) {
-	channelResp, err := c.service.Channels.List([]string{"contentDetails"}).
-		Id(channelID).
-		Context(ctx).
-		Do()
+	channels, err := c.GetChannels(ctx, []string{channelID})
 	if err != nil {
-		return nil, fmt.Errorf("youtube: get channel: %w", err)
+		return nil, err
 	}
-	if len(channelResp.Items) == 0 {
+	if len(channels) == 0 {
 		return nil, fmt.Errorf("youtube: channel not found: %s", channelID)
 	}
+	if channels[0].UploadsPlaylistID == "" {
+		return nil, fmt.Errorf("youtube: channel has no uploads playlist: %s", channelID)
+	}
+	return c.FetchPlaylistVideosWithToken(ctx, channels[0].UploadsPlaylistID, pageToken, maxResults)
+}
+
+// embedMaxWidth is passed to videos.list so that the player size it reports
+// reflects the aspect ratio of each video.
+const embedMaxWidth = 480
 
-	uploadsPlaylistID := channelResp.Items[0].ContentDetails.RelatedPlaylists.Uploads
-	return c.FetchPlaylistVideosWithToken(ctx, uploadsPlaylistID, pageToken, maxResults)
+// maxIDsPerCall is the number of IDs the list endpoints accept per request.
+const maxIDsPerCall = 50
+
+// GetChannels looks up channel metadata for ids, 50 per API call. Channels
+// that don't exist are missing from the result.
+func (c *Client) GetChannels(ctx context.Context, ids []string) ([]ChannelInfo, error) {
+	channels := make([]ChannelInfo, 0, len(ids))
+	for start := 0; start < len(ids); start += maxIDsPerCall {
+		batch := ids[start:min(start+maxIDsPerCall, len(ids))]
+
+		if err := c.charge(ctx, "channels.list", costChannelsList); err != nil {
+			return nil, err
+		}
+		resp, err := c.service.Channels.List([]string{"snippet", "contentDetails", "statistics"}).
+			Id(batch...).
+			MaxResults(maxIDsPerCall).
+			Context(ctx).
+			Do()
+		if err != nil {
+			return nil, fmt.Errorf("youtube: get channels: %w", err)
+		}
+		for _, item := range resp.Items {
+			channels = append(channels, channelInfo(item))
+		}
+	}
+	return channels, nil
+}
+
+func channelInfo(item *youtube.Channel) ChannelInfo {
+	info := ChannelInfo{ID: item.Id}
+	if item.Snippet != nil {
+		info.Title = item.Snippet.Title
+		info.ThumbnailURL = getBestThumbnail(item.Snippet.Thumbnails)
+		info.CustomURL = item.Snippet.CustomUrl
+	}
+	if item.ContentDetails != nil && item.ContentDetails.RelatedPlaylists != nil {
+		info.UploadsPlaylistID = item.ContentDetails.RelatedPlaylists.Uploads
+	}
+	if item.Statistics != nil && !item.Statistics.HiddenSubscriberCount {
+		info.SubscriberCount = int64(item.Statistics.SubscriberCount)
+	}
+	return info
 }
 
 func (c *Client) FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]VideoInfo, error) {
@@ -131,6 +275,12 @@ func (c *Client) FetchPlaylistVideos(ctx context.Context, playlistID string, max
 }
 
 func (c *Client) FetchPlaylistVideosWithToken(ctx context.Context, playlistID string, pageToken string, maxResults int64) (*FetchResult, error) {
+	if playlistID == "" {
+		return nil, errors.New("youtube: fetch playlist: empty playlist ID")
+	}
+	if err := c.charge(ctx, "playlistItems.list", costPlaylistItemsList); err != nil {
+		return nil, err
+	}
 	call := c.service.PlaylistItems.List([]string{"snippet", "contentDetails"}).
 		PlaylistId(playlistID).
 		MaxResults(maxResults)
@@ -145,37 +295,177 @@ func (c *Client) FetchPlaylistVideosWithToken(ctx context.Context, playlistID st
 	}
 
 	videoIDs := make([]string, 0, len(resp.Items))
+	positions := make(map[string]int64, len(resp.Items))
 	for _, item := range resp.Items {
 		videoIDs = append(videoIDs, item.ContentDetails.VideoId)
+		if _, ok := positions[item.ContentDetails.VideoId]; !ok {
+			positions[item.ContentDetails.VideoId] = item.Snippet.Position
+		}
 	}
 
 	if len(videoIDs) == 0 {
 		return &FetchResult{Videos: nil, NextPageToken: ""}, nil
 	}
 
-	videoResp, err := c.service.Videos.List([]string{"snippet", "contentDetails"}).
-		Id(videoIDs...).
-		Context(ctx).
-		Do()
+	videos, err := c.GetVideos(ctx, videoIDs)
 	if err != nil {
-		return nil, fmt.Errorf("youtube: fetch video details: %w", err)
+		return nil, err
+	}
+	for i := range videos {
+		if pos, ok := positions[videos[i].ID]; ok {
+			videos[i].PlaylistPosition = &pos
+		}
+	}
+	return &FetchResult{Videos: videos, NextPageToken: resp.NextPageToken}, nil
+}
+
+// PlaylistPositions reads the whole of a playlist and returns the zero-based
+// position of every video in it, 50 items per API call. A video that is in
+// the playlist more than once keeps its first position.
+func (c *Client) PlaylistPositions(ctx context.Context, playlistID string) (map[string]int64, error) {
+	positions := make(map[string]int64)
+	pageToken := ""
+	for {
+		if err := c.charge(ctx, "playlistItems.list", costPlaylistItemsList); err != nil {
+			return nil, err
+		}
+		call := c.service.PlaylistItems.List([]string{"snippet"}).
+			PlaylistId(playlistID).
+			MaxResults(maxIDsPerCall)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("youtube: fetch playlist positions: %w", err)
+		}
+		for _, item := range resp.Items {
+			if item.Snippet.ResourceId == nil {
+				continue
+			}
+			id := item.Snippet.ResourceId.VideoId
+			if _, ok := positions[id]; !ok {
+				positions[id] = item.Snippet.Position
+			}
+		}
+		if resp.NextPageToken == "" {
+			return positions, nil
+		}
+		pageToken = resp.NextPageToken
 	}
+}
+
+// GetVideos looks up the details of ids, 50 per API call. Videos that are
+// unavailable are missing from the result.
+func (c *Client) GetVideos(ctx context.Context, ids []string) ([]VideoInfo, error) {
+	videos := make([]VideoInfo, 0, len(ids))
+	for start := 0; start < len(ids); start += maxIDsPerCall {
+		batch := ids[start:min(start+maxIDsPerCall, len(ids))]
 
-	videos := make([]VideoInfo, 0, len(videoResp.Items))
-	for _, v := range videoResp.Items {
-		publishedAt, err := time.Parse(time.RFC3339, v.Snippet.PublishedAt)
+		if err := c.charge(ctx, "videos.list", costVideosList); err != nil {
+			return nil, err
+		}
+		videoResp, err := c.service.Videos.List([]string{"snippet", "contentDetails", "liveStreamingDetails", "player", "statistics", "status"}).
+			Id(batch...).
+			MaxWidth(embedMaxWidth).
+			Context(ctx).
+			Do()
 		if err != nil {
-			publishedAt = time.Now()
-		}
-		videos = append(videos, VideoInfo{
-			ID:           v.Id,
-			Title:        v.Snippet.Title,
-			ThumbnailURL: getBestThumbnail(v.Snippet.Thumbnails),
-			Duration:     v.ContentDetails.Duration,
-			PublishedAt:  publishedAt,
-		})
+			return nil, fmt.Errorf("youtube: fetch video details: %w", err)
+		}
+
+		for _, v := range videoResp.Items {
+			videos = append(videos, videoInfo(v))
+		}
 	}
-	return &FetchResult{Videos: videos, NextPageToken: resp.NextPageToken}, nil
+	return videos, nil
+}
+
+// videoInfo converts a Data API video. Livestreams and premieres report the
+// time they were scheduled as publishedAt and a zero duration until they end,
+// so they are dated by their (scheduled) start and have no duration until
+// then.
+func videoInfo(v *youtube.Video) VideoInfo {
+	info := VideoInfo{
+		ID:             v.Id,
+		Title:          v.Snippet.Title,
+		ThumbnailURL:   getBestThumbnail(v.Snippet.Thumbnails),
+		Description:    v.Snippet.Description,
+		BroadcastState: BroadcastNone,
+	}
+	if v.Statistics != nil {
+		info.Statistics = &VideoStatistics{
+			Views:    int64(v.Statistics.ViewCount),
+			Likes:    int64(v.Statistics.LikeCount),
+			Comments: int64(v.Statistics.CommentCount),
+		}
+	}
+	if v.ContentDetails != nil {
+		info.Duration = v.ContentDetails.Duration
+		if rr := v.ContentDetails.RegionRestriction; rr != nil {
+			info.RegionAllowed = rr.Allowed
+			info.RegionBlocked = rr.Blocked
+		}
+	}
+	if v.Status != nil {
+		info.UploadStatus = v.Status.UploadStatus
+	}
+	if v.Player != nil {
+		info.EmbedWidth = v.Player.EmbedWidth
+		info.EmbedHeight = v.Player.EmbedHeight
+	}
+	publishedAt, err := time.Parse(time.RFC3339, v.Snippet.PublishedAt)
+	if err != nil {
+		publishedAt = time.Now()
+	}
+	info.PublishedAt = publishedAt
+
+	live := v.LiveStreamingDetails
+	if live != nil {
+		info.BroadcastState = BroadcastCompleted
+		if t, err := time.Parse(time.RFC3339, live.ScheduledStartTime); err == nil {
+			info.ScheduledStartAt = t
+			info.PublishedAt = t
+		}
+		if t, err := time.Parse(time.RFC3339, live.ActualStartTime); err == nil {
+			info.PublishedAt = t
+		}
+	}
+	switch v.Snippet.LiveBroadcastContent {
+	case BroadcastUpcoming, BroadcastLive:
+		info.BroadcastState = v.Snippet.LiveBroadcastContent
+		info.Duration = ""
+	}
+	return info
+}
+
+// EnrichVideos fills in the durations and thumbnails of videos found through
+// a feed. Videos the Data API doesn't return are kept as they are.
+func (c *Client) EnrichVideos(ctx context.Context, videos []VideoInfo) ([]VideoInfo, error) {
+	if len(videos) == 0 {
+		return videos, nil
+	}
+	ids := make([]string, len(videos))
+	for i, v := range videos {
+		ids[i] = v.ID
+	}
+	details, err := c.GetVideos(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]VideoInfo, len(details))
+	for _, d := range details {
+		byID[d.ID] = d
+	}
+
+	result := make([]VideoInfo, len(videos))
+	for i, v := range videos {
+		if d, ok := byID[v.ID]; ok {
+			v = d
+		}
+		result[i] = v
+	}
+	return result, nil
 }
 
 func getBestThumbnail(t *youtube.ThumbnailDetails) string {
@@ -194,26 +484,35 @@ func getBestThumbnail(t *youtube.ThumbnailDetails) string {
 	return ""
 }
 
-// IsShort checks if a video is a YouTube Short by making a HEAD request
-// to the /shorts/ URL. Returns true if it's a Short, false otherwise.
-func (c *Client) IsShort(ctx context.Context, videoID string) bool {
-	url := fmt.Sprintf("https://www.youtube.com/shorts/%s", videoID)
-	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+// ProbeShort asks youtube.com whether a video is a Short: the Shorts URL of
+// a Short answers 200, that of any other video redirects to /watch. Errors,
+// rate limiting and consent redirects leave the verdict unknown.
+func (c *Client) ProbeShort(ctx context.Context, videoID string) ShortsVerdict {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.shortsURL+videoID, nil)
 	if err != nil {
-		return false
+		return ShortsUnknown
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return false
+		return ShortsUnknown
 	}
 	defer resp.Body.Close()
 
-	// 200 = it's a Short, 303 redirect = not a Short
-	return resp.StatusCode == http.StatusOK
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return ShortsYes
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		loc, err := resp.Location()
+		if err == nil && loc.Path == "/watch" {
+			return ShortsNo
+		}
+	}
+	return ShortsUnknown
 }
 
-// CheckShortsParallel checks multiple videos for Short status in parallel
+// CheckShortsParallel classifies videos as Shorts. Metadata decides where it
+// can; the remaining videos are probed, ten at a time.
 func (c *Client) CheckShortsParallel(ctx context.Context, videos []VideoInfo) []VideoInfo {
 	var wg sync.WaitGroup
 	result := make([]VideoInfo, len(videos))
@@ -222,11 +521,17 @@ func (c *Client) CheckShortsParallel(ctx context.Context, videos []VideoInfo) []
 	sem := make(chan struct{}, 10)
 
 	for i := range result {
+		result[i].Short, result[i].ShortSource = ClassifyShort(result[i])
+		if result[i].Short != ShortsUnknown {
+			continue
+		}
 		wg.Add(1)
 		go func(idx int) {
 			sem <- struct{}{}
 			defer func() { <-sem; wg.Done() }()
-			result[idx].IsShort = c.IsShort(ctx, result[idx].ID)
+			if verdict := c.ProbeShort(ctx, result[idx].ID); verdict != ShortsUnknown {
+				result[idx].Short, result[idx].ShortSource = verdict, ShortsSourceProbe
+			}
 		}(i)
 	}
 
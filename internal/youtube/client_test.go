@@ -2,6 +2,7 @@ package youtube
 
 import (
 	"testing"
+	"time"
 
 	"google.golang.org/api/youtube/v3"
 )
@@ -71,3 +72,102 @@ func TestGetBestThumbnail(t *testing.T) {
 		})
 	}
 }
+
+func TestVideoInfoBroadcastState(t *testing.T) {
+	scheduled := "2024-06-02T18:00:00Z"
+	tests := []struct {
+		name         string
+		content      string
+		live         *youtube.VideoLiveStreamingDetails
+		wantState    string
+		wantDuration string
+		wantDate     string
+	}{
+		{
+			name:         "regular upload",
+			content:      "none",
+			wantState:    BroadcastNone,
+			wantDuration: "PT4M13S",
+			wantDate:     "2024-06-01T12:00:00Z",
+		},
+		{
+			name:         "upcoming premiere",
+			content:      "upcoming",
+			live:         &youtube.VideoLiveStreamingDetails{ScheduledStartTime: scheduled},
+			wantState:    BroadcastUpcoming,
+			wantDuration: "",
+			wantDate:     scheduled,
+		},
+		{
+			name:    "live stream",
+			content: "live",
+			live: &youtube.VideoLiveStreamingDetails{
+				ScheduledStartTime: scheduled,
+				ActualStartTime:    "2024-06-02T18:03:00Z",
+			},
+			wantState:    BroadcastLive,
+			wantDuration: "",
+			wantDate:     "2024-06-02T18:03:00Z",
+		},
+		{
+			name:    "ended premiere",
+			content: "none",
+			live: &youtube.VideoLiveStreamingDetails{
+				ScheduledStartTime: scheduled,
+				ActualStartTime:    "2024-06-02T18:01:00Z",
+				ActualEndTime:      "2024-06-02T18:05:13Z",
+			},
+			wantState:    BroadcastCompleted,
+			wantDuration: "PT4M13S",
+			wantDate:     "2024-06-02T18:01:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := videoInfo(&youtube.Video{
+				Id: "vid",
+				Snippet: &youtube.VideoSnippet{
+					Title:                "Title",
+					PublishedAt:          "2024-06-01T12:00:00Z",
+					LiveBroadcastContent: tt.content,
+				},
+				ContentDetails:       &youtube.VideoContentDetails{Duration: "PT4M13S"},
+				LiveStreamingDetails: tt.live,
+			})
+			if info.BroadcastState != tt.wantState {
+				t.Errorf("BroadcastState = %q, want %q", info.BroadcastState, tt.wantState)
+			}
+			if info.Duration != tt.wantDuration {
+				t.Errorf("Duration = %q, want %q", info.Duration, tt.wantDuration)
+			}
+			if got := info.PublishedAt.Format(time.RFC3339); got != tt.wantDate {
+				t.Errorf("PublishedAt = %s, want %s", got, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestVideoInfoStatistics(t *testing.T) {
+	info := videoInfo(&youtube.Video{
+		Id: "vid",
+		Snippet: &youtube.VideoSnippet{
+			Title:       "Title",
+			Description: "First line\nSecond line",
+			PublishedAt: "2024-06-01T12:00:00Z",
+		},
+		Statistics: &youtube.VideoStatistics{ViewCount: 1500, LikeCount: 20, CommentCount: 3},
+	})
+	if info.Description != "First line\nSecond line" {
+		t.Errorf("Description = %q", info.Description)
+	}
+	want := VideoStatistics{Views: 1500, Likes: 20, Comments: 3}
+	if info.Statistics == nil || *info.Statistics != want {
+		t.Errorf("Statistics = %+v, want %+v", info.Statistics, want)
+	}
+
+	info = videoInfo(&youtube.Video{Id: "vid", Snippet: &youtube.VideoSnippet{PublishedAt: "2024-06-01T12:00:00Z"}})
+	if info.Statistics != nil {
+		t.Errorf("Statistics = %+v without statistics part, want nil", info.Statistics)
+	}
+}
@@ -0,0 +1,55 @@
+package youtube
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseDuration parses the ISO-8601 durations the Data API reports for
+// videos, such as "PT1H2M3S" or "P1DT2H". Years, months and weeks never
+// occur there and are rejected.
+func ParseDuration(iso string) (time.Duration, error) {
+	if len(iso) < 2 || iso[0] != 'P' {
+		return 0, fmt.Errorf("youtube: invalid duration %q", iso)
+	}
+
+	var total time.Duration
+	inTime, dateParts := false, 0
+	num, digits, parts := int64(0), 0, 0
+	for i := 1; i < len(iso); i++ {
+		c := iso[i]
+		switch {
+		case c >= '0' && c <= '9':
+			num = num*10 + int64(c-'0')
+			digits++
+			continue
+		case c == 'T' && !inTime && digits == 0:
+			inTime, dateParts = true, parts
+			continue
+		}
+
+		if digits == 0 {
+			return 0, fmt.Errorf("youtube: invalid duration %q", iso)
+		}
+		var unit time.Duration
+		switch {
+		case c == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case c == 'H' && inTime:
+			unit = time.Hour
+		case c == 'M' && inTime:
+			unit = time.Minute
+		case c == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("youtube: invalid duration %q", iso)
+		}
+		total += time.Duration(num) * unit
+		num, digits = 0, 0
+		parts++
+	}
+	if digits != 0 || parts == 0 || (inTime && parts == dateParts) {
+		return 0, fmt.Errorf("youtube: invalid duration %q", iso)
+	}
+	return total, nil
+}
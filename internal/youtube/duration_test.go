@@ -0,0 +1,40 @@
+package youtube
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"PT0S", 0},
+		{"PT45S", 45 * time.Second},
+		{"PT4M13S", 4*time.Minute + 13*time.Second},
+		{"PT1H", time.Hour},
+		{"PT1H2M3S", time.Hour + 2*time.Minute + 3*time.Second},
+		{"PT10H0M1S", 10*time.Hour + time.Second},
+		{"P1DT2H", 26 * time.Hour},
+		{"P0D", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseDurationInvalid(t *testing.T) {
+	for _, input := range []string{"", "P", "1H", "PT", "P1DT", "PTM", "PT5", "P1M", "PT1D", "P1Y2M", "PT1H2X"} {
+		if _, err := ParseDuration(input); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want error", input)
+		}
+	}
+}
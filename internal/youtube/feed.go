@@ -0,0 +1,150 @@
+package youtube
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Fetcher fetches the most recent videos of a channel or playlist. *Client
+// implements it on top of the Data API and *FeedFetcher on top of the public
+// Atom feeds.
+type Fetcher interface {
+	FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]VideoInfo, error)
+	FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]VideoInfo, error)
+}
+
+// DefaultFeedURL is the endpoint serving channel and playlist Atom feeds.
+const DefaultFeedURL = "https://www.youtube.com/feeds/videos.xml"
+
+// FeedFetcher reads the Atom feeds YouTube publishes for every channel and
+// playlist. Feeds cost no API quota but only list the 15 most recent entries
+// and carry no durations, so new videos still need EnrichVideos.
+type FeedFetcher struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewFeedFetcher returns a fetcher for the feeds at baseURL, which is
+// DefaultFeedURL outside of tests.
+func NewFeedFetcher(httpClient *http.Client, baseURL string) *FeedFetcher {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if baseURL == "" {
+		baseURL = DefaultFeedURL
+	}
+	return &FeedFetcher{httpClient: httpClient, baseURL: baseURL}
+}
+
+// FeedURL is the public feed of a channel or playlist, kind being the type
+// of its subscription. Feed readers subscribe to these.
+func FeedURL(kind, id string) string {
+	param := "channel_id"
+	if kind == "playlist" {
+		param = "playlist_id"
+	}
+	return DefaultFeedURL + "?" + url.Values{param: {id}}.Encode()
+}
+
+// ParseFeedURL finds the channel, playlist or legacy user a YouTube feed URL
+// is for. Other URLs aren't feeds, though ParseLink may recognize them.
+func ParseFeedURL(raw string) (Link, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Link{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if !youtubeHosts[host] || u.Path != "/feeds/videos.xml" {
+		return Link{}, false
+	}
+	q := u.Query()
+	switch {
+	case q.Has("channel_id"):
+		return matchLink(LinkChannel, q.Get("channel_id"), channelIDPattern)
+	case q.Has("playlist_id"):
+		return matchLink(LinkPlaylist, q.Get("playlist_id"), playlistIDPattern)
+	case q.Has("user"):
+		return matchLink(LinkUsername, q.Get("user"), namePattern)
+	}
+	return Link{}, false
+}
+
+func (f *FeedFetcher) FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]VideoInfo, error) {
+	return f.fetch(ctx, "channel_id", channelID, maxResults)
+}
+
+func (f *FeedFetcher) FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]VideoInfo, error) {
+	return f.fetch(ctx, "playlist_id", playlistID, maxResults)
+}
+
+func (f *FeedFetcher) fetch(ctx context.Context, param, id string, maxResults int64) ([]VideoInfo, error) {
+	u, err := url.Parse(f.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: feed url: %w", err)
+	}
+	q := u.Query()
+	q.Set(param, id)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: feed request: %w", err)
+	}
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("youtube: fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("youtube: fetch feed %s=%s: status %d", param, id, resp.StatusCode)
+	}
+
+	var feed atomFeed
+	if err := xml.NewDecoder(resp.Body).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("youtube: parse feed: %w", err)
+	}
+
+	videos := make([]VideoInfo, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		if maxResults > 0 && int64(len(videos)) >= maxResults {
+			break
+		}
+		if e.VideoID == "" {
+			continue
+		}
+		publishedAt, err := time.Parse(time.RFC3339, e.Published)
+		if err != nil {
+			publishedAt = time.Now()
+		}
+		videos = append(videos, VideoInfo{
+			ID:           e.VideoID,
+			Title:        e.Title,
+			ThumbnailURL: e.Group.Thumbnail.URL,
+			PublishedAt:  publishedAt,
+		})
+	}
+	return videos, nil
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Entries []atomEntry `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+type atomEntry struct {
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	ChannelID string `xml:"http://www.youtube.com/xml/schemas/2015 channelId"`
+	Title     string `xml:"http://www.w3.org/2005/Atom title"`
+	Published string `xml:"http://www.w3.org/2005/Atom published"`
+	Group     struct {
+		Thumbnail struct {
+			URL string `xml:"url,attr"`
+		} `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
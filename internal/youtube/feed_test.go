@@ -0,0 +1,108 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func newFeedServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	fixture, err := os.ReadFile("testdata/channel_feed.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		if r.URL.Query().Get("channel_id") == "UCmissing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/atom+xml")
+		_, _ = w.Write(fixture)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &queries
+}
+
+func TestFeedFetcherChannel(t *testing.T) {
+	srv, queries := newFeedServer(t)
+	f := NewFeedFetcher(srv.Client(), srv.URL)
+
+	videos, err := f.FetchChannelVideos(context.Background(), "UCtestchannel000000000000", 20)
+	if err != nil {
+		t.Fatalf("FetchChannelVideos() error = %v", err)
+	}
+	if got, want := (*queries)[0], "channel_id=UCtestchannel000000000000"; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+	if len(videos) != 2 {
+		t.Fatalf("got %d videos, want 2", len(videos))
+	}
+
+	want := VideoInfo{
+		ID:           "vid00000002",
+		Title:        "Second upload & more",
+		ThumbnailURL: "https://i2.ytimg.com/vi/vid00000002/hqdefault.jpg",
+		PublishedAt:  time.Date(2024, 5, 2, 15, 30, 0, 0, time.UTC),
+	}
+	got := videos[0]
+	if got.ID != want.ID || got.Title != want.Title || got.ThumbnailURL != want.ThumbnailURL || !got.PublishedAt.Equal(want.PublishedAt) {
+		t.Errorf("videos[0] = %+v, want %+v", got, want)
+	}
+	if got.Duration != "" {
+		t.Errorf("videos[0].Duration = %q, want empty until enriched", got.Duration)
+	}
+}
+
+func TestFeedFetcherPlaylistMaxResults(t *testing.T) {
+	srv, queries := newFeedServer(t)
+	f := NewFeedFetcher(srv.Client(), srv.URL)
+
+	videos, err := f.FetchPlaylistVideos(context.Background(), "PLtest", 1)
+	if err != nil {
+		t.Fatalf("FetchPlaylistVideos() error = %v", err)
+	}
+	if got, want := (*queries)[0], "playlist_id=PLtest"; got != want {
+		t.Errorf("query = %q, want %q", got, want)
+	}
+	if len(videos) != 1 || videos[0].ID != "vid00000002" {
+		t.Errorf("videos = %+v, want only vid00000002", videos)
+	}
+}
+
+func TestFeedFetcherNotFound(t *testing.T) {
+	srv, _ := newFeedServer(t)
+	f := NewFeedFetcher(srv.Client(), srv.URL)
+
+	if _, err := f.FetchChannelVideos(context.Background(), "UCmissing", 20); err == nil {
+		t.Error("FetchChannelVideos() error = nil, want error for 404")
+	}
+}
+
+func TestFeedURL(t *testing.T) {
+	const channelID = "UCabcdefghijklmnopqrstuv"
+	for _, tt := range []struct{ kind, id string }{{"channel", channelID}, {"playlist", "PLcourse0000"}} {
+		link, ok := ParseFeedURL(FeedURL(tt.kind, tt.id))
+		if !ok || link.Kind != tt.kind || link.ID != tt.id {
+			t.Errorf("ParseFeedURL(FeedURL(%q, %q)) = %+v, %v", tt.kind, tt.id, link, ok)
+		}
+	}
+	if link, ok := ParseFeedURL("http://youtube.com/feeds/videos.xml?user=olduser"); !ok || link != (Link{LinkUsername, "olduser"}) {
+		t.Errorf("user feed = %+v, %v", link, ok)
+	}
+	for _, raw := range []string{
+		"https://www.youtube.com/@handle",
+		"https://example.com/feeds/videos.xml?channel_id=" + channelID,
+		"https://www.youtube.com/feeds/videos.xml?channel_id=UCshort",
+		"https://www.youtube.com/feeds/videos.xml",
+	} {
+		if link, ok := ParseFeedURL(raw); ok {
+			t.Errorf("ParseFeedURL(%q) = %+v, want no feed", raw, link)
+		}
+	}
+}
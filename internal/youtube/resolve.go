@@ -0,0 +1,192 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+// Kinds of Link.
+const (
+	// LinkChannel is a channel ID, as in /channel/UC….
+	LinkChannel = "channel"
+	// LinkHandle is an @handle, including the @.
+	LinkHandle = "handle"
+	// LinkUsername is a legacy /user/ name.
+	LinkUsername = "username"
+	// LinkCustom is a legacy /c/ custom URL.
+	LinkCustom   = "custom"
+	LinkPlaylist = "playlist"
+	LinkVideo    = "video"
+)
+
+// Link is a channel, playlist or video a user pasted, as found by ParseLink.
+type Link struct {
+	Kind string
+	ID   string
+}
+
+// ErrNotFound is returned by Resolve when the link leads nowhere.
+var ErrNotFound = errors.New("youtube: no channel or playlist found")
+
+var (
+	channelIDPattern  = regexp.MustCompile(`^UC[\w-]{22}$`)
+	handlePattern     = regexp.MustCompile(`^@[\p{L}\p{N}_.\-·]+$`)
+	videoIDPattern    = regexp.MustCompile(`^[\w-]{11}$`)
+	playlistIDPattern = regexp.MustCompile(`^[\w-]{12,}$`)
+	namePattern       = regexp.MustCompile(`^[\p{L}\p{N}_.\-]+$`)
+)
+
+// youtubeHosts are the hosts serving youtube.com pages, after "www." is
+// dropped.
+var youtubeHosts = map[string]bool{
+	"youtube.com":       true,
+	"m.youtube.com":     true,
+	"music.youtube.com": true,
+}
+
+// ParseLink recognizes the ways of pointing at a channel or playlist: channel
+// URLs by ID, @handle, /c/ or /user/ name, playlist URLs and video URLs,
+// which stand for their uploader. A bare @handle or channel ID works too, as
+// do links without a scheme. Anything else is not a link and should be
+// searched for instead.
+func ParseLink(input string) (Link, bool) {
+	s := strings.TrimSpace(input)
+	switch {
+	case handlePattern.MatchString(s):
+		return Link{Kind: LinkHandle, ID: s}, true
+	case channelIDPattern.MatchString(s):
+		return Link{Kind: LinkChannel, ID: s}, true
+	}
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return Link{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	if host == "youtu.be" {
+		return matchLink(LinkVideo, segments[0], videoIDPattern)
+	}
+	if !youtubeHosts[host] {
+		return Link{}, false
+	}
+	next := ""
+	if len(segments) > 1 {
+		next = segments[1]
+	}
+	switch first := segments[0]; {
+	case strings.HasPrefix(first, "@"):
+		return matchLink(LinkHandle, first, handlePattern)
+	case first == "channel":
+		return matchLink(LinkChannel, next, channelIDPattern)
+	case first == "c":
+		return matchLink(LinkCustom, next, namePattern)
+	case first == "user":
+		return matchLink(LinkUsername, next, namePattern)
+	case first == "playlist":
+		return matchLink(LinkPlaylist, u.Query().Get("list"), playlistIDPattern)
+	case first == "watch":
+		return matchLink(LinkVideo, u.Query().Get("v"), videoIDPattern)
+	case first == "shorts", first == "live", first == "embed", first == "v":
+		return matchLink(LinkVideo, next, videoIDPattern)
+	}
+	return Link{}, false
+}
+
+func matchLink(kind, id string, pattern *regexp.Regexp) (Link, bool) {
+	if !pattern.MatchString(id) {
+		return Link{}, false
+	}
+	return Link{Kind: kind, ID: id}, true
+}
+
+// Resolve looks up the channel or playlist link points at, for a few quota
+// units where a search costs a hundred. Legacy /c/ names are tried as a
+// handle first, which YouTube migrated most of them to, then as a username.
+func (c *Client) Resolve(ctx context.Context, link Link) (SearchResult, error) {
+	switch link.Kind {
+	case LinkChannel:
+		return c.findChannel(ctx, func(call *youtube.ChannelsListCall) *youtube.ChannelsListCall {
+			return call.Id(link.ID)
+		})
+	case LinkHandle:
+		return c.findChannel(ctx, func(call *youtube.ChannelsListCall) *youtube.ChannelsListCall {
+			return call.ForHandle(link.ID)
+		})
+	case LinkUsername:
+		return c.findChannel(ctx, func(call *youtube.ChannelsListCall) *youtube.ChannelsListCall {
+			return call.ForUsername(link.ID)
+		})
+	case LinkCustom:
+		result, err := c.Resolve(ctx, Link{Kind: LinkHandle, ID: "@" + link.ID})
+		if !errors.Is(err, ErrNotFound) {
+			return result, err
+		}
+		return c.Resolve(ctx, Link{Kind: LinkUsername, ID: link.ID})
+	case LinkPlaylist:
+		return c.findPlaylist(ctx, link.ID)
+	case LinkVideo:
+		return c.findUploader(ctx, link.ID)
+	}
+	return SearchResult{}, fmt.Errorf("youtube: unknown link kind %q", link.Kind)
+}
+
+// findChannel runs a channels.list call narrowed down by filter.
+func (c *Client) findChannel(ctx context.Context, filter func(*youtube.ChannelsListCall) *youtube.ChannelsListCall) (SearchResult, error) {
+	if err := c.charge(ctx, "channels.list", costChannelsList); err != nil {
+		return SearchResult{}, err
+	}
+	resp, err := filter(c.service.Channels.List([]string{"snippet"})).Context(ctx).Do()
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("youtube: find channel: %w", err)
+	}
+	if len(resp.Items) == 0 {
+		return SearchResult{}, ErrNotFound
+	}
+	info := channelInfo(resp.Items[0])
+	return SearchResult{ID: info.ID, Title: info.Title, ThumbnailURL: info.ThumbnailURL, Type: "channel"}, nil
+}
+
+func (c *Client) findPlaylist(ctx context.Context, playlistID string) (SearchResult, error) {
+	if err := c.charge(ctx, "playlists.list", costPlaylistsList); err != nil {
+		return SearchResult{}, err
+	}
+	resp, err := c.service.Playlists.List([]string{"snippet"}).Id(playlistID).Context(ctx).Do()
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("youtube: find playlist: %w", err)
+	}
+	if len(resp.Items) == 0 || resp.Items[0].Snippet == nil {
+		return SearchResult{}, ErrNotFound
+	}
+	item := resp.Items[0]
+	return SearchResult{
+		ID:           item.Id,
+		Title:        item.Snippet.Title,
+		ThumbnailURL: getBestThumbnail(item.Snippet.Thumbnails),
+		Type:         "playlist",
+	}, nil
+}
+
+// findUploader resolves a video to the channel that uploaded it.
+func (c *Client) findUploader(ctx context.Context, videoID string) (SearchResult, error) {
+	if err := c.charge(ctx, "videos.list", costVideosList); err != nil {
+		return SearchResult{}, err
+	}
+	resp, err := c.service.Videos.List([]string{"snippet"}).Id(videoID).Context(ctx).Do()
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("youtube: find video: %w", err)
+	}
+	if len(resp.Items) == 0 || resp.Items[0].Snippet == nil {
+		return SearchResult{}, ErrNotFound
+	}
+	return c.Resolve(ctx, Link{Kind: LinkChannel, ID: resp.Items[0].Snippet.ChannelId})
+}
@@ -0,0 +1,129 @@
+package youtube
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/youtube/v3"
+)
+
+func TestParseLink(t *testing.T) {
+	const channelID = "UCabcdefghijklmnopqrstuv"
+	tests := []struct {
+		input string
+		want  Link
+		ok    bool
+	}{
+		{"@veritasium", Link{LinkHandle, "@veritasium"}, true},
+		{"https://www.youtube.com/@veritasium", Link{LinkHandle, "@veritasium"}, true},
+		{"youtube.com/@veritasium/videos", Link{LinkHandle, "@veritasium"}, true},
+		{"https://m.youtube.com/@Vsauce", Link{LinkHandle, "@Vsauce"}, true},
+		{channelID, Link{LinkChannel, channelID}, true},
+		{"https://www.youtube.com/channel/" + channelID, Link{LinkChannel, channelID}, true},
+		{"https://www.youtube.com/channel/UCshort", Link{}, false},
+		{"https://www.youtube.com/c/LinusTechTips", Link{LinkCustom, "LinusTechTips"}, true},
+		{"https://www.youtube.com/user/pewdiepie", Link{LinkUsername, "pewdiepie"}, true},
+		{"https://www.youtube.com/playlist?list=PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf", Link{LinkPlaylist, "PLrAXtmErZgOeiKm4sgNOknGvNjby9efdf"}, true},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42", Link{LinkVideo, "dQw4w9WgXcQ"}, true},
+		{"https://youtu.be/dQw4w9WgXcQ?si=abc", Link{LinkVideo, "dQw4w9WgXcQ"}, true},
+		{"https://www.youtube.com/shorts/dQw4w9WgXcQ", Link{LinkVideo, "dQw4w9WgXcQ"}, true},
+		{"https://www.youtube.com/live/dQw4w9WgXcQ", Link{LinkVideo, "dQw4w9WgXcQ"}, true},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ", Link{LinkVideo, "dQw4w9WgXcQ"}, true},
+		{"  https://www.youtube.com/@veritasium  ", Link{LinkHandle, "@veritasium"}, true},
+		{"veritasium", Link{}, false},
+		{"science videos", Link{}, false},
+		{"https://example.com/@veritasium", Link{}, false},
+		{"https://www.youtube.com/feed/subscriptions", Link{}, false},
+		{"https://www.youtube.com/watch?v=short", Link{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLink(tt.input)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseLink(%q) = %+v, %v; want %+v, %v", tt.input, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// newResolveClient serves channels.list, playlists.list and videos.list for
+// one channel, @handle or user "handle", with one playlist and one video.
+func newResolveClient(t *testing.T) *Client {
+	t.Helper()
+	const channelID = "UCabcdefghijklmnopqrstuv"
+	channel := &youtube.Channel{Id: channelID, Snippet: &youtube.ChannelSnippet{Title: "Handle Channel"}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var resp any
+		switch r.URL.Path {
+		case "/youtube/v3/channels":
+			list := &youtube.ChannelListResponse{}
+			if q.Get("forHandle") == "@handle" || q.Get("forUsername") == "olduser" || q.Get("id") == channelID {
+				list.Items = []*youtube.Channel{channel}
+			}
+			resp = list
+		case "/youtube/v3/playlists":
+			list := &youtube.PlaylistListResponse{}
+			if q.Get("id") == "PLcourse0000" {
+				list.Items = []*youtube.Playlist{{Id: "PLcourse0000", Snippet: &youtube.PlaylistSnippet{Title: "A Course"}}}
+			}
+			resp = list
+		case "/youtube/v3/videos":
+			list := &youtube.VideoListResponse{}
+			if q.Get("id") == "dQw4w9WgXcQ" {
+				list.Items = []*youtube.Video{{Id: "dQw4w9WgXcQ", Snippet: &youtube.VideoSnippet{ChannelId: channelID}}}
+			}
+			resp = list
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	service, err := youtube.NewService(context.Background(),
+		option.WithEndpoint(srv.URL+"/"), option.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{service: service}
+}
+
+func TestResolve(t *testing.T) {
+	c := newResolveClient(t)
+	ctx := context.Background()
+	tests := []struct {
+		link      Link
+		wantTitle string
+		wantType  string
+	}{
+		{Link{LinkHandle, "@handle"}, "Handle Channel", "channel"},
+		{Link{LinkChannel, "UCabcdefghijklmnopqrstuv"}, "Handle Channel", "channel"},
+		{Link{LinkUsername, "olduser"}, "Handle Channel", "channel"},
+		// Custom URLs are tried as a handle, then as a username.
+		{Link{LinkCustom, "handle"}, "Handle Channel", "channel"},
+		{Link{LinkCustom, "olduser"}, "Handle Channel", "channel"},
+		{Link{LinkPlaylist, "PLcourse0000"}, "A Course", "playlist"},
+		{Link{LinkVideo, "dQw4w9WgXcQ"}, "Handle Channel", "channel"},
+	}
+	for _, tt := range tests {
+		got, err := c.Resolve(ctx, tt.link)
+		if err != nil {
+			t.Errorf("Resolve(%+v) error = %v", tt.link, err)
+			continue
+		}
+		if got.Title != tt.wantTitle || got.Type != tt.wantType {
+			t.Errorf("Resolve(%+v) = %+v, want %s %q", tt.link, got, tt.wantType, tt.wantTitle)
+		}
+	}
+
+	for _, link := range []Link{{LinkHandle, "@nobody"}, {LinkCustom, "nobody"}, {LinkPlaylist, "PLmissing000"}, {LinkVideo, "aaaaaaaaaaa"}} {
+		if _, err := c.Resolve(ctx, link); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Resolve(%+v) error = %v, want ErrNotFound", link, err)
+		}
+	}
+}
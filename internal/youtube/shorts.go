@@ -0,0 +1,43 @@
+package youtube
+
+import "time"
+
+// ShortsVerdict classifies a video as a Short or not. ShortsUnknown means no
+// signal was conclusive and the video should be checked again later.
+type ShortsVerdict string
+
+const (
+	ShortsUnknown ShortsVerdict = ""
+	ShortsYes     ShortsVerdict = "short"
+	ShortsNo      ShortsVerdict = "not_short"
+)
+
+// Sources of a ShortsVerdict, stored alongside it.
+const (
+	ShortsSourceDuration = "duration"
+	ShortsSourceAspect   = "aspect"
+	ShortsSourceProbe    = "probe"
+)
+
+// maxShortDuration is the longest a Short can be.
+const maxShortDuration = 3 * time.Minute
+
+// ClassifyShort decides from a video's metadata alone whether it is a Short.
+// Anything longer than three minutes is not; otherwise a landscape player is
+// not a Short and a portrait one is. Without a duration or player size the
+// verdict is unknown and the video has to be probed.
+func ClassifyShort(v VideoInfo) (ShortsVerdict, string) {
+	d, err := ParseDuration(v.Duration)
+	if err == nil && d > maxShortDuration {
+		return ShortsNo, ShortsSourceDuration
+	}
+	if v.EmbedWidth > 0 && v.EmbedHeight > 0 {
+		if v.EmbedWidth >= v.EmbedHeight {
+			return ShortsNo, ShortsSourceAspect
+		}
+		if err == nil && d > 0 {
+			return ShortsYes, ShortsSourceAspect
+		}
+	}
+	return ShortsUnknown, ""
+}
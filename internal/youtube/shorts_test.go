@@ -0,0 +1,75 @@
+package youtube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClassifyShort(t *testing.T) {
+	tests := []struct {
+		name       string
+		v          VideoInfo
+		want       ShortsVerdict
+		wantSource string
+	}{
+		{"long", VideoInfo{Duration: "PT10M"}, ShortsNo, ShortsSourceDuration},
+		{"long portrait", VideoInfo{Duration: "PT4M", EmbedWidth: 270, EmbedHeight: 480}, ShortsNo, ShortsSourceDuration},
+		{"short landscape", VideoInfo{Duration: "PT50S", EmbedWidth: 480, EmbedHeight: 270}, ShortsNo, ShortsSourceAspect},
+		{"short portrait", VideoInfo{Duration: "PT50S", EmbedWidth: 270, EmbedHeight: 480}, ShortsYes, ShortsSourceAspect},
+		{"three minutes portrait", VideoInfo{Duration: "PT3M", EmbedWidth: 270, EmbedHeight: 480}, ShortsYes, ShortsSourceAspect},
+		{"short without player", VideoInfo{Duration: "PT50S"}, ShortsUnknown, ""},
+		{"portrait without duration", VideoInfo{EmbedWidth: 270, EmbedHeight: 480}, ShortsUnknown, ""},
+		{"nothing known", VideoInfo{}, ShortsUnknown, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, source := ClassifyShort(tt.v)
+			if got != tt.want || source != tt.wantSource {
+				t.Errorf("ClassifyShort() = %q, %q; want %q, %q", got, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestProbeShort(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/shorts/short":
+			w.WriteHeader(http.StatusOK)
+		case "/shorts/video":
+			http.Redirect(w, r, "/watch?v=video", http.StatusSeeOther)
+		case "/shorts/consent":
+			http.Redirect(w, r, "https://consent.youtube.com/m?continue=x", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{
+		httpClient: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		shortsURL: srv.URL + "/shorts/",
+	}
+	tests := map[string]ShortsVerdict{
+		"short":   ShortsYes,
+		"video":   ShortsNo,
+		"consent": ShortsUnknown,
+		"limited": ShortsUnknown,
+	}
+	for id, want := range tests {
+		if got := c.ProbeShort(context.Background(), id); got != want {
+			t.Errorf("ProbeShort(%q) = %q, want %q", id, got, want)
+		}
+	}
+
+	c.shortsURL = "http://127.0.0.1:0/shorts/"
+	if got := c.ProbeShort(context.Background(), "short"); got != ShortsUnknown {
+		t.Errorf("ProbeShort() without a connection = %q, want unknown", got)
+	}
+}
@@ -0,0 +1,23 @@
+package youtube
+
+import "context"
+
+// VideoSource is the part of the Data API the handlers depend on. *Client
+// implements it; youtubetest.Fake is an in-memory implementation for tests.
+type VideoSource interface {
+	SearchChannels(ctx context.Context, query string, maxResults int64) ([]SearchResult, error)
+	SearchPlaylists(ctx context.Context, query string, maxResults int64) ([]SearchResult, error)
+	GetChannels(ctx context.Context, ids []string) ([]ChannelInfo, error)
+	Resolve(ctx context.Context, link Link) (SearchResult, error)
+	FetchPlaylistVideosWithToken(ctx context.Context, playlistID string, pageToken string, maxResults int64) (*FetchResult, error)
+	PlaylistPositions(ctx context.Context, playlistID string) (map[string]int64, error)
+	GetVideos(ctx context.Context, ids []string) ([]VideoInfo, error)
+	EnrichVideos(ctx context.Context, videos []VideoInfo) ([]VideoInfo, error)
+	CheckShortsParallel(ctx context.Context, videos []VideoInfo) []VideoInfo
+}
+
+var (
+	_ VideoSource = (*Client)(nil)
+	_ Fetcher     = (*Client)(nil)
+	_ Fetcher     = (*FeedFetcher)(nil)
+)
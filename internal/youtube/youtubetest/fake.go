@@ -0,0 +1,382 @@
+// Package youtubetest provides an in-memory stand-in for the YouTube Data API
+// and feeds, for tests that must not touch the network.
+package youtubetest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"youtube-deck-go/internal/youtube"
+)
+
+// Fake implements youtube.VideoSource, youtube.Fetcher and
+// youtube.AccountSource. Playlists hold
+// their videos newest first and are paged with numeric page tokens; a
+// channel's uploads are the playlist named by its UploadsPlaylistID.
+type Fake struct {
+	mu        sync.Mutex
+	channels  map[string]youtube.ChannelInfo
+	playlists map[string][]youtube.VideoInfo
+	shorts    map[string]bool
+	noProbe   map[string]bool
+	searches  []youtube.SearchResult
+	mySubs    []youtube.SearchResult
+	calls     map[string]int
+
+	// Err, when set, is returned by every Data API call that can fail.
+	Err error
+	// FeedErr, when set, is returned by the feed methods.
+	FeedErr error
+}
+
+func NewFake() *Fake {
+	return &Fake{
+		channels:  make(map[string]youtube.ChannelInfo),
+		playlists: make(map[string][]youtube.VideoInfo),
+		shorts:    make(map[string]bool),
+		noProbe:   make(map[string]bool),
+		calls:     make(map[string]int),
+	}
+}
+
+// AddChannel registers a channel. Its uploads playlist defaults to the
+// channel ID with the "UC" prefix replaced by "UU", as on YouTube.
+func (f *Fake) AddChannel(c youtube.ChannelInfo) youtube.ChannelInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c.UploadsPlaylistID == "" {
+		c.UploadsPlaylistID = "UU" + strings.TrimPrefix(c.ID, "UC")
+	}
+	f.channels[c.ID] = c
+	f.searches = append(f.searches, youtube.SearchResult{
+		ID: c.ID, Title: c.Title, ThumbnailURL: c.ThumbnailURL, Type: "channel",
+	})
+	return c
+}
+
+// AddPlaylist registers a playlist so that it shows up in playlist searches.
+func (f *Fake) AddPlaylist(id, title string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.searches = append(f.searches, youtube.SearchResult{ID: id, Title: title, Type: "playlist"})
+}
+
+// AddVideos prepends videos, given newest first, to a playlist.
+func (f *Fake) AddVideos(playlistID string, videos ...youtube.VideoInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.playlists[playlistID] = append(slices.Clone(videos), f.playlists[playlistID]...)
+}
+
+// UpdateVideo replaces the stored details of a video, wherever it is listed,
+// as when a premiere ends.
+func (f *Fake) UpdateVideo(v youtube.VideoInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, videos := range f.playlists {
+		for i := range videos {
+			if videos[i].ID == v.ID {
+				videos[i] = v
+			}
+		}
+	}
+}
+
+// DeleteVideo takes a video down, as when its uploader deletes it or makes it
+// private: it disappears from playlists and GetVideos.
+func (f *Fake) DeleteVideo(videoID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, videos := range f.playlists {
+		f.playlists[id] = slices.DeleteFunc(videos, func(v youtube.VideoInfo) bool {
+			return v.ID == videoID
+		})
+	}
+}
+
+// ReorderPlaylist moves the videos named by videoIDs to the front of a
+// playlist, in that order. The others keep their order behind them.
+func (f *Fake) ReorderPlaylist(playlistID string, videoIDs ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var front, rest []youtube.VideoInfo
+	for _, id := range videoIDs {
+		for _, v := range f.playlists[playlistID] {
+			if v.ID == id {
+				front = append(front, v)
+			}
+		}
+	}
+	for _, v := range f.playlists[playlistID] {
+		if !slices.Contains(videoIDs, v.ID) {
+			rest = append(rest, v)
+		}
+	}
+	f.playlists[playlistID] = append(front, rest...)
+}
+
+// SetShort marks a video as a Short for CheckShortsParallel.
+func (f *Fake) SetShort(videoID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.shorts[videoID] = true
+}
+
+// SetProbeFails makes probing a video inconclusive, as when youtube.com
+// can't be reached, until it is called again with fails false.
+func (f *Fake) SetProbeFails(videoID string, fails bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.noProbe[videoID] = fails
+}
+
+// SetMySubscriptions replaces the channels the signed-in account subscribes
+// to.
+func (f *Fake) SetMySubscriptions(subs ...youtube.SearchResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mySubs = slices.Clone(subs)
+}
+
+// Calls returns how often the named method has been called.
+func (f *Fake) Calls(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *Fake) record(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	return f.Err
+}
+
+func (f *Fake) recordFeed(method string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[method]++
+	return f.FeedErr
+}
+
+func (f *Fake) SearchChannels(ctx context.Context, query string, maxResults int64) ([]youtube.SearchResult, error) {
+	return f.search("SearchChannels", "channel", query, maxResults)
+}
+
+func (f *Fake) SearchPlaylists(ctx context.Context, query string, maxResults int64) ([]youtube.SearchResult, error) {
+	return f.search("SearchPlaylists", "playlist", query, maxResults)
+}
+
+func (f *Fake) search(method, kind, query string, maxResults int64) ([]youtube.SearchResult, error) {
+	if err := f.record(method); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var results []youtube.SearchResult
+	for _, r := range f.searches {
+		if int64(len(results)) >= maxResults {
+			break
+		}
+		if r.Type == kind && strings.Contains(strings.ToLower(r.Title), strings.ToLower(query)) {
+			results = append(results, r)
+		}
+	}
+	return results, nil
+}
+
+func (f *Fake) GetChannels(ctx context.Context, ids []string) ([]youtube.ChannelInfo, error) {
+	if err := f.record("GetChannels"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var channels []youtube.ChannelInfo
+	for _, id := range ids {
+		if c, ok := f.channels[id]; ok {
+			channels = append(channels, c)
+		}
+	}
+	return channels, nil
+}
+
+// MySubscriptions reports its progress once, as if the account's
+// subscriptions fit on one page.
+func (f *Fake) MySubscriptions(ctx context.Context, progress func(done, total int64)) ([]youtube.SearchResult, error) {
+	if err := f.record("MySubscriptions"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	subs := slices.Clone(f.mySubs)
+	f.mu.Unlock()
+	if progress != nil {
+		progress(int64(len(subs)), int64(len(subs)))
+	}
+	return subs, nil
+}
+
+// Resolve finds registered channels by ID, handle (their CustomURL) or
+// username (the handle without @), and registered playlists by ID. Videos
+// resolve to the channel whose uploads list them.
+func (f *Fake) Resolve(ctx context.Context, link youtube.Link) (youtube.SearchResult, error) {
+	if err := f.record("Resolve"); err != nil {
+		return youtube.SearchResult{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if link.Kind == youtube.LinkPlaylist {
+		for _, r := range f.searches {
+			if r.Type == "playlist" && r.ID == link.ID {
+				return r, nil
+			}
+		}
+		return youtube.SearchResult{}, youtube.ErrNotFound
+	}
+	for _, c := range f.channels {
+		var match bool
+		switch link.Kind {
+		case youtube.LinkChannel:
+			match = c.ID == link.ID
+		case youtube.LinkHandle:
+			match = strings.EqualFold(c.CustomURL, link.ID)
+		case youtube.LinkUsername, youtube.LinkCustom:
+			match = strings.EqualFold(c.CustomURL, "@"+link.ID)
+		case youtube.LinkVideo:
+			match = slices.ContainsFunc(f.playlists[c.UploadsPlaylistID], func(v youtube.VideoInfo) bool {
+				return v.ID == link.ID
+			})
+		}
+		if match {
+			return youtube.SearchResult{ID: c.ID, Title: c.Title, ThumbnailURL: c.ThumbnailURL, Type: "channel"}, nil
+		}
+	}
+	return youtube.SearchResult{}, youtube.ErrNotFound
+}
+
+func (f *Fake) FetchPlaylistVideosWithToken(ctx context.Context, playlistID string, pageToken string, maxResults int64) (*youtube.FetchResult, error) {
+	if err := f.record("FetchPlaylistVideosWithToken"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	start := 0
+	if pageToken != "" {
+		n, err := strconv.Atoi(pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("youtubetest: invalid page token %q", pageToken)
+		}
+		start = n
+	}
+	videos := f.playlists[playlistID]
+	start = min(start, len(videos))
+	end := min(start+int(maxResults), len(videos))
+
+	result := &youtube.FetchResult{Videos: slices.Clone(videos[start:end])}
+	for i := range result.Videos {
+		pos := int64(start + i)
+		result.Videos[i].PlaylistPosition = &pos
+	}
+	if end < len(videos) {
+		result.NextPageToken = strconv.Itoa(end)
+	}
+	return result, nil
+}
+
+func (f *Fake) PlaylistPositions(ctx context.Context, playlistID string) (map[string]int64, error) {
+	if err := f.record("PlaylistPositions"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	positions := make(map[string]int64)
+	for i, v := range f.playlists[playlistID] {
+		positions[v.ID] = int64(i)
+	}
+	return positions, nil
+}
+
+func (f *Fake) GetVideos(ctx context.Context, ids []string) ([]youtube.VideoInfo, error) {
+	if err := f.record("GetVideos"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var videos []youtube.VideoInfo
+	for _, id := range ids {
+	search:
+		for _, playlist := range f.playlists {
+			for _, v := range playlist {
+				if v.ID == id {
+					videos = append(videos, v)
+					break search
+				}
+			}
+		}
+	}
+	return videos, nil
+}
+
+func (f *Fake) EnrichVideos(ctx context.Context, videos []youtube.VideoInfo) ([]youtube.VideoInfo, error) {
+	if err := f.record("EnrichVideos"); err != nil {
+		return nil, err
+	}
+	return videos, nil
+}
+
+// CheckShortsParallel classifies videos from their metadata where it can, and
+// otherwise as the probe would: Shorts are those marked with SetShort.
+func (f *Fake) CheckShortsParallel(ctx context.Context, videos []youtube.VideoInfo) []youtube.VideoInfo {
+	_ = f.record("CheckShortsParallel")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := slices.Clone(videos)
+	for i := range result {
+		result[i].Short, result[i].ShortSource = youtube.ClassifyShort(result[i])
+		if result[i].Short != youtube.ShortsUnknown || f.noProbe[result[i].ID] {
+			continue
+		}
+		result[i].Short, result[i].ShortSource = youtube.ShortsNo, youtube.ShortsSourceProbe
+		if f.shorts[result[i].ID] {
+			result[i].Short = youtube.ShortsYes
+		}
+	}
+	return result
+}
+
+// FetchChannelVideos serves a channel's uploads the way its feed would.
+func (f *Fake) FetchChannelVideos(ctx context.Context, channelID string, maxResults int64) ([]youtube.VideoInfo, error) {
+	if err := f.recordFeed("FetchChannelVideos"); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	c, ok := f.channels[channelID]
+	f.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("youtubetest: channel not found: %s", channelID)
+	}
+	return f.latest(c.UploadsPlaylistID, maxResults), nil
+}
+
+func (f *Fake) FetchPlaylistVideos(ctx context.Context, playlistID string, maxResults int64) ([]youtube.VideoInfo, error) {
+	if err := f.recordFeed("FetchPlaylistVideos"); err != nil {
+		return nil, err
+	}
+	return f.latest(playlistID, maxResults), nil
+}
+
+func (f *Fake) latest(playlistID string, maxResults int64) []youtube.VideoInfo {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	videos := f.playlists[playlistID]
+	return slices.Clone(videos[:min(int(maxResults), len(videos))])
+}
+
+var (
+	_ youtube.VideoSource = (*Fake)(nil)
+	_ youtube.Fetcher     = (*Fake)(nil)
+)
@@ -144,6 +144,12 @@
         this.openSearch();
       }
 
+      // Search the library with / unless typing somewhere
+      if (e.key === '/' && !e.target.closest('input, textarea, select, [contenteditable]')) {
+        e.preventDefault();
+        this.openLibrarySearch();
+      }
+
       // Navigate with arrow keys when focused on list items
       if (e.key === 'ArrowDown' || e.key === 'ArrowUp') {
         this.navigateList(e);
@@ -164,6 +170,13 @@
       }
     },
 
+    openLibrarySearch() {
+      const libraryBtn = document.querySelector('[hx-get="/library"]');
+      if (libraryBtn) {
+        libraryBtn.click();
+      }
+    },
+
     navigateList(e) {
       const focusable = document.activeElement;
       const list = focusable?.closest('[role="listbox"], [role="list"]');
@@ -235,6 +248,7 @@
     init() {
       this.initSidebar();
       this.initDeck();
+      this.initQueues();
       this.bindHTMXEvents();
     },
 
@@ -248,6 +262,7 @@
       if (sidebarList && !sidebarList._sortable && typeof Sortable !== 'undefined') {
         sidebarList._sortable = new Sortable(sidebarList, {
           animation: 150,
+          draggable: '.sidebar__item',
           ghostClass: 'sortable-ghost',
           chosenClass: 'sortable-chosen',
           dragClass: 'sortable-drag',
@@ -296,6 +311,35 @@
       }
     },
 
+    // Every deck showing the watch-later column gets its own sortable list.
+    initQueues() {
+      if (typeof Sortable === 'undefined') return;
+      document.querySelectorAll('.queue-list').forEach(list => {
+        if (list._sortable) return;
+        list._sortable = new Sortable(list, {
+          animation: 150,
+          draggable: '.queue-item',
+          ghostClass: 'sortable-ghost',
+          chosenClass: 'sortable-chosen',
+          dragClass: 'sortable-drag',
+          onEnd: (evt) => {
+            const ids = [...evt.to.children]
+              .filter(el => el.dataset.id)
+              .map(el => el.dataset.id);
+
+            fetch('/subscriptions/reorder', {
+              method: 'POST',
+              headers: {
+                'Content-Type': 'application/json',
+                'X-CSRF-Token': this.getCsrfToken()
+              },
+              body: JSON.stringify({ ids, context: 'queue' })
+            }).catch(err => console.error('Reorder failed:', err));
+          }
+        });
+      });
+    },
+
     bindHTMXEvents() {
       document.body.addEventListener('htmx:afterSwap', (evt) => {
         if (evt.detail.target.id === 'deck-columns' || evt.detail.target.id === 'sidebar-list') {
@@ -311,11 +355,63 @@
         setTimeout(() => {
           this.initSidebar();
           this.initDeck();
+          this.initQueues();
         }, 0);
       });
     }
   };
 
+  // ===== LIVE DECK UPDATES =====
+  // The server pushes HTML fragments with hx-swap-oob attributes over
+  // Server-Sent Events; htmx applies them like any other OOB response.
+  const DeckEvents = {
+    source: null,
+    hadError: false,
+
+    init() {
+      if (!document.getElementById('deck-columns') || !('EventSource' in window) || typeof htmx === 'undefined') return;
+
+      this.source = new EventSource('/events');
+      this.source.onmessage = (e) => {
+        htmx.swap(document.body, e.data, { swapStyle: 'none' });
+      };
+      this.source.onerror = () => {
+        this.hadError = true;
+      };
+      this.source.onopen = () => {
+        // Updates published while we were disconnected are lost, so reload
+        // every column from the database once the stream is back.
+        if (this.hadError) {
+          this.hadError = false;
+          this.reloadColumns();
+        }
+      };
+
+      // Skip cards the target column already shows, e.g. when this tab's own
+      // refresh response arrived before the pushed update. The same video may
+      // legitimately appear in a subscription column and a river column.
+      document.body.addEventListener('htmx:oobBeforeSwap', (evt) => {
+        const fragment = evt.detail.fragment;
+        const target = evt.detail.target;
+        if (!fragment || !fragment.querySelectorAll || !target) return;
+        fragment.querySelectorAll('[id^="col-video-"], [id^="queue-video-"]').forEach(el => {
+          if (target.querySelector('#' + CSS.escape(el.id))) el.remove();
+        });
+      });
+
+      window.addEventListener('beforeunload', () => this.source.close());
+    },
+
+    reloadColumns() {
+      document.querySelectorAll('#deck-columns > .column[data-id]').forEach(col => {
+        htmx.ajax('GET', `/subscriptions/${col.dataset.id}/reload`, { target: col, swap: 'outerHTML' });
+      });
+      document.querySelectorAll('#river-columns > .column[data-river-id]').forEach(col => {
+        htmx.ajax('GET', `/columns/${col.dataset.riverId}`, { target: col, swap: 'outerHTML' });
+      });
+    }
+  };
+
   // ===== ACTIVE COLUMNS SECTION MANAGER =====
   const ActiveColumnsManager = {
     update() {
@@ -457,6 +553,7 @@
     AnimationObserver.init();
     LazyLoadManager.init();
     HTMXEventHandlers.init();
+    DeckEvents.init();
 
     // Initialize sortables after DOM is ready
     if (document.readyState === 'loading') {